@@ -0,0 +1,62 @@
+package autolock
+
+import (
+	"sync"
+	"time"
+)
+
+// Locker calls a lock function once the application has been idle for a while
+type Locker struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	timer   *time.Timer
+	onLock  func()
+}
+
+// NewLocker creates a locker that calls onLock after timeout without Activity.
+// A zero timeout never locks on its own. onLock is called on its own goroutine.
+func NewLocker(timeout time.Duration, onLock func()) *Locker {
+	l := &Locker{onLock: onLock}
+	l.SetTimeout(timeout)
+	return l
+}
+
+// Activity restarts the idle timer
+func (l *Locker) Activity() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.timer != nil {
+		l.timer.Reset(l.timeout)
+	}
+}
+
+// SetTimeout changes the idle timeout and restarts the timer. Zero disables it.
+func (l *Locker) SetTimeout(timeout time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stopLocked()
+	l.timeout = timeout
+	if timeout > 0 {
+		l.timer = time.AfterFunc(timeout, l.onLock)
+	}
+}
+
+// Lock calls the lock function right away, for example when the screen is locked
+func (l *Locker) Lock() {
+	l.onLock()
+}
+
+// Stop disables the idle timer
+func (l *Locker) Stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stopLocked()
+}
+
+// stopLocked stops the timer, the caller holds l.mu
+func (l *Locker) stopLocked() {
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+}
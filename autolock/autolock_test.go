@@ -0,0 +1,59 @@
+package autolock
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockerLocksWhenIdle(t *testing.T) {
+	var locks atomic.Int32
+	l := NewLocker(20*time.Millisecond, func() { locks.Add(1) })
+	defer l.Stop()
+
+	assert.Eventually(t, func() bool { return locks.Load() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestLockerActivityDelaysLock(t *testing.T) {
+	var locks atomic.Int32
+	l := NewLocker(50*time.Millisecond, func() { locks.Add(1) })
+	defer l.Stop()
+
+	for i := 0; i < 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		l.Activity()
+	}
+	assert.Equal(t, int32(0), locks.Load())
+
+	assert.Eventually(t, func() bool { return locks.Load() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestLockerDisabled(t *testing.T) {
+	var locks atomic.Int32
+	l := NewLocker(0, func() { locks.Add(1) })
+	defer l.Stop()
+
+	l.Activity()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, int32(0), locks.Load())
+
+	// Locking by hand still works
+	l.Lock()
+	assert.Equal(t, int32(1), locks.Load())
+}
+
+func TestLockerSetTimeout(t *testing.T) {
+	var locks atomic.Int32
+	l := NewLocker(time.Hour, func() { locks.Add(1) })
+	defer l.Stop()
+
+	l.SetTimeout(10 * time.Millisecond)
+	assert.Eventually(t, func() bool { return locks.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	l.SetTimeout(0)
+	l.Activity()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, int32(1), locks.Load())
+}
@@ -0,0 +1,87 @@
+package autolock
+
+import (
+	"errors"
+	"os"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Screen saver interfaces on the session bus that send ActiveChanged(bool)
+var screenSaverInterfaces = []string{"org.freedesktop.ScreenSaver", "org.gnome.ScreenSaver"}
+
+// logind interfaces on the system bus
+const (
+	logindManager   = "org.freedesktop.login1.Manager"
+	logindSession   = "org.freedesktop.login1.Session"
+	logindService   = "org.freedesktop.login1"
+	logindObject    = "/org/freedesktop/login1"
+	activeChanged   = "ActiveChanged"
+	logindLockEvent = "Lock"
+)
+
+// WatchScreenLock calls onLock whenever the session is locked, as reported by the
+// screen saver on the session bus or by logind on the system bus. onLock is
+// called on its own goroutine. Call stop to stop watching.
+func WatchScreenLock(onLock func()) (stop func(), err error) {
+	var conns []*dbus.Conn
+
+	if conn, err := dbus.ConnectSessionBus(); err == nil {
+		for _, iface := range screenSaverInterfaces {
+			conn.AddMatchSignal(dbus.WithMatchInterface(iface), dbus.WithMatchMember(activeChanged))
+		}
+		conns = append(conns, conn)
+	}
+
+	if conn, err := dbus.ConnectSystemBus(); err == nil {
+		// Only our own session matters, not other users locking theirs
+		var session dbus.ObjectPath
+		call := conn.Object(logindService, logindObject).Call(logindManager+".GetSessionByPID", 0, uint32(os.Getpid()))
+		if call.Store(&session) == nil {
+			conn.AddMatchSignal(dbus.WithMatchInterface(logindSession), dbus.WithMatchMember(logindLockEvent), dbus.WithMatchObjectPath(session))
+			conns = append(conns, conn)
+		} else {
+			conn.Close()
+		}
+	}
+
+	if len(conns) == 0 {
+		return nil, errors.New("neither the session bus nor logind is available")
+	}
+
+	for _, conn := range conns {
+		// Closing the connection closes the channel, which ends the goroutine
+		signals := make(chan *dbus.Signal, 8)
+		conn.Signal(signals)
+		go func() {
+			for signal := range signals {
+				if isLockSignal(signal) {
+					onLock()
+				}
+			}
+		}()
+	}
+
+	return func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}, nil
+}
+
+// isLockSignal reports whether a D-Bus signal means the session was locked
+func isLockSignal(signal *dbus.Signal) bool {
+	if signal == nil {
+		return false
+	}
+	if signal.Name == logindSession+"."+logindLockEvent {
+		return true
+	}
+	for _, iface := range screenSaverInterfaces {
+		if signal.Name == iface+"."+activeChanged && len(signal.Body) > 0 {
+			active, ok := signal.Body[0].(bool)
+			return ok && active
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package autolock
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLockSignal(t *testing.T) {
+	assert.True(t, isLockSignal(&dbus.Signal{Name: "org.freedesktop.ScreenSaver.ActiveChanged", Body: []interface{}{true}}))
+	assert.True(t, isLockSignal(&dbus.Signal{Name: "org.gnome.ScreenSaver.ActiveChanged", Body: []interface{}{true}}))
+	assert.True(t, isLockSignal(&dbus.Signal{Name: "org.freedesktop.login1.Session.Lock"}))
+
+	// Unlocking and unrelated signals do not lock
+	assert.False(t, isLockSignal(&dbus.Signal{Name: "org.freedesktop.ScreenSaver.ActiveChanged", Body: []interface{}{false}}))
+	assert.False(t, isLockSignal(&dbus.Signal{Name: "org.freedesktop.ScreenSaver.ActiveChanged"}))
+	assert.False(t, isLockSignal(&dbus.Signal{Name: "org.freedesktop.login1.Session.Unlock"}))
+	assert.False(t, isLockSignal(nil))
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package autolock
+
+import "errors"
+
+// WatchScreenLock is only supported on Linux, where the lock signal is sent over D-Bus
+func WatchScreenLock(onLock func()) (stop func(), err error) {
+	return nil, errors.New("watching the screen lock is not supported on this platform")
+}
@@ -0,0 +1,126 @@
+package clipboard
+
+import (
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// tickInterval is how often the countdown is reported
+var tickInterval = time.Second
+
+// Manager copies secrets to the clipboard and puts back what was there before
+// once the timeout expires, like pass show --clip
+type Manager struct {
+	// OnCountdown is called when something is copied and then every second with
+	// the time left. It is called with 0 once the clipboard has been restored.
+	OnCountdown func(name string, remaining time.Duration)
+
+	clipboard fyne.Clipboard
+	do        func(func())
+
+	mu       sync.Mutex
+	name     string
+	copied   string
+	previous string
+	deadline time.Time
+	done     chan struct{} // Closed when the current copy is restored or replaced
+}
+
+// NewManager creates a manager for the given clipboard. The clipboard is only
+// touched from functions passed to do, such as fyne.Do.
+func NewManager(clipboard fyne.Clipboard, do func(func())) *Manager {
+	return &Manager{clipboard: clipboard, do: do}
+}
+
+// Copy puts text on the clipboard and restores the previous contents after the
+// timeout. The name describes the text in the countdown, e.g. "Password".
+// Copy must be called from the same goroutine do runs functions on.
+func (m *Manager) Copy(name, text string, timeout time.Duration) {
+	m.mu.Lock()
+	if m.done != nil {
+		// Keep what was there before the first secret, not the secret itself
+		close(m.done)
+	} else {
+		m.previous = m.clipboard.Content()
+	}
+	m.name = name
+	m.copied = text
+	m.deadline = time.Now().Add(timeout)
+	m.done = make(chan struct{})
+	done := m.done
+	m.clipboard.SetContent(text)
+	m.mu.Unlock()
+
+	m.countdown(name, timeout)
+	go m.run(done, tickInterval)
+}
+
+// Restore puts back the previous clipboard contents right away if a copied
+// secret is still on the clipboard
+func (m *Manager) Restore() {
+	m.mu.Lock()
+	name, restored := m.name, m.restoreLocked()
+	m.mu.Unlock()
+
+	if restored {
+		m.countdown(name, 0)
+	}
+}
+
+// restoreLocked ends the current copy. The previous contents are only put back
+// if the user has not copied something else in the meantime.
+func (m *Manager) restoreLocked() bool {
+	if m.done == nil {
+		return false
+	}
+	close(m.done)
+	m.done = nil
+
+	if m.clipboard.Content() == m.copied {
+		m.clipboard.SetContent(m.previous)
+	}
+	m.copied = ""
+	m.previous = ""
+	return true
+}
+
+// run reports the countdown until the copy is restored or replaced
+func (m *Manager) run(done chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			m.do(func() { m.tick(done) })
+		}
+	}
+}
+
+// tick reports the time left and restores the clipboard once it is up
+func (m *Manager) tick(done chan struct{}) {
+	m.mu.Lock()
+	if m.done != done {
+		m.mu.Unlock()
+		return
+	}
+	name := m.name
+	remaining := time.Until(m.deadline)
+	if remaining <= 0 {
+		remaining = 0
+		m.restoreLocked()
+	}
+	m.mu.Unlock()
+
+	m.countdown(name, remaining)
+}
+
+// countdown calls OnCountdown if set
+func (m *Manager) countdown(name string, remaining time.Duration) {
+	if m.OnCountdown != nil {
+		m.OnCountdown(name, remaining)
+	}
+}
@@ -0,0 +1,95 @@
+package clipboard
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClipboard is an in-memory fyne.Clipboard
+type fakeClipboard struct {
+	mu      sync.Mutex
+	content string
+}
+
+func (c *fakeClipboard) Content() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.content
+}
+
+func (c *fakeClipboard) SetContent(content string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.content = content
+}
+
+// newTestManager returns a manager ticking every few milliseconds
+func newTestManager(t *testing.T, content string) (*Manager, *fakeClipboard) {
+	original := tickInterval
+	tickInterval = 5 * time.Millisecond
+	t.Cleanup(func() { tickInterval = original })
+
+	cb := &fakeClipboard{content: content}
+	return NewManager(cb, func(f func()) { f() }), cb
+}
+
+func TestCopyRestoresPreviousContent(t *testing.T) {
+	m, cb := newTestManager(t, "previous")
+
+	m.Copy("Password", "hunter2", 30*time.Millisecond)
+	assert.Equal(t, "hunter2", cb.Content())
+
+	assert.Eventually(t, func() bool { return cb.Content() == "previous" }, time.Second, 5*time.Millisecond)
+}
+
+func TestCopyKeepsOtherContent(t *testing.T) {
+	m, cb := newTestManager(t, "previous")
+
+	m.Copy("Password", "hunter2", 30*time.Millisecond)
+	// The user copies something else before the timeout
+	cb.SetContent("something else")
+
+	time.Sleep(80 * time.Millisecond)
+	assert.Equal(t, "something else", cb.Content())
+}
+
+func TestCopyTwiceRestoresOriginal(t *testing.T) {
+	m, cb := newTestManager(t, "previous")
+
+	m.Copy("Password", "hunter2", time.Hour)
+	m.Copy("Username", "alice", 30*time.Millisecond)
+	assert.Equal(t, "alice", cb.Content())
+
+	assert.Eventually(t, func() bool { return cb.Content() == "previous" }, time.Second, 5*time.Millisecond)
+}
+
+func TestRestore(t *testing.T) {
+	m, cb := newTestManager(t, "previous")
+
+	var mu sync.Mutex
+	var names []string
+	var last time.Duration
+	m.OnCountdown = func(name string, remaining time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		names = append(names, name)
+		last = remaining
+	}
+
+	m.Copy("Password", "hunter2", time.Hour)
+	m.Restore()
+	assert.Equal(t, "previous", cb.Content())
+
+	mu.Lock()
+	assert.Equal(t, "Password", names[0])
+	assert.Equal(t, time.Duration(0), last)
+	mu.Unlock()
+
+	// Restoring again does nothing
+	cb.SetContent("new")
+	m.Restore()
+	assert.Equal(t, "new", cb.Content())
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"main.go/gitrepo"
+	"main.go/storeops"
+)
+
+// showCommitDialog lists the uncommitted changes of the store with a checkbox each
+// and commits the chosen files with a message suggested from the changes. Only
+// entries and .gpg-id files can be chosen unless other files are allowed explicitly.
+func showCommitDialog(window fyne.Window, storeRoot string) {
+	go func() {
+		repo, err := gitrepo.Open(storeRoot)
+		var files []gitrepo.FileStatus
+		if err == nil {
+			files, err = repo.Status()
+		}
+		fyne.Do(func() {
+			switch {
+			case err != nil:
+				dialog.ShowError(fmt.Errorf("Failed to read the changes of the store: %v", err), window)
+			case repo.RebaseInProgress():
+				dialog.ShowInformation("Commit", "A sync stopped on conflicting changes. Press Sync to merge them first.", window)
+			case len(files) == 0:
+				fyne.CurrentApp().SendNotification(&fyne.Notification{
+					Title:   "No Changes",
+					Content: "No changes to commit.",
+				})
+			default:
+				showCommitChoices(window, storeRoot, repo, files)
+			}
+		})
+	}()
+}
+
+// showCommitChoices shows the commit dialog for the changed files
+func showCommitChoices(window fyne.Window, storeRoot string, repo gitrepo.Repository, files []gitrepo.FileStatus) {
+	chosen := make([]bool, len(files))
+	allowOther := false
+
+	messageEntry := widget.NewMultiLineEntry()
+	messageEntry.Wrapping = fyne.TextWrapWord
+	messageEntry.SetMinRowsVisible(3)
+
+	// suggestMessage builds the message from the chosen files, unless the user
+	// changed the last suggestion
+	suggested := ""
+	suggestMessage := func() {
+		var selected []gitrepo.FileStatus
+		for i, file := range files {
+			if chosen[i] {
+				selected = append(selected, file)
+			}
+		}
+		message := storeops.CommitMessage(storeRoot, selected)
+		if messageEntry.Text == suggested {
+			messageEntry.SetText(message)
+		}
+		suggested = message
+	}
+
+	checks := make([]*widget.Check, len(files))
+	fileRows := container.NewVBox()
+	hasOther := false
+	for i, file := range files {
+		check := widget.NewCheck(withBadge(file.Path, storeops.ChangeOf(file).Badge()), nil)
+		if storeops.IsStoreFile(file.Path) {
+			check.SetChecked(true)
+			chosen[i] = true
+		} else {
+			// Editor swap files and the like may hold plaintext secrets
+			check.Disable()
+			hasOther = true
+		}
+		check.OnChanged = func(on bool) {
+			chosen[i] = on
+			suggestMessage()
+		}
+		checks[i] = check
+		fileRows.Add(check)
+	}
+	suggestMessage()
+
+	options := container.NewVBox()
+	if hasOther {
+		allowCheck := widget.NewCheck("Allow files other than entries and .gpg-id files", func(on bool) {
+			allowOther = on
+			for i, file := range files {
+				if storeops.IsStoreFile(file.Path) {
+					continue
+				}
+				if on {
+					checks[i].Enable()
+				} else {
+					checks[i].SetChecked(false)
+					checks[i].Disable()
+				}
+			}
+		})
+		warning := widget.NewLabel("Other files, such as editor swap files, may contain secrets in plaintext.")
+		warning.Wrapping = fyne.TextWrapWord
+		options.Add(allowCheck)
+		options.Add(warning)
+	}
+	options.Add(widget.NewLabel("Commit message:"))
+	options.Add(messageEntry)
+
+	content := container.NewBorder(
+		widget.NewLabel("Choose the changes to commit:"),
+		options, nil, nil,
+		container.NewVScroll(fileRows),
+	)
+
+	commitDialog := dialog.NewCustomConfirm("Commit Changes", "Commit", "Cancel", content, func(commit bool) {
+		if !commit {
+			return
+		}
+		var paths []string
+		for i, file := range files {
+			if chosen[i] {
+				paths = append(paths, file.Path)
+			}
+		}
+		message := strings.TrimSpace(messageEntry.Text)
+		switch {
+		case len(paths) == 0:
+			dialog.ShowError(errors.New("Choose at least one change to commit"), window)
+			return
+		case message == "":
+			dialog.ShowError(errors.New("The commit message is empty"), window)
+			return
+		}
+
+		go func() {
+			err := storeops.CommitFiles(repo, message, paths, allowOther)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("Failed to commit: %v", err), window)
+					return
+				}
+				refreshGitBadges()
+				fyne.CurrentApp().SendNotification(&fyne.Notification{
+					Title:   "Commit Successful",
+					Content: message,
+				})
+			})
+		}()
+	}, window)
+	commitDialog.Resize(fyne.NewSize(700, 550))
+	commitDialog.Show()
+}
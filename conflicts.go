@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"main.go/entry"
+	"main.go/storeops"
+)
+
+// errSyncAborted is passed to the callback of resolveConflicts when the user gave up
+var errSyncAborted = errors.New("the sync was aborted, local changes are back as they were")
+
+// resolveConflicts shows a three-way merge for each conflicted file of a rebase
+// stopped by a sync, then continues the rebase. Later commits may conflict again,
+// which starts over with their files. onDone is called on the UI thread when the
+// rebase went through, or with an error if it was aborted or failed.
+func resolveConflicts(window fyne.Window, storeRoot string, paths []string, onDone func(err error)) {
+	if len(paths) == 0 {
+		go func() {
+			err := storeops.ContinueRebase(storeRoot)
+			fyne.Do(func() {
+				var conflict *storeops.ConflictError
+				if errors.As(err, &conflict) {
+					resolveConflicts(window, storeRoot, conflict.Paths, onDone)
+					return
+				}
+				onDone(err)
+			})
+		}()
+		return
+	}
+
+	p := paths[0]
+	abort := func() {
+		go func() {
+			err := storeops.AbortRebase(storeRoot)
+			fyne.Do(func() {
+				if err == nil {
+					err = errSyncAborted
+				}
+				onDone(err)
+			})
+		}()
+	}
+
+	go func() {
+		versions, err := storeops.Versions(storeRoot, p)
+		if err != nil {
+			fyne.Do(func() {
+				dialog.ShowError(fmt.Errorf("Failed to read the conflicting versions of %s: %v", p, err), window)
+				abort()
+			})
+			return
+		}
+
+		// Entries are decrypted one after another, other files are plain text
+		full := filepath.Join(storeRoot, filepath.FromSlash(p))
+		var plaintexts []string
+		var decryptNext func()
+		decryptNext = func() {
+			ciphertexts := [][]byte{versions.Base, versions.Remote, versions.Local}
+			for len(plaintexts) < len(ciphertexts) {
+				ciphertext := ciphertexts[len(plaintexts)]
+				if ciphertext != nil && path.Ext(p) == ".gpg" {
+					side := []string{"common", "remote", "local"}[len(plaintexts)]
+					go tryDecryptCiphertext(full, ciphertext, window, func(content string) {
+						plaintexts = append(plaintexts, content)
+						decryptNext()
+					}, func(err error) {
+						// Without every version there is nothing to merge, so the
+						// sync is either tried again or given up, never left stopped
+						message := fmt.Sprintf("Failed to decrypt the %s version of %s: %v", side, strings.TrimSuffix(p, ".gpg"), err)
+						dialog.ShowCustomConfirm("Decryption Failed", "Retry", "Abort Sync", widget.NewLabel(message), func(retry bool) {
+							if retry {
+								decryptNext()
+								return
+							}
+							abort()
+						}, window)
+					})
+					return
+				}
+				plaintexts = append(plaintexts, string(ciphertext))
+			}
+			fyne.Do(func() {
+				showMergeDialog(window, storeRoot, p, plaintexts[0], versions.Remote != nil, plaintexts[1], versions.Local != nil, plaintexts[2], func() {
+					resolveConflicts(window, storeRoot, paths[1:], onDone)
+				}, abort)
+			})
+		}
+		decryptNext()
+	}()
+}
+
+// showMergeDialog shows what the remote and the local commit changed in a conflicted
+// file and lets the user edit the merged result. Saving encrypts the result for the
+// current recipients and marks the file resolved; an empty result removes it.
+func showMergeDialog(window fyne.Window, storeRoot, p, base string, hasRemote bool, remote string, hasLocal bool, local string, onResolved, onAbort func()) {
+	merged, _ := entry.Merge(base, remote, local, "remote", "local")
+
+	// changes shows what one side did to the common base
+	changes := func(title string, exists bool, content string) fyne.CanvasObject {
+		label := widget.NewLabel(title)
+		label.TextStyle = fyne.TextStyle{Bold: true}
+		var view fyne.CanvasObject = widget.NewLabel("Removed on this side")
+		if exists {
+			view = container.NewVScroll(entry.NewDiffView(entry.Diff(base, content)))
+		}
+		return container.NewBorder(label, nil, nil, nil, view)
+	}
+
+	result := widget.NewMultiLineEntry()
+	result.TextStyle = fyne.TextStyle{Monospace: true}
+	result.SetText(merged)
+	result.OnChanged = func(string) {
+		noteActivity()
+	}
+
+	buttons := container.NewHBox(
+		widget.NewButton("Use Remote", func() { result.SetText(remote) }),
+		widget.NewButton("Use Local", func() { result.SetText(local) }),
+		widget.NewButton("Merge Again", func() { result.SetText(merged) }),
+	)
+	resultTitle := widget.NewLabel("Result (lines between <<<<<<< and >>>>>>> changed on both sides):")
+	resultTitle.TextStyle = fyne.TextStyle{Bold: true}
+
+	sides := container.NewHSplit(
+		changes("Remote: changed by others", hasRemote, remote),
+		changes("Local: your change", hasLocal, local),
+	)
+	content := container.NewVSplit(sides, container.NewBorder(resultTitle, buttons, nil, nil, result))
+
+	name := strings.TrimSuffix(p, ".gpg")
+	var mergeDialog dialog.Dialog
+	mergeDialog = dialog.NewCustomConfirm(fmt.Sprintf("Resolve Conflict in %s", name), "Save", "Abort Sync", content, func(save bool) {
+		if !save {
+			onAbort()
+			return
+		}
+		text := result.Text
+		if entry.HasConflictMarkers(text) {
+			dialog.ShowError(errors.New("The result still contains conflict markers"), window)
+			mergeDialog.Show()
+			return
+		}
+
+		go func() {
+			err := writeResolution(storeRoot, p, text)
+			if err == nil {
+				err = storeops.ResolveConflict(storeRoot, p)
+			}
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("Failed to save %s: %v", name, err), window)
+					mergeDialog.Show()
+					return
+				}
+				onResolved()
+			})
+		}()
+	}, window)
+	mergeDialog.Resize(fyne.NewSize(900, 650))
+	mergeDialog.Show()
+}
+
+// writeResolution writes the merged content of a conflicted file, encrypting
+// entries for the recipients of their folder. Empty content removes the file.
+func writeResolution(storeRoot, p, content string) error {
+	full := filepath.Join(storeRoot, filepath.FromSlash(p))
+	if strings.TrimSpace(content) == "" {
+		if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	// The folder is gone if the file was removed on the local side
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return err
+	}
+	if path.Ext(p) == ".gpg" {
+		return saveEntryContent(storeRoot, full, content)
+	}
+	return storeops.WriteFile(full, []byte(content))
+}
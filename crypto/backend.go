@@ -0,0 +1,127 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Backend names accepted by New and stored in settings
+const (
+	BackendGPG     = "gpg"
+	BackendOpenPGP = "openpgp"
+)
+
+var (
+	// ErrPassphraseRequired is returned when a secret key is locked and no passphrase was given
+	ErrPassphraseRequired = errors.New("passphrase required")
+	// ErrBadPassphrase is returned when the given passphrase does not unlock any secret key
+	ErrBadPassphrase = errors.New("bad passphrase")
+	// ErrAmbiguousRecipient is returned when a recipient names more than one key,
+	// which a key ID or fingerprint tells apart
+	ErrAmbiguousRecipient = errors.New("recipient matches more than one key, use its key ID or fingerprint")
+)
+
+// NoSecretKeyError is returned when no secret key for any recipient of a message is available
+type NoSecretKeyError struct {
+	KeyIDs []string // Long key IDs the message is encrypted to, upper-case hex
+}
+
+func (e *NoSecretKeyError) Error() string {
+	if len(e.KeyIDs) == 0 {
+		return "no secret key available to decrypt this message"
+	}
+	ids := make([]string, len(e.KeyIDs))
+	for i, id := range e.KeyIDs {
+		ids[i] = "0x" + id
+	}
+	return "no secret key for " + strings.Join(ids, ", ")
+}
+
+// Key describes a public key known to a backend
+type Key struct {
+	KeyID       string   // Long key ID of the primary key, upper-case hex
+	Fingerprint string   // Fingerprint of the primary key, upper-case hex
+	UserIDs     []string // User IDs such as "Jane Doe <jane@example.com>"
+	SubkeyIDs   []string // Long key IDs of the subkeys, upper-case hex
+}
+
+// Backend performs the OpenPGP operations needed by the viewer
+type Backend interface {
+	// Decrypt decrypts a message. A nil passphrase relies on gpg-agent or unprotected keys.
+	Decrypt(ciphertext []byte, passphrase []byte) ([]byte, error)
+	// Encrypt encrypts plaintext to every recipient (email, user ID, key ID or fingerprint)
+	Encrypt(plaintext []byte, recipients []string) ([]byte, error)
+	// ListRecipients returns the long key IDs a message is encrypted to
+	ListRecipients(ciphertext []byte) ([]string, error)
+	// ListKeys returns the public keys available for encryption
+	ListKeys() ([]Key, error)
+}
+
+// PassphraseCache is implemented by backends that remember passphrases between calls
+type PassphraseCache interface {
+	// ForgetPassphrases drops every cached passphrase
+	ForgetPassphrases() error
+}
+
+// New creates the backend with the given name.
+// keyringPath is only used by the OpenPGP backend.
+func New(name, keyringPath string) (Backend, error) {
+	switch name {
+	case BackendGPG, "":
+		return NewGPGBackend(), nil
+	case BackendOpenPGP:
+		if keyringPath == "" {
+			return nil, errors.New("the openpgp backend needs a keyring path")
+		}
+		return LoadOpenPGPBackend(keyringPath)
+	default:
+		return nil, fmt.Errorf("unknown crypto backend %q", name)
+	}
+}
+
+// NewDefault creates the configured backend, falling back to OpenPGP when gpg is not installed
+func NewDefault(name, keyringPath string) (Backend, error) {
+	if (name == BackendGPG || name == "") && keyringPath != "" {
+		if _, err := exec.LookPath("gpg"); err != nil {
+			return New(BackendOpenPGP, keyringPath)
+		}
+	}
+	return New(name, keyringPath)
+}
+
+// GetAvailableBackends returns the names of all backends
+func GetAvailableBackends() []string {
+	return []string{BackendGPG, BackendOpenPGP}
+}
+
+// Wipe overwrites a secret such as a passphrase once it is no longer needed
+func Wipe(secret []byte) {
+	for i := range secret {
+		secret[i] = 0
+	}
+}
+
+// normalizeKeyID strips an optional 0x prefix and upper-cases a hex key ID
+func normalizeKeyID(id string) string {
+	id = strings.TrimSpace(id)
+	id = strings.TrimPrefix(strings.TrimPrefix(id, "0x"), "0X")
+	return strings.ToUpper(id)
+}
+
+// isHexKeyID reports whether id looks like a short/long key ID or a fingerprint
+func isHexKeyID(id string) bool {
+	id = normalizeKeyID(id)
+	switch len(id) {
+	case 8, 16, 40, 64:
+	default:
+		return false
+	}
+	for _, r := range id {
+		if !strings.ContainsRune("0123456789ABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,255 @@
+package crypto
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Descriptors passed to gpg through cmd.ExtraFiles, which start at 3
+const (
+	passphraseFD = 3 // gpg reads the passphrase from here
+	statusFD     = 4 // gpg writes --status-fd lines here
+)
+
+// GPGBackend runs the gpg command line tool
+type GPGBackend struct {
+	// Binary is the gpg executable to run
+	Binary string
+	// HomeDir overrides the GnuPG home directory when set
+	HomeDir string
+}
+
+// NewGPGBackend creates a backend that uses gpg from PATH
+func NewGPGBackend() *GPGBackend {
+	return &GPGBackend{Binary: "gpg"}
+}
+
+// baseArgs returns the options shared by every gpg invocation
+func (g *GPGBackend) baseArgs() []string {
+	args := []string{"--batch"}
+	if g.HomeDir != "" {
+		args = append(args, "--homedir", g.HomeDir)
+	}
+	return args
+}
+
+// decryptArgs builds the arguments for decrypting a message read from stdin.
+// The passphrase itself is never part of the arguments, which any user can read
+// from /proc; gpg reads it from a pipe instead.
+func (g *GPGBackend) decryptArgs(passphrase []byte) []string {
+	args := append(g.baseArgs(), "--status-fd", fmt.Sprint(statusFD))
+	if passphrase != nil {
+		args = append(args, "--pinentry-mode", "loopback", "--passphrase-fd", fmt.Sprint(passphraseFD))
+	}
+	return append(args, "--decrypt")
+}
+
+// encryptArgs builds the arguments for encrypting stdin to stdout
+func (g *GPGBackend) encryptArgs(recipients []string) []string {
+	args := append(g.baseArgs(), "--yes")
+	for _, id := range recipients {
+		args = append(args, "--recipient", id)
+	}
+	return append(args, "--output", "-", "--encrypt")
+}
+
+// run executes gpg and returns its stdout, adding stderr to any error.
+// A non-nil passphrase is written to a pipe that gpg reads as passphraseFD.
+func (g *GPGBackend) run(args []string, stdin []byte, passphrase []byte) ([]byte, error) {
+	stdout, _, err := g.runWithStatus(args, stdin, passphrase)
+	return stdout, err
+}
+
+// runWithStatus is like run but also collects everything gpg writes to statusFD.
+// stdout only ever holds gpg's output, so decrypted content is never mixed with messages.
+func (g *GPGBackend) runWithStatus(args []string, stdin []byte, passphrase []byte) ([]byte, []byte, error) {
+	cmd := exec.Command(g.Binary, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	// A nil entry leaves passphraseFD closed in gpg, keeping statusFD at a fixed number
+	cmd.ExtraFiles = make([]*os.File, 2)
+	if passphrase != nil {
+		passphraseReader, err := passphrasePipe(passphrase)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer passphraseReader.Close()
+		cmd.ExtraFiles[passphraseFD-3] = passphraseReader
+	}
+
+	statusReader, statusWriter, err := os.Pipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create status pipe: %v", err)
+	}
+	defer statusReader.Close()
+	cmd.ExtraFiles[statusFD-3] = statusWriter
+
+	var stdout, stderr, status bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		statusWriter.Close()
+		return nil, nil, err
+	}
+	// Only gpg holds the write end now, so reading stops when it exits
+	statusWriter.Close()
+	statusDone := make(chan struct{})
+	go func() {
+		io.Copy(&status, statusReader)
+		close(statusDone)
+	}()
+
+	err = cmd.Wait()
+	<-statusDone
+	if err != nil {
+		return nil, status.Bytes(), fmt.Errorf("%v\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), status.Bytes(), nil
+}
+
+// Decrypt implements Backend. Failures gpg explains on --status-fd are returned
+// as ErrPassphraseRequired, ErrBadPassphrase or *NoSecretKeyError.
+func (g *GPGBackend) Decrypt(ciphertext []byte, passphrase []byte) ([]byte, error) {
+	plaintext, statusOutput, err := g.runWithStatus(g.decryptArgs(passphrase), ciphertext, passphrase)
+	if err != nil {
+		if statusErr := parseStatus(statusOutput).Err(); statusErr != nil {
+			return nil, statusErr
+		}
+		return nil, err
+	}
+	return plaintext, nil
+}
+
+// Encrypt implements Backend
+func (g *GPGBackend) Encrypt(plaintext []byte, recipients []string) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipients given")
+	}
+
+	// Stream the plaintext through stdin so it never touches the disk
+	return g.run(g.encryptArgs(recipients), plaintext, nil)
+}
+
+// ListRecipients implements Backend
+func (g *GPGBackend) ListRecipients(ciphertext []byte) ([]string, error) {
+	args := append(g.baseArgs(), "--list-only", "--list-packets")
+	output, err := g.run(args, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseListPackets(output), nil
+}
+
+// ListKeys implements Backend
+func (g *GPGBackend) ListKeys() ([]Key, error) {
+	args := append(g.baseArgs(), "--with-colons", "--list-keys")
+	output, err := g.run(args, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseColonKeys(output), nil
+}
+
+// ForgetPassphrases implements PassphraseCache by reloading gpg-agent, which
+// drops every cached passphrase
+func (g *GPGBackend) ForgetPassphrases() error {
+	output, err := exec.Command("gpgconf", g.reloadAgentArgs()...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("gpgconf failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// reloadAgentArgs builds the gpgconf arguments for reloading gpg-agent
+func (g *GPGBackend) reloadAgentArgs() []string {
+	var args []string
+	if g.HomeDir != "" {
+		args = append(args, "--homedir", g.HomeDir)
+	}
+	return append(args, "--reload", "gpg-agent")
+}
+
+// passphrasePipe returns the read end of a pipe that already holds the passphrase.
+// The passphrase is far smaller than the pipe buffer, so writing never blocks.
+func passphrasePipe(passphrase []byte) (*os.File, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create passphrase pipe: %v", err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write(passphrase); err != nil {
+		r.Close()
+		return nil, fmt.Errorf("failed to write passphrase: %v", err)
+	}
+	if _, err := w.Write([]byte("\n")); err != nil {
+		r.Close()
+		return nil, fmt.Errorf("failed to write passphrase: %v", err)
+	}
+	return r, nil
+}
+
+// parseListPackets extracts key IDs from ":pubkey enc packet:" lines
+func parseListPackets(output []byte) []string {
+	var keyIDs []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, ":pubkey enc packet:") {
+			continue
+		}
+		idx := strings.Index(line, "keyid ")
+		if idx < 0 {
+			continue
+		}
+		fields := strings.Fields(line[idx+len("keyid "):])
+		if len(fields) > 0 {
+			keyIDs = append(keyIDs, normalizeKeyID(fields[0]))
+		}
+	}
+	return keyIDs
+}
+
+// parseColonKeys parses the output of gpg --with-colons --list-keys
+func parseColonKeys(output []byte) []Key {
+	var keys []Key
+	var current *Key
+	lastRecord := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 10 {
+			continue
+		}
+
+		switch fields[0] {
+		case "pub":
+			keys = append(keys, Key{KeyID: fields[4]})
+			current = &keys[len(keys)-1]
+		case "sub":
+			if current != nil {
+				current.SubkeyIDs = append(current.SubkeyIDs, fields[4])
+			}
+		case "fpr":
+			// The fingerprint record directly follows the key it belongs to
+			if current != nil && lastRecord == "pub" {
+				current.Fingerprint = fields[9]
+			}
+		case "uid":
+			if current != nil && fields[9] != "" {
+				current.UserIDs = append(current.UserIDs, fields[9])
+			}
+		}
+		lastRecord = fields[0]
+	}
+	return keys
+}
@@ -0,0 +1,115 @@
+package crypto
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGPGDecryptArgs(t *testing.T) {
+	backend := NewGPGBackend()
+	assert.Equal(t, []string{"--batch", "--status-fd", "4", "--decrypt"}, backend.decryptArgs(nil))
+
+	args := backend.decryptArgs([]byte("secret"))
+	assert.Equal(t, []string{"--batch", "--status-fd", "4", "--pinentry-mode", "loopback", "--passphrase-fd", "3", "--decrypt"}, args)
+	assert.NotContains(t, args, "secret")
+
+	backend.HomeDir = "/tmp/gnupg"
+	assert.Equal(t, []string{"--batch", "--homedir", "/tmp/gnupg", "--status-fd", "4", "--decrypt"}, backend.decryptArgs(nil))
+}
+
+func TestPassphrasePipe(t *testing.T) {
+	r, err := passphrasePipe([]byte("correct horse"))
+	require.NoError(t, err)
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "correct horse\n", string(data))
+}
+
+func TestWipe(t *testing.T) {
+	secret := []byte("hunter2")
+	Wipe(secret)
+	assert.Equal(t, make([]byte, 7), secret)
+}
+
+func TestGPGEncryptArgs(t *testing.T) {
+	backend := NewGPGBackend()
+	args := backend.encryptArgs([]string{"alice@example.com", "0xDEADBEEF"})
+	assert.Equal(t, []string{
+		"--batch", "--yes",
+		"--recipient", "alice@example.com",
+		"--recipient", "0xDEADBEEF",
+		"--output", "-", "--encrypt",
+	}, args)
+}
+
+func TestGPGReloadAgentArgs(t *testing.T) {
+	backend := NewGPGBackend()
+	assert.Equal(t, []string{"--reload", "gpg-agent"}, backend.reloadAgentArgs())
+
+	backend.HomeDir = "/tmp/gnupg"
+	assert.Equal(t, []string{"--homedir", "/tmp/gnupg", "--reload", "gpg-agent"}, backend.reloadAgentArgs())
+
+	// Only the gpg backend caches passphrases
+	var backendIface Backend = backend
+	_, ok := backendIface.(PassphraseCache)
+	assert.True(t, ok)
+}
+
+func TestParseListPackets(t *testing.T) {
+	output := `# off=0 ctb=85 tag=1 hlen=3 plen=396
+:pubkey enc packet: version 3, algo 1, keyid ED6309D2D60599A7
+	data: [3072 bits]
+# off=399 ctb=85 tag=1 hlen=3 plen=396
+:pubkey enc packet: version 3, algo 18, keyid f5e42ece32262e39
+	data: [263 bits]
+# off=798 ctb=d2 tag=18 hlen=2 plen=66 new-ctb
+:encrypted data packet:
+	length: 66
+`
+	assert.Equal(t, []string{"ED6309D2D60599A7", "F5E42ECE32262E39"}, parseListPackets([]byte(output)))
+	assert.Empty(t, parseListPackets([]byte("")))
+}
+
+func TestParseColonKeys(t *testing.T) {
+	output := `tru::1:1792182634:0:3:1:5
+pub:u:3072:1:B25D9E6AA42948E0:1792182629:::u:::scESC::::::23::0:
+fpr:::::::::F1CAC8D4F323EAC04EABFCB3B25D9E6AA42948E0:
+uid:u::::1792182629::7AD0C2E655786217C70E9384866F23969DDAD952::Test One <one@example.com>::::::::::0:
+sub:u:3072:1:ED6309D2D60599A7:1792182629::::::e::::::23:
+fpr:::::::::B473DE577A96024637D981A3ED6309D2D60599A7:
+pub:u:3072:1:B7A1D38316CB42BD:1792182631:::u:::scESC::::::23::0:
+fpr:::::::::181F9D8BB920AFA3F272F230B7A1D38316CB42BD:
+uid:u::::1792182631::91B42EC0713F8CE87FC424AC1A7FC6D2FB89655D::Test Two <two@example.com>::::::::::0:
+sub:u:3072:1:F5E42ECE32262E39:1792182631::::::e::::::23:
+fpr:::::::::2F164F036B317D21DC629FB8F5E42ECE32262E39:
+`
+	keys := parseColonKeys([]byte(output))
+	assert.Equal(t, []Key{
+		{
+			KeyID:       "B25D9E6AA42948E0",
+			Fingerprint: "F1CAC8D4F323EAC04EABFCB3B25D9E6AA42948E0",
+			UserIDs:     []string{"Test One <one@example.com>"},
+			SubkeyIDs:   []string{"ED6309D2D60599A7"},
+		},
+		{
+			KeyID:       "B7A1D38316CB42BD",
+			Fingerprint: "181F9D8BB920AFA3F272F230B7A1D38316CB42BD",
+			UserIDs:     []string{"Test Two <two@example.com>"},
+			SubkeyIDs:   []string{"F5E42ECE32262E39"},
+		},
+	}, keys)
+}
+
+func TestIsHexKeyID(t *testing.T) {
+	assert.True(t, isHexKeyID("DEADBEEF"))
+	assert.True(t, isHexKeyID("0xdeadbeefcafebabe"))
+	assert.True(t, isHexKeyID("F1CAC8D4F323EAC04EABFCB3B25D9E6AA42948E0"))
+	assert.False(t, isHexKeyID("alice@example.com"))
+	assert.False(t, isHexKeyID("DEADBEE"))
+	assert.False(t, isHexKeyID("XYZXYZXY"))
+}
@@ -0,0 +1,315 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// OpenPGPBackend is a pure-Go backend that works on an exported keyring
+// instead of talking to gpg. Secret keys stay encrypted between calls.
+type OpenPGPBackend struct {
+	keyringData []byte
+	keyring     openpgp.EntityList
+}
+
+// NewOpenPGPBackend creates a backend from an armored or binary keyring
+func NewOpenPGPBackend(keyringData []byte) (*OpenPGPBackend, error) {
+	keyring, err := readKeyRing(keyringData)
+	if err != nil {
+		return nil, err
+	}
+	if len(keyring) == 0 {
+		return nil, errors.New("keyring does not contain any keys")
+	}
+	return &OpenPGPBackend{keyringData: keyringData, keyring: keyring}, nil
+}
+
+// LoadOpenPGPBackend creates a backend from keyring files such as the output of
+// gpg --export-secret-keys. Several files are concatenated into one keyring.
+func LoadOpenPGPBackend(paths ...string) (*OpenPGPBackend, error) {
+	var data []byte
+	for _, path := range paths {
+		fileData, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading keyring %s: %w", path, err)
+		}
+		dearmored, err := dearmor(fileData)
+		if err != nil {
+			return nil, fmt.Errorf("error reading keyring %s: %w", path, err)
+		}
+		data = append(data, dearmored...)
+	}
+	return NewOpenPGPBackend(data)
+}
+
+// dearmor returns the binary form of armored data and leaves binary data untouched
+func dearmor(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) {
+		return data, nil
+	}
+
+	var result []byte
+	rest := data
+	for len(bytes.TrimSpace(rest)) > 0 {
+		block, err := armor.Decode(bytes.NewReader(rest))
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		body, err := io.ReadAll(block.Body)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, body...)
+
+		// Continue after the end of this armor block
+		end := bytes.Index(rest, []byte("-----END"))
+		if end < 0 {
+			break
+		}
+		next := bytes.IndexByte(rest[end:], '\n')
+		if next < 0 {
+			break
+		}
+		rest = rest[end+next+1:]
+	}
+	return result, nil
+}
+
+// readKeyRing parses a binary or armored keyring
+func readKeyRing(data []byte) (openpgp.EntityList, error) {
+	binary, err := dearmor(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding keyring: %w", err)
+	}
+	keyring, err := openpgp.ReadKeyRing(bytes.NewReader(binary))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing keyring: %w", err)
+	}
+	return keyring, nil
+}
+
+// Decrypt implements Backend
+func (o *OpenPGPBackend) Decrypt(ciphertext []byte, passphrase []byte) ([]byte, error) {
+	// Parse a fresh copy so unlocked secret keys never outlive this call
+	keyring, err := readKeyRing(o.keyringData)
+	if err != nil {
+		return nil, err
+	}
+
+	prompted := false
+	prompt := func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+		if passphrase == nil {
+			return nil, ErrPassphraseRequired
+		}
+		// ReadMessage calls the prompt again if no key was unlocked, so only try once
+		if prompted {
+			return nil, ErrBadPassphrase
+		}
+		prompted = true
+
+		for _, key := range keys {
+			if key.PrivateKey != nil && key.PrivateKey.Encrypted {
+				if err := key.PrivateKey.Decrypt(passphrase); err == nil {
+					return nil, nil
+				}
+			}
+		}
+		return nil, ErrBadPassphrase
+	}
+
+	data, err := dearmor(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding message: %w", err)
+	}
+
+	md, err := openpgp.ReadMessage(bytes.NewReader(data), keyring, prompt, nil)
+	if err != nil {
+		if errors.Is(err, pgperrors.ErrKeyIncorrect) {
+			keyIDs, _ := listEncryptedKeyIDs(data)
+			return nil, &NoSecretKeyError{KeyIDs: keyIDs}
+		}
+		return nil, err
+	}
+
+	plaintext, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return nil, fmt.Errorf("error reading decrypted message: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Encrypt implements Backend
+func (o *OpenPGPBackend) Encrypt(plaintext []byte, recipients []string) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients given")
+	}
+
+	var to []*openpgp.Entity
+	for _, recipient := range recipients {
+		entity, err := o.findEntity(recipient)
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			return nil, fmt.Errorf("no public key found for recipient %q", recipient)
+		}
+		if _, ok := entity.EncryptionKey(time.Now()); !ok {
+			return nil, fmt.Errorf("key for recipient %q cannot be used for encryption", recipient)
+		}
+		to = append(to, entity)
+	}
+
+	var buf bytes.Buffer
+	w, err := openpgp.Encrypt(&buf, to, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting message: %w", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("error encrypting message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error encrypting message: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ListRecipients implements Backend
+func (o *OpenPGPBackend) ListRecipients(ciphertext []byte) ([]string, error) {
+	return listEncryptedKeyIDs(ciphertext)
+}
+
+// ListKeys implements Backend
+func (o *OpenPGPBackend) ListKeys() ([]Key, error) {
+	var keys []Key
+	for _, entity := range o.keyring {
+		keys = append(keys, entityToKey(entity))
+	}
+	return keys, nil
+}
+
+// findEntity resolves a recipient the way gpg does: by key ID or fingerprint, or
+// else by the email or the whole user ID of a key. Only when nothing matches
+// exactly is the recipient looked for within user IDs. A recipient matching more
+// than one key is an error rather than a guess. No match returns nil.
+func (o *OpenPGPBackend) findEntity(recipient string) (*openpgp.Entity, error) {
+	if isHexKeyID(recipient) {
+		id := normalizeKeyID(recipient)
+		return o.onlyEntity(recipient, func(entity *openpgp.Entity) bool {
+			if strings.HasSuffix(entityToKey(entity).Fingerprint, id) {
+				return true
+			}
+			for _, subkey := range entity.Subkeys {
+				if strings.HasSuffix(formatFingerprint(subkey.PublicKey.Fingerprint), id) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	needle := strings.ToLower(strings.Trim(strings.TrimSpace(recipient), "<>"))
+	entity, err := o.onlyEntity(recipient, func(entity *openpgp.Entity) bool {
+		for name, identity := range entity.Identities {
+			if strings.EqualFold(identity.UserId.Email, needle) || strings.EqualFold(name, needle) {
+				return true
+			}
+		}
+		return false
+	})
+	if entity != nil || err != nil {
+		return entity, err
+	}
+	return o.onlyEntity(recipient, func(entity *openpgp.Entity) bool {
+		for name := range entity.Identities {
+			if strings.Contains(strings.ToLower(name), needle) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// onlyEntity returns the key in the keyring that matches, nil if none does, or
+// ErrAmbiguousRecipient if several do. A key listed twice, such as from a public
+// and a secret keyring, counts once.
+func (o *OpenPGPBackend) onlyEntity(recipient string, matches func(*openpgp.Entity) bool) (*openpgp.Entity, error) {
+	var found *openpgp.Entity
+	for _, entity := range o.keyring {
+		if !matches(entity) {
+			continue
+		}
+		if found != nil && !bytes.Equal(found.PrimaryKey.Fingerprint, entity.PrimaryKey.Fingerprint) {
+			return nil, fmt.Errorf("recipient %q: %w", recipient, ErrAmbiguousRecipient)
+		}
+		if found == nil {
+			found = entity
+		}
+	}
+	return found, nil
+}
+
+// listEncryptedKeyIDs reads the public-key encrypted session key packets of a message
+func listEncryptedKeyIDs(ciphertext []byte) ([]string, error) {
+	data, err := dearmor(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding message: %w", err)
+	}
+
+	var keyIDs []string
+	packets := packet.NewReader(bytes.NewReader(data))
+	for {
+		p, err := packets.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading message: %w", err)
+		}
+
+		switch p := p.(type) {
+		case *packet.EncryptedKey:
+			keyIDs = append(keyIDs, fmt.Sprintf("%016X", p.KeyId))
+		case *packet.SymmetricallyEncrypted, *packet.AEADEncrypted:
+			// Session keys always come before the encrypted data
+			return keyIDs, nil
+		}
+	}
+	return keyIDs, nil
+}
+
+// entityToKey converts an OpenPGP entity into the backend independent Key type
+func entityToKey(entity *openpgp.Entity) Key {
+	key := Key{
+		KeyID:       fmt.Sprintf("%016X", entity.PrimaryKey.KeyId),
+		Fingerprint: formatFingerprint(entity.PrimaryKey.Fingerprint),
+	}
+	for name := range entity.Identities {
+		key.UserIDs = append(key.UserIDs, name)
+	}
+	sort.Strings(key.UserIDs)
+	for _, subkey := range entity.Subkeys {
+		key.SubkeyIDs = append(key.SubkeyIDs, fmt.Sprintf("%016X", subkey.PublicKey.KeyId))
+	}
+	return key
+}
+
+// formatFingerprint renders a fingerprint as upper-case hex
+func formatFingerprint(fingerprint []byte) string {
+	return strings.ToUpper(hex.EncodeToString(fingerprint))
+}
@@ -0,0 +1,238 @@
+package crypto
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestEntity generates a fast elliptic curve key, optionally protected by a passphrase
+func newTestEntity(t testing.TB, name, email string, passphrase []byte) (*openpgp.Entity, []byte) {
+	config := &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA}
+	entity, err := openpgp.NewEntity(name, "", email, config)
+	require.NoError(t, err)
+
+	if passphrase != nil {
+		require.NoError(t, entity.EncryptPrivateKeys(passphrase, config))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, entity.SerializePrivateWithoutSigning(&buf, config))
+	return entity, buf.Bytes()
+}
+
+// newTestBackend creates a backend whose keyring holds the given serialized keys
+func newTestBackend(t testing.TB, keys ...[]byte) *OpenPGPBackend {
+	backend, err := NewOpenPGPBackend(bytes.Join(keys, nil))
+	require.NoError(t, err)
+	return backend
+}
+
+func TestOpenPGPRoundTrip(t *testing.T) {
+	_, alice := newTestEntity(t, "Alice", "alice@example.com", nil)
+	backend := newTestBackend(t, alice)
+
+	plaintext := []byte("s3cr3t\nUsername: alice\ncreated: 2024-01-01\n")
+	ciphertext, err := backend.Encrypt(plaintext, []string{"alice@example.com"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "s3cr3t")
+
+	decrypted, err := backend.Decrypt(ciphertext, nil)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+}
+
+func TestOpenPGPMultipleRecipients(t *testing.T) {
+	aliceEntity, alice := newTestEntity(t, "Alice", "alice@example.com", nil)
+	bobEntity, bob := newTestEntity(t, "Bob", "bob@example.com", nil)
+	team := newTestBackend(t, alice, bob)
+
+	ciphertext, err := team.Encrypt([]byte("shared"), []string{"alice@example.com", "Bob"})
+	require.NoError(t, err)
+
+	// Every recipient can decrypt with only their own key
+	for _, key := range [][]byte{alice, bob} {
+		decrypted, err := newTestBackend(t, key).Decrypt(ciphertext, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "shared", string(decrypted))
+	}
+
+	// The message lists the encryption subkeys of both recipients
+	ids, err := team.ListRecipients(ciphertext)
+	require.NoError(t, err)
+	assert.Len(t, ids, 2)
+	aliceSub, _ := aliceEntity.EncryptionKey(aliceEntity.PrimaryKey.CreationTime)
+	bobSub, _ := bobEntity.EncryptionKey(bobEntity.PrimaryKey.CreationTime)
+	assert.Contains(t, ids, aliceSub.PublicKey.KeyIdString())
+	assert.Contains(t, ids, bobSub.PublicKey.KeyIdString())
+}
+
+func TestOpenPGPRecipientByKeyID(t *testing.T) {
+	entity, alice := newTestEntity(t, "Alice", "alice@example.com", nil)
+	backend := newTestBackend(t, alice)
+
+	keyIDs := []string{
+		entity.PrimaryKey.KeyIdString(),
+		"0x" + entity.PrimaryKey.KeyIdShortString(),
+		formatFingerprint(entity.PrimaryKey.Fingerprint),
+	}
+	for _, id := range keyIDs {
+		_, err := backend.Encrypt([]byte("x"), []string{id})
+		assert.NoError(t, err, "recipient %s", id)
+	}
+}
+
+func TestOpenPGPRecipientExactMatchFirst(t *testing.T) {
+	bob, bobKey := newTestEntity(t, "Bob", "bob@example.com", nil)
+	jimbob, jimbobKey := newTestEntity(t, "Jim Bob", "jimbob@example.com", nil)
+
+	// Either keyring order resolves the exact email to the same key
+	for _, backend := range []*OpenPGPBackend{newTestBackend(t, bobKey, jimbobKey), newTestBackend(t, jimbobKey, bobKey)} {
+		for recipient, want := range map[string]*openpgp.Entity{
+			"bob@example.com":    bob,
+			"<BOB@example.com>":  bob,
+			"jimbob@example.com": jimbob,
+			"Jim":                jimbob,
+		} {
+			ciphertext, err := backend.Encrypt([]byte("x"), []string{recipient})
+			require.NoError(t, err, "recipient %s", recipient)
+			keyIDs, err := backend.ListRecipients(ciphertext)
+			require.NoError(t, err)
+			encryptionKey, ok := want.EncryptionKey(time.Now())
+			require.True(t, ok)
+			assert.Equal(t, []string{fmt.Sprintf("%016X", encryptionKey.PublicKey.KeyId)}, keyIDs, "recipient %s", recipient)
+		}
+
+		_, err := backend.Encrypt([]byte("x"), []string{"bob"})
+		assert.ErrorIs(t, err, ErrAmbiguousRecipient)
+	}
+}
+
+func TestOpenPGPUnknownRecipient(t *testing.T) {
+	_, alice := newTestEntity(t, "Alice", "alice@example.com", nil)
+	backend := newTestBackend(t, alice)
+
+	_, err := backend.Encrypt([]byte("x"), []string{"mallory@example.com"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no public key found")
+
+	_, err = backend.Encrypt([]byte("x"), nil)
+	assert.Error(t, err)
+}
+
+func TestOpenPGPPassphrase(t *testing.T) {
+	_, alice := newTestEntity(t, "Alice", "alice@example.com", []byte("correct horse"))
+	backend := newTestBackend(t, alice)
+
+	ciphertext, err := backend.Encrypt([]byte("locked"), []string{"alice@example.com"})
+	require.NoError(t, err)
+
+	_, err = backend.Decrypt(ciphertext, nil)
+	assert.ErrorIs(t, err, ErrPassphraseRequired)
+
+	_, err = backend.Decrypt(ciphertext, []byte("wrong"))
+	assert.ErrorIs(t, err, ErrBadPassphrase)
+
+	decrypted, err := backend.Decrypt(ciphertext, []byte("correct horse"))
+	require.NoError(t, err)
+	assert.Equal(t, "locked", string(decrypted))
+
+	// Unlocked keys must not be cached between calls
+	_, err = backend.Decrypt(ciphertext, nil)
+	assert.ErrorIs(t, err, ErrPassphraseRequired)
+}
+
+func TestOpenPGPNoSecretKey(t *testing.T) {
+	_, alice := newTestEntity(t, "Alice", "alice@example.com", nil)
+	_, bob := newTestEntity(t, "Bob", "bob@example.com", nil)
+
+	ciphertext, err := newTestBackend(t, alice).Encrypt([]byte("for alice"), []string{"alice@example.com"})
+	require.NoError(t, err)
+
+	_, err = newTestBackend(t, bob).Decrypt(ciphertext, nil)
+	var noSecretKey *NoSecretKeyError
+	require.ErrorAs(t, err, &noSecretKey)
+	require.Len(t, noSecretKey.KeyIDs, 1)
+	assert.Contains(t, err.Error(), "no secret key for 0x"+noSecretKey.KeyIDs[0])
+}
+
+func TestOpenPGPListKeys(t *testing.T) {
+	entity, alice := newTestEntity(t, "Alice", "alice@example.com", nil)
+	backend := newTestBackend(t, alice)
+
+	keys, err := backend.ListKeys()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, entity.PrimaryKey.KeyIdString(), keys[0].KeyID)
+	assert.Equal(t, formatFingerprint(entity.PrimaryKey.Fingerprint), keys[0].Fingerprint)
+	assert.Equal(t, []string{"Alice <alice@example.com>"}, keys[0].UserIDs)
+	assert.Len(t, keys[0].SubkeyIDs, 1)
+}
+
+func TestLoadOpenPGPBackendArmoredFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "openpgp_keyring_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	// Write one armored and one binary keyring
+	_, alice := newTestEntity(t, "Alice", "alice@example.com", nil)
+	_, bob := newTestEntity(t, "Bob", "bob@example.com", nil)
+
+	var armored bytes.Buffer
+	w, err := armor.Encode(&armored, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	_, err = w.Write(alice)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	alicePath := filepath.Join(tempDir, "alice.asc")
+	bobPath := filepath.Join(tempDir, "bob.gpg")
+	require.NoError(t, os.WriteFile(alicePath, armored.Bytes(), 0600))
+	require.NoError(t, os.WriteFile(bobPath, bob, 0600))
+
+	backend, err := LoadOpenPGPBackend(alicePath, bobPath)
+	require.NoError(t, err)
+
+	keys, err := backend.ListKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	_, err = LoadOpenPGPBackend(filepath.Join(tempDir, "missing.gpg"))
+	assert.Error(t, err)
+}
+
+func TestNewBackend(t *testing.T) {
+	backend, err := New(BackendGPG, "")
+	require.NoError(t, err)
+	assert.IsType(t, &GPGBackend{}, backend)
+
+	_, err = New(BackendOpenPGP, "")
+	assert.Error(t, err)
+
+	_, err = New("rot13", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown crypto backend")
+}
+
+func BenchmarkOpenPGPRoundTrip(b *testing.B) {
+	_, alice := newTestEntity(b, "Alice", "alice@example.com", nil)
+	backend := newTestBackend(b, alice)
+	plaintext := []byte("benchmark secret")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ciphertext, err := backend.Encrypt(plaintext, []string{"alice@example.com"})
+		require.NoError(b, err)
+		_, err = backend.Decrypt(ciphertext, nil)
+		require.NoError(b, err)
+	}
+}
@@ -0,0 +1,72 @@
+package crypto
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// statusPrefix starts every line gpg writes to --status-fd
+const statusPrefix = "[GNUPG:] "
+
+// Status is the machine readable outcome of a gpg decryption, parsed from --status-fd
+type Status struct {
+	EncTo             []string // Long key IDs the message is encrypted to (ENC_TO)
+	NoSecretKey       []string // Key IDs without a usable secret key (NO_SECKEY)
+	DecryptionOkay    bool     // The message was decrypted (DECRYPTION_OKAY)
+	DecryptionFailed  bool     // The message could not be decrypted (DECRYPTION_FAILED)
+	BadPassphrase     bool     // A passphrase was given but wrong (BAD_PASSPHRASE)
+	MissingPassphrase bool     // A passphrase was needed but not given (MISSING_PASSPHRASE)
+}
+
+// parseStatus parses the lines gpg writes to --status-fd
+func parseStatus(output []byte) *Status {
+	status := &Status{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(scanner.Text(), statusPrefix)
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "ENC_TO":
+			if len(fields) > 1 {
+				status.EncTo = append(status.EncTo, normalizeKeyID(fields[1]))
+			}
+		case "NO_SECKEY":
+			if len(fields) > 1 {
+				status.NoSecretKey = append(status.NoSecretKey, normalizeKeyID(fields[1]))
+			}
+		case "DECRYPTION_OKAY":
+			status.DecryptionOkay = true
+		case "DECRYPTION_FAILED":
+			status.DecryptionFailed = true
+		case "BAD_PASSPHRASE":
+			status.BadPassphrase = true
+		case "MISSING_PASSPHRASE":
+			status.MissingPassphrase = true
+		}
+	}
+	return status
+}
+
+// Err returns the typed error described by the status, or nil if it does not explain a failure
+func (s *Status) Err() error {
+	switch {
+	case s.DecryptionOkay:
+		return nil
+	case s.BadPassphrase:
+		return ErrBadPassphrase
+	case s.MissingPassphrase:
+		return ErrPassphraseRequired
+	case len(s.NoSecretKey) > 0:
+		return &NoSecretKeyError{KeyIDs: s.NoSecretKey}
+	default:
+		return nil
+	}
+}
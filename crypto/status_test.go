@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatus(t *testing.T) {
+	output := `[GNUPG:] ENC_TO ED6309D2D60599A7 1 0
+[GNUPG:] ENC_TO f5e42ece32262e39 18 0
+[GNUPG:] NO_SECKEY F5E42ECE32262E39
+[GNUPG:] KEY_CONSIDERED F1CAC8D4F323EAC04EABFCB3B25D9E6AA42948E0 0
+[GNUPG:] DECRYPTION_KEY B473DE577A96024637D981A3ED6309D2D60599A7 F1CAC8D4F323EAC04EABFCB3B25D9E6AA42948E0 u
+[GNUPG:] BEGIN_DECRYPTION
+[GNUPG:] DECRYPTION_OKAY
+[GNUPG:] GOODMDC
+[GNUPG:] END_DECRYPTION
+`
+	status := parseStatus([]byte(output))
+	assert.Equal(t, []string{"ED6309D2D60599A7", "F5E42ECE32262E39"}, status.EncTo)
+	assert.Equal(t, []string{"F5E42ECE32262E39"}, status.NoSecretKey)
+	assert.True(t, status.DecryptionOkay)
+	assert.False(t, status.DecryptionFailed)
+	// Another recipient's missing key does not matter once decryption worked
+	assert.NoError(t, status.Err())
+}
+
+func TestStatusErr(t *testing.T) {
+	noSecKey := parseStatus([]byte(`[GNUPG:] ENC_TO ABCDEF0123456789 1 0
+[GNUPG:] NO_SECKEY ABCDEF0123456789
+[GNUPG:] BEGIN_DECRYPTION
+[GNUPG:] DECRYPTION_FAILED
+[GNUPG:] END_DECRYPTION
+`))
+	var noSecretKey *NoSecretKeyError
+	assert.ErrorAs(t, noSecKey.Err(), &noSecretKey)
+	assert.Equal(t, []string{"ABCDEF0123456789"}, noSecretKey.KeyIDs)
+	assert.EqualError(t, noSecKey.Err(), "no secret key for 0xABCDEF0123456789")
+
+	badPassphrase := parseStatus([]byte(`[GNUPG:] ENC_TO ABCDEF0123456789 1 0
+[GNUPG:] BAD_PASSPHRASE ABCDEF0123456789
+[GNUPG:] DECRYPTION_FAILED
+`))
+	assert.ErrorIs(t, badPassphrase.Err(), ErrBadPassphrase)
+
+	missingPassphrase := parseStatus([]byte(`[GNUPG:] MISSING_PASSPHRASE
+[GNUPG:] DECRYPTION_FAILED
+`))
+	assert.ErrorIs(t, missingPassphrase.Err(), ErrPassphraseRequired)
+
+	// Without any status lines the caller falls back to gpg's own error
+	assert.NoError(t, parseStatus(nil).Err())
+	assert.NoError(t, parseStatus([]byte("gpg: decryption failed\n")).Err())
+}
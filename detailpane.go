@@ -0,0 +1,297 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"main.go/crypto"
+	"main.go/entry"
+	"main.go/otp"
+	"main.go/recipients"
+	"main.go/storeops"
+)
+
+// detailPane shows the selected entry read-only next to the file list. Editing
+// is a separate mode, so stray keystrokes cannot change a secret.
+type detailPane struct {
+	storeRoot string
+	window    fyne.Window
+
+	filePath   string // Full path of the shown entry, "" if none
+	content    string // Decrypted content of the shown entry
+	editing    bool
+	otpDisplay *otp.Display
+	opening    int // Counts calls to Open and Clear, so a decrypt that finishes late is dropped
+
+	// onRestore restores the entry with node ID id to a revision from its history
+	onRestore func(id string, revision storeops.Revision)
+
+	container *fyne.Container
+}
+
+// newDetailPane creates an empty detail pane
+func newDetailPane(storeRoot string, window fyne.Window) *detailPane {
+	p := &detailPane{storeRoot: storeRoot, window: window, container: container.NewStack()}
+	p.Clear()
+	return p
+}
+
+// Clear hides the shown entry and forgets its content
+func (p *detailPane) Clear() {
+	p.opening++
+	p.filePath = ""
+	p.content = ""
+	p.editing = false
+	p.setContent(container.NewCenter(widget.NewLabel("Select an entry to view it")))
+}
+
+// Show displays decrypted content read-only
+func (p *detailPane) Show(filePath, content string) {
+	p.filePath = filePath
+	p.content = content
+	p.editing = false
+
+	viewer := entry.NewViewer(content)
+	viewer.OnCopy = copyToClipboard
+
+	// Show the one-time code if the entry has an otpauth:// line
+	var display *otp.Display
+	var otpView fyne.CanvasObject
+	if key, err := otp.Find(content); err == nil {
+		display = otp.NewDisplay(key)
+		display.OnCopy = func(code string) {
+			copyToClipboard("One-time code", code)
+		}
+		display.OnNext = func(next *otp.Key, done func(saved bool)) {
+			// Save the new counter before its code is shown, like pass otp, so no
+			// code is used twice
+			filePath, id := p.filePath, p.entryPath()
+			content := otp.ReplaceURI(p.content, next)
+			go func() {
+				err := saveEntryContent(p.storeRoot, filePath, content)
+				fyne.Do(func() {
+					if err != nil {
+						dialog.ShowError(fmt.Errorf("Failed to save HOTP counter: %v", err), p.window)
+						done(false)
+						return
+					}
+					if p.filePath == filePath {
+						p.content = content
+					}
+					done(true)
+					commitStoreChange(p.storeRoot, p.window, fmt.Sprintf("Increment HOTP counter for %s.", id), storeops.DiskPath(id))
+				})
+			}()
+		}
+		otpView = display
+	} else if !errors.Is(err, otp.ErrNoKey) {
+		otpView = widget.NewLabel(err.Error())
+	}
+
+	editBtn := widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), p.Edit)
+	closeBtn := widget.NewButtonWithIcon("Close", theme.CancelIcon(), p.Clear)
+	buttons := container.NewHBox(editBtn)
+	if storeops.IsGitRepo(p.storeRoot) {
+		buttons.Add(widget.NewButtonWithIcon("History", theme.HistoryIcon(), func() {
+			id := p.entryPath()
+			showHistoryDialog(p.window, p.storeRoot, id, p.content, func(revision storeops.Revision) {
+				p.onRestore(id, revision)
+			})
+		}))
+	}
+	buttons.Add(closeBtn)
+
+	body := container.NewVBox(viewer)
+	if otpView != nil {
+		body.Objects = append([]fyne.CanvasObject{otpView, widget.NewSeparator()}, body.Objects...)
+	}
+	p.setContent(container.NewBorder(
+		p.header(),
+		buttons,
+		nil, nil,
+		container.NewVScroll(body),
+	))
+	p.otpDisplay = display
+}
+
+// Edit switches to edit mode for the shown entry
+func (p *detailPane) Edit() {
+	if p.filePath == "" {
+		return
+	}
+	p.editing = true
+
+	// Show the decrypted content as fields, with the raw text one tab away
+	editor := entry.NewEditor(p.content)
+	editor.OnCopy = copyToClipboard
+	// Typing counts as activity, so auto-lock does not throw away the edits
+	editor.OnChanged = noteActivity
+
+	saveBtn := widget.NewButtonWithIcon("Save Changes", theme.DocumentSaveIcon(), func() {
+		p.save(editor.Text())
+	})
+
+	generateBtn := widget.NewButtonWithIcon("Generate Password", theme.ViewRefreshIcon(), func() {
+		showGenerateDialog(p.window, p.entryPath(), editor.SetPassword)
+	})
+
+	cancelBtn := widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), func() {
+		p.Show(p.filePath, p.content)
+	})
+
+	p.setContent(container.NewBorder(
+		p.header(),
+		container.NewHBox(saveBtn, generateBtn, cancelBtn),
+		nil, nil,
+		editor,
+	))
+}
+
+// Moved follows the shown entry when it or one of its folders was renamed.
+// Paths are full paths without the .gpg extension.
+func (p *detailPane) Moved(oldPath, newPath string, isDir bool) {
+	switch {
+	case p.filePath == "":
+	case !isDir && p.filePath == oldPath+".gpg":
+		p.filePath = newPath + ".gpg"
+	case isDir && strings.HasPrefix(p.filePath, oldPath+string(filepath.Separator)):
+		p.filePath = newPath + strings.TrimPrefix(p.filePath, oldPath)
+	default:
+		return
+	}
+	if !p.editing {
+		p.Show(p.filePath, p.content)
+	}
+}
+
+// Removed clears the pane if the shown entry or one of its folders was deleted.
+// The path is a full path without the .gpg extension.
+func (p *detailPane) Removed(path string, isDir bool) {
+	if p.filePath == "" || p.editing {
+		// Saving from the editor creates the entry again
+		return
+	}
+	if p.filePath == path+".gpg" || (isDir && strings.HasPrefix(p.filePath, path+string(filepath.Separator))) {
+		p.Clear()
+	}
+}
+
+// header shows the path of the entry
+func (p *detailPane) header() fyne.CanvasObject {
+	title := widget.NewLabel(p.entryPath())
+	title.TextStyle = fyne.TextStyle{Bold: true}
+	title.Truncation = fyne.TextTruncateEllipsis
+	return container.NewVBox(title, widget.NewSeparator())
+}
+
+// entryPath returns the store-relative path of the entry, such as "banking/chase"
+func (p *detailPane) entryPath() string {
+	entryPath, err := filepath.Rel(p.storeRoot, strings.TrimSuffix(p.filePath, ".gpg"))
+	if err != nil {
+		return filepath.Base(strings.TrimSuffix(p.filePath, ".gpg"))
+	}
+	return filepath.ToSlash(entryPath)
+}
+
+// setContent replaces what the pane shows, stopping the one-time code refresh
+func (p *detailPane) setContent(object fyne.CanvasObject) {
+	if p.otpDisplay != nil {
+		p.otpDisplay.Stop()
+		p.otpDisplay = nil
+	}
+	p.container.Objects = []fyne.CanvasObject{object}
+	p.container.Refresh()
+}
+
+// save encrypts edited content to the recipients of the entry and shows it again
+func (p *detailPane) save(text string) {
+	filePath := p.filePath
+
+	// saveFor encrypts the edited content to the given recipients in the
+	// background, so the window stays responsive while gpg runs
+	saveFor := func(gpgIDs []string) {
+		id := p.entryPath()
+		go func() {
+			plaintext := []byte(text)
+			ciphertext, err := cryptoBackend.Encrypt(plaintext, gpgIDs)
+			crypto.Wipe(plaintext)
+			if err != nil {
+				err = fmt.Errorf("Failed to encrypt file: %v", err)
+			} else if err = storeops.WriteFile(filePath, ciphertext); err != nil {
+				err = fmt.Errorf("Failed to save file: %v", err)
+			}
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, p.window)
+					return
+				}
+				commitStoreChange(p.storeRoot, p.window, fmt.Sprintf("Edit password for %s using gpg_viewer.", id), storeops.DiskPath(id))
+
+				dialog.ShowInformation("Success", "File saved successfully", p.window)
+				if p.filePath == filePath {
+					p.Show(filePath, text)
+				}
+			})
+		}()
+	}
+
+	// Resolve recipients from the nearest .gpg-id, exactly like pass does
+	gpgIDs, err := recipients.ForEntry(p.storeRoot, filePath)
+	if err != nil && !errors.Is(err, recipients.ErrNoGpgID) {
+		dialog.ShowError(fmt.Errorf("Failed to resolve recipients: %v", err), p.window)
+		return
+	}
+
+	if len(gpgIDs) > 0 {
+		// Encrypt the edited content to every recipient from .gpg-id
+		saveFor(gpgIDs)
+		return
+	}
+
+	// If the store has no .gpg-id, ask the user
+	recipientPicker := recipients.NewPicker(defaultRecipients)
+	recipientPicker.SetSuggestions(keySuggestions())
+	recipientDialog := dialog.NewCustomConfirm(
+		"Select Recipients",
+		"Encrypt",
+		"Cancel",
+		container.NewVBox(
+			widget.NewLabel("No .gpg-id file found in the password store."),
+			widget.NewLabel("Please choose GPG recipients (email or key ID):"),
+			recipientPicker,
+		),
+		func(confirm bool) {
+			if !confirm {
+				return
+			}
+			selected := recipientPicker.Recipients()
+			if len(selected) == 0 {
+				dialog.ShowError(errors.New("At least one recipient is required"), p.window)
+				return
+			}
+			saveFor(selected)
+		},
+		p.window,
+	)
+	recipientDialog.Show()
+}
+
+// Open decrypts an entry in the background and shows it, unless another entry
+// was opened or the pane was cleared, for example by auto-lock, in the meantime
+func (p *detailPane) Open(filePath string) {
+	p.opening++
+	opening := p.opening
+	go decryptFile(filePath, p.window, func(content string) {
+		if opening != p.opening {
+			return
+		}
+		p.Show(filePath, content)
+	})
+}
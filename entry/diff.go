@@ -0,0 +1,105 @@
+package entry
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// DiffOp says whether a line of a diff is kept, removed or added
+type DiffOp int
+
+const (
+	DiffEqual DiffOp = iota
+	DiffDelete
+	DiffInsert
+)
+
+// DiffLine is one line of a line-level diff
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// Diff compares two contents line by line, listing removed lines before the
+// lines that replace them. Entries are short, so a plain longest common
+// subsequence is fast enough.
+func Diff(old, new string) []DiffLine {
+	a, b := splitLines(old), splitLines(new)
+	lcs := lcsTable(a, b)
+
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, DiffLine{DiffEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{DiffDelete, a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{DiffInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{DiffDelete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{DiffInsert, b[j]})
+	}
+	return lines
+}
+
+// lcsTable returns a table where lcs[i][j] is the length of the longest common
+// subsequence of a[i:] and b[j:]
+func lcsTable(a, b []string) [][]int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return lcs
+}
+
+// splitLines splits content into lines, ignoring the newline at the end
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// NewDiffView shows a diff with removed lines in red and added lines in green.
+// Unlike the Viewer it shows passwords in clear text, since comparing them is the point.
+func NewDiffView(lines []DiffLine) *widget.RichText {
+	segments := make([]widget.RichTextSegment, 0, len(lines))
+	for _, line := range lines {
+		style := widget.RichTextStyle{TextStyle: fyne.TextStyle{Monospace: true}}
+		prefix := "  "
+		switch line.Op {
+		case DiffDelete:
+			prefix = "- "
+			style.ColorName = theme.ColorNameError
+		case DiffInsert:
+			prefix = "+ "
+			style.ColorName = theme.ColorNameSuccess
+		}
+		segments = append(segments, &widget.TextSegment{Style: style, Text: prefix + line.Text})
+	}
+	view := widget.NewRichText(segments...)
+	view.Wrapping = fyne.TextWrapWord
+	return view
+}
@@ -0,0 +1,27 @@
+package entry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	old := "hunter2\nUsername: alice\nurl: https://example.com\n"
+	new := "correct horse\nUsername: alice\nurl: https://example.com\nNotes: rotated\n"
+
+	assert.Equal(t, []DiffLine{
+		{DiffDelete, "hunter2"},
+		{DiffInsert, "correct horse"},
+		{DiffEqual, "Username: alice"},
+		{DiffEqual, "url: https://example.com"},
+		{DiffInsert, "Notes: rotated"},
+	}, Diff(old, new))
+}
+
+func TestDiffEmpty(t *testing.T) {
+	assert.Empty(t, Diff("", ""))
+	assert.Equal(t, []DiffLine{{DiffInsert, "hunter2"}}, Diff("", "hunter2"))
+	assert.Equal(t, []DiffLine{{DiffDelete, "hunter2"}}, Diff("hunter2\n", ""))
+	assert.Equal(t, []DiffLine{{DiffEqual, "hunter2"}}, Diff("hunter2", "hunter2\n"))
+}
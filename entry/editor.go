@@ -0,0 +1,160 @@
+package entry
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Editor is a widget for editing an entry, either as labeled fields or as raw text
+type Editor struct {
+	widget.BaseWidget
+
+	// OnCopy is called with "Password" or the field name and the value to copy
+	OnCopy func(name, value string)
+	// OnChanged is called whenever the user edits the entry
+	OnChanged func()
+
+	entry *Entry
+
+	passwordEntry *widget.Entry
+	rows          *fyne.Container
+	newKeyEntry   *widget.Entry
+	newValueEntry *widget.Entry
+	rawEntry      *widget.Entry
+	tabs          *container.AppTabs
+	fieldsTab     *container.TabItem
+}
+
+// NewEditor creates an editor for decrypted content
+func NewEditor(content string) *Editor {
+	ed := &Editor{entry: Parse(content)}
+
+	ed.passwordEntry = widget.NewPasswordEntry()
+	ed.rows = container.NewVBox()
+
+	ed.newKeyEntry = widget.NewEntry()
+	ed.newKeyEntry.SetPlaceHolder("Field name")
+	ed.newValueEntry = widget.NewEntry()
+	ed.newValueEntry.SetPlaceHolder("Value")
+	ed.newValueEntry.OnSubmitted = func(string) { ed.addField() }
+	addBtn := widget.NewButtonWithIcon("Add Field", theme.ContentAddIcon(), ed.addField)
+
+	fields := container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Password:"), ed.copyButton("Password", func() string { return ed.entry.Password() }), ed.passwordEntry),
+		ed.rows,
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, nil, addBtn, container.NewGridWithColumns(2, ed.newKeyEntry, ed.newValueEntry)),
+	)
+
+	ed.rawEntry = widget.NewMultiLineEntry()
+	ed.rawEntry.OnChanged = func(string) { ed.changed() }
+	ed.fieldsTab = container.NewTabItem("Fields", container.NewVScroll(fields))
+	ed.tabs = container.NewAppTabs(ed.fieldsTab, container.NewTabItem("Raw", ed.rawEntry))
+	ed.tabs.OnSelected = func(tab *container.TabItem) {
+		// Carry edits over to the other view
+		if tab == ed.fieldsTab {
+			ed.entry = Parse(ed.rawEntry.Text)
+			ed.refresh()
+		} else {
+			ed.rawEntry.SetText(ed.entry.String())
+		}
+	}
+
+	ed.ExtendBaseWidget(ed)
+	ed.refresh()
+	return ed
+}
+
+// CreateRenderer implements fyne.Widget
+func (ed *Editor) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(ed.tabs)
+}
+
+// Text returns the edited content
+func (ed *Editor) Text() string {
+	if ed.tabs.Selected() != ed.fieldsTab {
+		return ed.rawEntry.Text
+	}
+	return ed.entry.String()
+}
+
+// SetText replaces the content being edited
+func (ed *Editor) SetText(content string) {
+	ed.entry = Parse(content)
+	ed.rawEntry.SetText(content)
+	ed.refresh()
+}
+
+// SetPassword replaces the password, keeping the rest of the content
+func (ed *Editor) SetPassword(password string) {
+	ed.entry = Parse(ed.Text())
+	ed.entry.SetPassword(password)
+	ed.SetText(ed.entry.String())
+}
+
+// refresh rebuilds the field rows from the entry
+func (ed *Editor) refresh() {
+	ed.passwordEntry.OnChanged = nil
+	ed.passwordEntry.SetText(ed.entry.Password())
+	ed.passwordEntry.OnChanged = func(password string) {
+		ed.entry.SetPassword(password)
+		ed.changed()
+	}
+
+	ed.rows.RemoveAll()
+	for i, line := range ed.entry.Lines() {
+		if !line.IsField() {
+			if strings.TrimSpace(line.Raw) != "" {
+				ed.rows.Add(widget.NewLabel(line.Raw))
+			}
+			continue
+		}
+
+		valueEntry := widget.NewEntry()
+		valueEntry.SetText(line.Value)
+		valueEntry.OnChanged = func(value string) {
+			ed.entry.SetValue(i, value)
+			ed.changed()
+		}
+		removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			ed.entry.Remove(i)
+			ed.refresh()
+			ed.changed()
+		})
+		buttons := container.NewHBox(ed.copyButton(line.Key, func() string { return valueEntry.Text }), removeBtn)
+		ed.rows.Add(container.NewBorder(nil, nil, widget.NewLabel(line.Key+":"), buttons, valueEntry))
+	}
+}
+
+// copyButton creates a button passing the current value to OnCopy
+func (ed *Editor) copyButton(name string, value func() string) *widget.Button {
+	return widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+		if ed.OnCopy != nil {
+			ed.OnCopy(name, value())
+		}
+	})
+}
+
+// addField adds the field typed into the new field entries
+func (ed *Editor) addField() {
+	key := strings.TrimSpace(ed.newKeyEntry.Text)
+	if key == "" || strings.Contains(key, ":") {
+		return
+	}
+	ed.entry.Add(key, strings.TrimSpace(ed.newValueEntry.Text))
+	ed.newKeyEntry.SetText("")
+	ed.newValueEntry.SetText("")
+	ed.refresh()
+	ed.changed()
+}
+
+// changed calls OnChanged if set
+func (ed *Editor) changed() {
+	if ed.OnChanged != nil {
+		ed.OnChanged()
+	}
+}
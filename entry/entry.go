@@ -0,0 +1,184 @@
+package entry
+
+import (
+	"strings"
+)
+
+// Entry is a decrypted pass entry. Following the pass convention the first line
+// is the password and later "key: value" lines are fields. Every other line is
+// kept as it is, so String returns the original content until something is changed.
+type Entry struct {
+	lines []Line // The first line is the password
+}
+
+// Line is one line of an entry after the password
+type Line struct {
+	Raw   string // The line as written, without the newline
+	Key   string // Field name, "" if the line is not a field
+	Value string // Field value without surrounding whitespace
+
+	valueStart int // Position of Value in Raw
+	valueEnd   int
+}
+
+// IsField reports whether the line is a "key: value" field
+func (l Line) IsField() bool {
+	return l.Key != ""
+}
+
+// New creates an entry with only a password
+func New(password string) *Entry {
+	return &Entry{lines: []Line{{Raw: password}}}
+}
+
+// Parse splits decrypted content into the password and its lines
+func Parse(content string) *Entry {
+	rawLines := strings.Split(content, "\n")
+	e := &Entry{lines: make([]Line, len(rawLines))}
+	e.lines[0] = Line{Raw: rawLines[0]}
+	for i, raw := range rawLines[1:] {
+		e.lines[i+1] = parseLine(raw)
+	}
+	return e
+}
+
+// parseLine recognizes "key: value" fields. URIs such as otpauth:// lines are
+// not fields even though they contain a colon.
+func parseLine(raw string) Line {
+	line := Line{Raw: raw}
+
+	colon := strings.Index(raw, ":")
+	if colon < 0 || strings.HasPrefix(raw[colon:], "://") {
+		return line
+	}
+	key := strings.TrimSpace(raw[:colon])
+	if key == "" || strings.ContainsAny(key, "\t") {
+		return line
+	}
+
+	line.Key = key
+	line.valueStart = colon + 1
+	for line.valueStart < len(raw) && (raw[line.valueStart] == ' ' || raw[line.valueStart] == '\t') {
+		line.valueStart++
+	}
+	line.valueEnd = len(strings.TrimRight(raw, " \t\r"))
+	if line.valueEnd < line.valueStart {
+		line.valueEnd = line.valueStart
+	}
+	line.Value = raw[line.valueStart:line.valueEnd]
+	return line
+}
+
+// String returns the content of the entry
+func (e *Entry) String() string {
+	raw := make([]string, len(e.lines))
+	for i, line := range e.lines {
+		raw[i] = line.Raw
+	}
+	return strings.Join(raw, "\n")
+}
+
+// Password returns the first line of the entry
+func (e *Entry) Password() string {
+	return strings.TrimSuffix(e.lines[0].Raw, "\r")
+}
+
+// SetPassword replaces the first line of the entry
+func (e *Entry) SetPassword(password string) {
+	if strings.HasSuffix(e.lines[0].Raw, "\r") {
+		password += "\r"
+	}
+	e.lines[0] = Line{Raw: password}
+}
+
+// Lines returns the lines after the password, in order
+func (e *Entry) Lines() []Line {
+	return append([]Line(nil), e.lines[1:]...)
+}
+
+// Fields returns only the "key: value" lines, in order
+func (e *Entry) Fields() []Line {
+	var fields []Line
+	for _, line := range e.lines[1:] {
+		if line.IsField() {
+			fields = append(fields, line)
+		}
+	}
+	return fields
+}
+
+// Get returns the value of the first field with the given key, ignoring case
+func (e *Entry) Get(key string) (string, bool) {
+	if i := e.index(key); i >= 0 {
+		return e.lines[i].Value, true
+	}
+	return "", false
+}
+
+// usernameKeys are the field names commonly used for the username, in order of preference
+var usernameKeys = []string{"username", "login", "user", "email"}
+
+// Username returns the value of the first username-like field, such as "login"
+func (e *Entry) Username() (string, bool) {
+	for _, key := range usernameKeys {
+		if value, ok := e.Get(key); ok && value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Set changes the value of the first field with the given key, ignoring case,
+// or adds the field if there is none
+func (e *Entry) Set(key, value string) {
+	if i := e.index(key); i >= 0 {
+		e.SetValue(i-1, value)
+		return
+	}
+	e.Add(key, value)
+}
+
+// SetValue changes the value of the field at index i of Lines, keeping its formatting
+func (e *Entry) SetValue(i int, value string) {
+	line := e.lines[i+1]
+	if !line.IsField() {
+		return
+	}
+	e.lines[i+1] = parseLine(line.Raw[:line.valueStart] + value + line.Raw[line.valueEnd:])
+}
+
+// Add appends a "key: value" field before any trailing blank lines. Further lines
+// of a multi-line value are added as plain lines after it.
+func (e *Entry) Add(key, value string) {
+	var added []Line
+	for i, raw := range strings.Split(value, "\n") {
+		if i == 0 {
+			raw = key + ": " + raw
+		}
+		added = append(added, parseLine(raw))
+	}
+
+	at := len(e.lines)
+	for at > 1 && strings.TrimSpace(e.lines[at-1].Raw) == "" {
+		at--
+	}
+	e.lines = append(e.lines[:at], append(added, e.lines[at:]...)...)
+}
+
+// Remove deletes the line at index i of Lines. An index out of range is ignored.
+func (e *Entry) Remove(i int) {
+	if i < 0 || i >= len(e.lines)-1 {
+		return
+	}
+	e.lines = append(e.lines[:i+1], e.lines[i+2:]...)
+}
+
+// index returns the position in e.lines of the first field with the given key, or -1
+func (e *Entry) index(key string) int {
+	for i, line := range e.lines[1:] {
+		if line.IsField() && strings.EqualFold(line.Key, key) {
+			return i + 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,116 @@
+package entry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	content := "hunter2\nUsername: alice\nurl:https://example.com/login\notpauth://totp/alice?secret=JBSWY3DPEHPK3PXP\n\nsome notes"
+	e := Parse(content)
+
+	assert.Equal(t, "hunter2", e.Password())
+
+	lines := e.Lines()
+	require.Len(t, lines, 5)
+	assert.Equal(t, "Username", lines[0].Key)
+	assert.Equal(t, "alice", lines[0].Value)
+	assert.Equal(t, "url", lines[1].Key)
+	assert.Equal(t, "https://example.com/login", lines[1].Value)
+	assert.False(t, lines[2].IsField(), "otpauth URIs are not fields")
+	assert.False(t, lines[3].IsField())
+	assert.False(t, lines[4].IsField())
+	assert.Equal(t, "some notes", lines[4].Raw)
+
+	fields := e.Fields()
+	require.Len(t, fields, 2)
+	assert.Equal(t, "url", fields[1].Key)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"hunter2",
+		"hunter2\n",
+		"hunter2\nUsername: alice\nNotes: y",
+		"  spaced password  \n  login :   alice  \n\n\n",
+		"hunter2\r\nuser: alice\r\n",
+		"hunter2\n: not a field\nkey:\nkey2:   \n---\nmulti\n  line notes",
+	} {
+		assert.Equal(t, content, Parse(content).String())
+	}
+}
+
+func TestSetValueKeepsFormatting(t *testing.T) {
+	e := Parse("hunter2\n  login :   alice  \nuser: bob\r\nempty:")
+
+	e.SetValue(0, "carol")
+	e.SetValue(1, "dave")
+	e.SetValue(2, "filled")
+	assert.Equal(t, "hunter2\n  login :   carol  \nuser: dave\r\nempty:filled", e.String())
+
+	value, ok := e.Get("LOGIN")
+	assert.True(t, ok)
+	assert.Equal(t, "carol", value)
+}
+
+func TestSetPassword(t *testing.T) {
+	e := Parse("old\r\nuser: alice")
+	e.SetPassword("new")
+	assert.Equal(t, "new", e.Password())
+	assert.Equal(t, "new\r\nuser: alice", e.String())
+}
+
+func TestSetAndAdd(t *testing.T) {
+	e := Parse("hunter2\nUsername: alice\n")
+	e.Set("username", "bob")
+	e.Set("url", "https://example.com")
+	assert.Equal(t, "hunter2\nUsername: bob\nurl: https://example.com\n", e.String())
+
+	_, ok := e.Get("missing")
+	assert.False(t, ok)
+}
+
+func TestNewMatchesRecordFormat(t *testing.T) {
+	e := New("hunter2")
+	e.Add("Username", "alice")
+	e.Add("Notes", "first\nsecond")
+	assert.Equal(t, "hunter2\nUsername: alice\nNotes: first\nsecond", e.String())
+
+	lines := e.Lines()
+	require.Len(t, lines, 3)
+	assert.Equal(t, "first", lines[1].Value)
+	assert.False(t, lines[2].IsField())
+}
+
+func TestRemove(t *testing.T) {
+	e := Parse("hunter2\nUsername: alice\nurl: https://example.com\nnotes")
+	e.Remove(1)
+	assert.Equal(t, "hunter2\nUsername: alice\nnotes", e.String())
+
+	// Indexes out of range change nothing
+	e.Remove(-1)
+	e.Remove(2)
+	assert.Equal(t, "hunter2\nUsername: alice\nnotes", e.String())
+
+	e.Remove(1)
+	e.Remove(0)
+	assert.Equal(t, "hunter2", e.String())
+	e.Remove(0)
+	assert.Equal(t, "hunter2", e.String())
+}
+
+func TestUsername(t *testing.T) {
+	username, ok := Parse("hunter2\nemail: alice@example.com\nlogin: alice").Username()
+	assert.True(t, ok)
+	assert.Equal(t, "alice", username)
+
+	username, ok = Parse("hunter2\nUsername: bob").Username()
+	assert.True(t, ok)
+	assert.Equal(t, "bob", username)
+
+	_, ok = Parse("hunter2\nurl: https://example.com").Username()
+	assert.False(t, ok)
+}
@@ -0,0 +1,98 @@
+package entry
+
+import (
+	"slices"
+	"strings"
+)
+
+// Conflict markers written around lines changed differently on both sides, like git does
+const (
+	ConflictStart = "<<<<<<< "
+	ConflictSep   = "======="
+	ConflictEnd   = ">>>>>>> "
+)
+
+// Merge combines two versions of an entry changed from a common base line by line,
+// like git merge-file. Lines changed on only one side are taken from that side.
+// Lines changed differently on both sides are kept from both between conflict
+// markers labeled oursName and theirsName, and conflict reports that this happened.
+func Merge(base, ours, theirs, oursName, theirsName string) (merged string, conflict bool) {
+	o, a, b := splitLines(base), splitLines(ours), splitLines(theirs)
+	matchA, matchB := matchLines(o, a), matchLines(o, b)
+
+	var lines []string
+	i, j, k := 0, 0, 0
+	for {
+		// Find the next base line kept on both sides
+		next := i
+		for next < len(o) && (matchA[next] < 0 || matchB[next] < 0) {
+			next++
+		}
+		endA, endB := len(a), len(b)
+		if next < len(o) {
+			endA, endB = matchA[next], matchB[next]
+		}
+
+		chunkO, chunkA, chunkB := o[i:next], a[j:endA], b[k:endB]
+		switch {
+		case slices.Equal(chunkA, chunkO):
+			lines = append(lines, chunkB...)
+		case slices.Equal(chunkB, chunkO), slices.Equal(chunkA, chunkB):
+			lines = append(lines, chunkA...)
+		default:
+			conflict = true
+			lines = append(lines, ConflictStart+oursName)
+			lines = append(lines, chunkA...)
+			lines = append(lines, ConflictSep)
+			lines = append(lines, chunkB...)
+			lines = append(lines, ConflictEnd+theirsName)
+		}
+
+		if next == len(o) {
+			break
+		}
+		lines = append(lines, o[next])
+		i, j, k = next+1, endA+1, endB+1
+	}
+
+	if len(lines) == 0 {
+		return "", conflict
+	}
+	return strings.Join(lines, "\n") + "\n", conflict
+}
+
+// HasConflictMarkers reports whether content still contains conflict markers from Merge
+func HasConflictMarkers(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, ConflictStart) || line == ConflictSep || strings.HasPrefix(line, ConflictEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchLines returns for every line of a the index of the same line in b along
+// their longest common subsequence, or -1 for lines not kept in b
+func matchLines(a, b []string) []int {
+	lcs := lcsTable(a, b)
+	matches := make([]int, len(a))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			matches[i] = j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			matches[i] = -1
+			i++
+		default:
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		matches[i] = -1
+	}
+	return matches
+}
@@ -0,0 +1,49 @@
+package entry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	base := "hunter2\nUsername: alice\nurl: https://example.com\n"
+	ours := "correct horse\nUsername: alice\nurl: https://example.com\n"
+	theirs := "hunter2\nUsername: alice\nurl: https://example.com/login\nNotes: moved\n"
+
+	merged, conflict := Merge(base, ours, theirs, "remote", "local")
+	assert.False(t, conflict)
+	assert.Equal(t, "correct horse\nUsername: alice\nurl: https://example.com/login\nNotes: moved\n", merged)
+	assert.False(t, HasConflictMarkers(merged))
+}
+
+func TestMergeSameChange(t *testing.T) {
+	merged, conflict := Merge("hunter2\n", "correct horse\n", "correct horse\n", "remote", "local")
+	assert.False(t, conflict)
+	assert.Equal(t, "correct horse\n", merged)
+}
+
+func TestMergeConflict(t *testing.T) {
+	base := "hunter2\nUsername: alice\n"
+	ours := "correct horse\nUsername: alice\n"
+	theirs := "battery staple\nUsername: alice\n"
+
+	merged, conflict := Merge(base, ours, theirs, "remote", "local")
+	assert.True(t, conflict)
+	assert.Equal(t, "<<<<<<< remote\ncorrect horse\n=======\nbattery staple\n>>>>>>> local\nUsername: alice\n", merged)
+	assert.True(t, HasConflictMarkers(merged))
+}
+
+func TestMergeWithoutBase(t *testing.T) {
+	// Entries added on both sides have no common base
+	merged, conflict := Merge("", "hunter2\n", "hunter2\n", "remote", "local")
+	assert.False(t, conflict)
+	assert.Equal(t, "hunter2\n", merged)
+
+	merged, conflict = Merge("", "hunter2\n", "", "remote", "local")
+	assert.False(t, conflict)
+	assert.Equal(t, "hunter2\n", merged)
+
+	_, conflict = Merge("", "hunter2\n", "correct horse\n", "remote", "local")
+	assert.True(t, conflict)
+}
@@ -0,0 +1,100 @@
+package entry
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maskedPassword is shown instead of the password until it is revealed
+const maskedPassword = "••••••••"
+
+// otpauthPrefix starts one-time password lines, which hold a secret and are not shown
+const otpauthPrefix = "otpauth://"
+
+// Viewer is a read-only widget showing an entry with its password masked
+type Viewer struct {
+	widget.BaseWidget
+
+	// OnCopy is called with "Password" or the field name and the value to copy
+	OnCopy func(name, value string)
+
+	entry    *Entry
+	revealed bool
+
+	passwordLabel *widget.Label
+	revealBtn     *widget.Button
+	content       fyne.CanvasObject
+}
+
+// NewViewer creates a viewer for decrypted content
+func NewViewer(content string) *Viewer {
+	v := &Viewer{entry: Parse(content)}
+
+	v.passwordLabel = widget.NewLabel(maskedPassword)
+	v.passwordLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	v.passwordLabel.Truncation = fyne.TextTruncateEllipsis
+	v.revealBtn = widget.NewButtonWithIcon("", theme.VisibilityIcon(), func() {
+		v.SetRevealed(!v.revealed)
+	})
+
+	rows := container.NewVBox(container.NewBorder(nil, nil,
+		boldLabel("Password:"),
+		container.NewHBox(v.revealBtn, v.copyButton("Password", v.entry.Password())),
+		v.passwordLabel,
+	))
+	for _, line := range v.entry.Lines() {
+		switch {
+		case line.IsField():
+			value := widget.NewLabel(line.Value)
+			value.Truncation = fyne.TextTruncateEllipsis
+			rows.Add(container.NewBorder(nil, nil, boldLabel(line.Key+":"), v.copyButton(line.Key, line.Value), value))
+		case strings.TrimSpace(line.Raw) == "", strings.HasPrefix(strings.TrimSpace(line.Raw), otpauthPrefix):
+			// Blank lines add nothing and one-time codes are shown on their own
+		default:
+			text := widget.NewLabel(line.Raw)
+			text.Wrapping = fyne.TextWrapWord
+			rows.Add(text)
+		}
+	}
+	v.content = rows
+
+	v.ExtendBaseWidget(v)
+	return v
+}
+
+// CreateRenderer implements fyne.Widget
+func (v *Viewer) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(v.content)
+}
+
+// SetRevealed shows or masks the password
+func (v *Viewer) SetRevealed(revealed bool) {
+	v.revealed = revealed
+	if revealed {
+		v.passwordLabel.SetText(v.entry.Password())
+		v.revealBtn.SetIcon(theme.VisibilityOffIcon())
+	} else {
+		v.passwordLabel.SetText(maskedPassword)
+		v.revealBtn.SetIcon(theme.VisibilityIcon())
+	}
+}
+
+// copyButton creates a button passing the value to OnCopy
+func (v *Viewer) copyButton(name, value string) *widget.Button {
+	return widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+		if v.OnCopy != nil {
+			v.OnCopy(name, value)
+		}
+	})
+}
+
+// boldLabel creates a label for a field name
+func boldLabel(text string) *widget.Label {
+	label := widget.NewLabel(text)
+	label.TextStyle = fyne.TextStyle{Bold: true}
+	return label
+}
@@ -0,0 +1,410 @@
+package gitrepo
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CLIRepo runs the git command line tool in the repository
+type CLIRepo struct {
+	// Root is the working tree of the repository
+	Root string
+	// Binary is the git executable to run
+	Binary string
+}
+
+// NewCLI creates a repository that uses git from PATH
+func NewCLI(root string) *CLIRepo {
+	return &CLIRepo{Root: root, Binary: "git"}
+}
+
+// Status lists the files that differ from HEAD, including untracked ones, by path
+func (r *CLIRepo) Status() ([]FileStatus, error) {
+	// Renames are reported as a removal and an addition, like go-git does
+	output, err := r.run("status", "--porcelain", "-z", "--no-renames", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []FileStatus
+	for _, record := range strings.Split(strings.TrimRight(output, "\x00"), "\x00") {
+		if len(record) < 4 {
+			continue
+		}
+		files = append(files, FileStatus{Path: record[3:], Staging: record[0], Worktree: record[1]})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Add stages the given paths, including removed ones, or every change without paths
+func (r *CLIRepo) Add(paths ...string) error {
+	unlock := lock(r.Root)
+	defer unlock()
+	return r.add(paths...)
+}
+
+// add stages paths like Add without taking the lock
+func (r *CLIRepo) add(paths ...string) error {
+	if len(paths) == 0 {
+		_, err := r.run("add", "-A")
+		return err
+	}
+
+	// git add fails for paths that are gone, so those are removed from the index instead
+	var existing, missing []string
+	for _, p := range paths {
+		if _, err := os.Lstat(filepath.Join(r.Root, filepath.FromSlash(p))); err == nil {
+			existing = append(existing, p)
+		} else {
+			missing = append(missing, p)
+		}
+	}
+	if len(existing) > 0 {
+		if _, err := r.run(append([]string{"add", "-A", "--"}, existing...)...); err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		if _, err := r.run(append([]string{"rm", "-r", "-q", "--cached", "--ignore-unmatch", "--"}, missing...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unstage puts the given paths in the index back to HEAD, keeping the working tree
+func (r *CLIRepo) Unstage(paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	unlock := lock(r.Root)
+	defer unlock()
+	_, err := r.run(append([]string{"reset", "-q", "--"}, paths...)...)
+	return err
+}
+
+// Commit commits the staged changes with message
+func (r *CLIRepo) Commit(message string) error {
+	unlock := lock(r.Root)
+	defer unlock()
+	// git diff --quiet exits with 1 when something is staged
+	if _, err := r.run("diff", "--cached", "--quiet"); err == nil {
+		return ErrNothingToCommit
+	}
+	_, err := r.run("commit", "-q", "-m", message)
+	return err
+}
+
+// CommitPaths stages the given paths and commits only those with message
+func (r *CLIRepo) CommitPaths(message string, paths ...string) error {
+	if len(paths) == 0 {
+		return ErrNothingToCommit
+	}
+	unlock := lock(r.Root)
+	defer unlock()
+	if err := r.add(paths...); err != nil {
+		return err
+	}
+
+	// Only files git knows about can be committed by path
+	output, err := r.run(append([]string{"diff", "--cached", "--no-renames", "--name-only", "-z", "--"}, paths...)...)
+	if err != nil {
+		return err
+	}
+	changed := strings.Split(strings.TrimRight(output, "\x00"), "\x00")
+	if changed[0] == "" {
+		return ErrNothingToCommit
+	}
+	_, err = r.run(append([]string{"commit", "-q", "-m", message, "--"}, changed...)...)
+	return err
+}
+
+// Log lists the commits that changed path, newest first
+func (r *CLIRepo) Log(path string) ([]Commit, error) {
+	// A repository without commits has no history yet
+	if _, err := r.run("rev-parse", "--verify", "-q", "HEAD"); err != nil {
+		return nil, nil
+	}
+	args := []string{"log", "--format=%x1e%H%x1f%an%x1f%aI%x1f%s"}
+	if path != "" {
+		args = append(args, "--", path)
+	}
+	output, err := r.run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(output, "\x1e")[1:] {
+		commit, err := parseLogHeader(record)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// Follow lists the commits that changed the file at path across renames, newest first
+func (r *CLIRepo) Follow(path string) ([]Commit, error) {
+	if _, err := r.run("rev-parse", "--verify", "-q", "HEAD"); err != nil {
+		return nil, nil
+	}
+	// Every commit is a header line followed by a name-status line
+	output, err := r.run("-c", "core.quotePath=false", "log", "--format=%x1e%H%x1f%an%x1f%aI%x1f%s",
+		"--follow", "--name-status", "--", path)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(output, "\x1e")[1:] {
+		header, changes, _ := strings.Cut(record, "\n")
+		commit, err := parseLogHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		commit.Path = path
+
+		// Renames list the old and the new path, the new one is the file in this commit
+		for _, line := range strings.Split(strings.TrimSpace(changes), "\n") {
+			status := strings.Split(line, "\t")
+			if len(status) < 2 {
+				continue
+			}
+			commit.Path = status[len(status)-1]
+			commit.Deleted = status[0] == "D"
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// parseLogHeader parses a commit printed with --format=%H%x1f%an%x1f%aI%x1f%s
+func parseLogHeader(header string) (Commit, error) {
+	fields := strings.Split(strings.TrimSpace(header), "\x1f")
+	if len(fields) != 4 {
+		return Commit{}, fmt.Errorf("unexpected git log output %q", header)
+	}
+	date, err := time.Parse(time.RFC3339, fields[2])
+	if err != nil {
+		return Commit{}, fmt.Errorf("unexpected git log date %q: %v", fields[2], err)
+	}
+	return Commit{Hash: fields[0], Author: fields[1], Date: date, Subject: fields[3]}, nil
+}
+
+// Fetch downloads the upstream branch without changing the store
+func (r *CLIRepo) Fetch() error {
+	unlock := lock(r.Root)
+	defer unlock()
+	_, err := r.run("fetch")
+	return err
+}
+
+// Pull rebases local commits onto the upstream branch, like git pull --rebase.
+// A rebase that stops on conflicts stays in progress.
+func (r *CLIRepo) Pull() error {
+	unlock := lock(r.Root)
+	defer unlock()
+	if r.RebaseInProgress() {
+		return ErrRebaseInProgress
+	}
+	if _, err := r.run("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err != nil {
+		return ErrNoUpstream
+	}
+	_, err := r.run("pull", "--rebase")
+	return r.conflictOr(err)
+}
+
+// Push uploads local commits to the upstream branch
+func (r *CLIRepo) Push() error {
+	unlock := lock(r.Root)
+	defer unlock()
+	_, err := r.run("push")
+	return err
+}
+
+// Show returns the contents of path at a revision
+func (r *CLIRepo) Show(rev, path string) ([]byte, error) {
+	output, err := r.run("show", rev+":"+path)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
+// Files lists the files at or below path at a revision
+func (r *CLIRepo) Files(rev, path string) ([]string, error) {
+	output, err := r.run("ls-tree", "-r", "-z", "--name-only", rev, "--", path)
+	if err != nil {
+		return nil, err
+	}
+	output = strings.TrimRight(output, "\x00")
+	if output == "" {
+		return nil, nil
+	}
+	return strings.Split(output, "\x00"), nil
+}
+
+// AheadBehind counts the local commits not pushed yet and the fetched upstream
+// commits not pulled yet
+func (r *CLIRepo) AheadBehind() (ahead, behind int, err error) {
+	if _, err := r.run("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err != nil {
+		return 0, 0, ErrNoUpstream
+	}
+	output, err := r.run("rev-list", "--left-right", "--count", "HEAD...@{upstream}")
+	if err != nil {
+		return 0, 0, err
+	}
+	counts := strings.Fields(output)
+	if len(counts) != 2 {
+		return 0, 0, fmt.Errorf("unexpected git rev-list output %q", output)
+	}
+	if ahead, err = strconv.Atoi(counts[0]); err == nil {
+		behind, err = strconv.Atoi(counts[1])
+	}
+	return ahead, behind, err
+}
+
+// RebaseInProgress reports whether a rebase stopped in the repository
+func (r *CLIRepo) RebaseInProgress() bool {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		if _, err := os.Stat(filepath.Join(r.Root, ".git", dir)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Conflicts lists the paths that are still conflicted
+func (r *CLIRepo) Conflicts() ([]string, error) {
+	output, err := r.run("diff", "--name-only", "--diff-filter=U", "-z")
+	if err != nil {
+		return nil, err
+	}
+	output = strings.TrimRight(output, "\x00")
+	if output == "" {
+		return nil, nil
+	}
+	return strings.Split(output, "\x00"), nil
+}
+
+// Versions reads the base, remote and local versions of a conflicted path from the index
+func (r *CLIRepo) Versions(path string) (*ConflictVersions, error) {
+	output, err := r.run("ls-files", "-u", "-z", "--", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each line is "<mode> <object> <stage>\t<path>"; stages 1 to 3 are base, ours and theirs
+	versions := &ConflictVersions{}
+	for _, line := range strings.Split(strings.TrimRight(output, "\x00"), "\x00") {
+		info, _, ok := strings.Cut(line, "\t")
+		fields := strings.Fields(info)
+		if !ok || len(fields) != 3 {
+			continue
+		}
+		content, err := r.run("cat-file", "blob", fields[1])
+		if err != nil {
+			return nil, err
+		}
+		// While rebasing, ours is the remote and theirs the local commit
+		switch fields[2] {
+		case "1":
+			versions.Base = []byte(content)
+		case "2":
+			versions.Remote = []byte(content)
+		case "3":
+			versions.Local = []byte(content)
+		}
+	}
+	if versions.Base == nil && versions.Remote == nil && versions.Local == nil {
+		return nil, fmt.Errorf("%s is not conflicted", path)
+	}
+	return versions, nil
+}
+
+// Resolve marks a conflicted path as resolved with whatever is in the working tree
+func (r *CLIRepo) Resolve(path string) error {
+	unlock := lock(r.Root)
+	defer unlock()
+	if _, err := os.Lstat(filepath.Join(r.Root, filepath.FromSlash(path))); err != nil {
+		_, err := r.run("rm", "-q", "--cached", "--ignore-unmatch", "--", path)
+		return err
+	}
+	_, err := r.run("add", "--", path)
+	return err
+}
+
+// ContinueRebase continues a rebase after every conflict was resolved
+func (r *CLIRepo) ContinueRebase() error {
+	unlock := lock(r.Root)
+	defer unlock()
+	if !r.RebaseInProgress() {
+		return ErrNoRebase
+	}
+	// An editor would wait for the user, the commit messages stay as they are
+	env := []string{"GIT_EDITOR=true"}
+	if staged, err := r.run("diff", "--cached", "--name-only"); err == nil && strings.TrimSpace(staged) == "" {
+		_, err := r.runEnv(env, "rebase", "--skip")
+		return r.conflictOr(err)
+	}
+	_, err := r.runEnv(env, "rebase", "--continue")
+	return r.conflictOr(err)
+}
+
+// AbortRebase gives up a rebase in progress
+func (r *CLIRepo) AbortRebase() error {
+	unlock := lock(r.Root)
+	defer unlock()
+	if !r.RebaseInProgress() {
+		return ErrNoRebase
+	}
+	_, err := r.run("rebase", "--abort")
+	return err
+}
+
+// conflictOr turns a failed git command into a *ConflictError when it stopped a
+// rebase on conflicts
+func (r *CLIRepo) conflictOr(err error) error {
+	if err == nil || !r.RebaseInProgress() {
+		return err
+	}
+	paths, conflictsErr := r.Conflicts()
+	if conflictsErr != nil || len(paths) == 0 {
+		return err
+	}
+	return &ConflictError{Paths: paths}
+}
+
+// run runs git in the repository and returns its standard output
+func (r *CLIRepo) run(args ...string) (string, error) {
+	return r.runEnv(nil, args...)
+}
+
+// runEnv runs git in the repository with extra environment variables
+func (r *CLIRepo) runEnv(env []string, args ...string) (string, error) {
+	cmd := exec.Command(r.Binary, args...)
+	cmd.Dir = r.Root
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		command := args[0]
+		if command == "-c" && len(args) > 2 {
+			command = args[2]
+		}
+		return "", fmt.Errorf("git %s failed: %v: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return string(output), nil
+}
@@ -0,0 +1,147 @@
+// Package gitrepo gives the viewer one interface to the git repository behind a
+// password store, backed either by the git command line tool or by go-git when
+// git is not installed.
+package gitrepo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotRepository is returned when the store has no .git
+	ErrNotRepository = errors.New("the password store is not a git repository")
+	// ErrNothingToCommit is returned by Commit when nothing is staged
+	ErrNothingToCommit = errors.New("nothing to commit")
+	// ErrNoUpstream is returned when the current branch does not track a remote branch
+	ErrNoUpstream = errors.New("the current branch has no upstream branch to sync with")
+	// ErrLocalChanges is returned when uncommitted changes keep the store from being pulled
+	ErrLocalChanges = errors.New("uncommitted changes keep the store from being pulled")
+	// ErrNoRebase is returned when continuing or aborting without a rebase in progress
+	ErrNoRebase = errors.New("no rebase in progress")
+	// ErrRebaseInProgress is returned when pulling while a rebase is stopped
+	ErrRebaseInProgress = errors.New("a rebase is in progress, resolve or abort it first")
+)
+
+// locks holds a *sync.Mutex per repository root. It serializes the operations
+// that write to a repository, so commits from background operations do not race
+// a sync for the index, while separate stores do not wait for each other.
+var locks sync.Map
+
+// lock takes the write lock of the repository in root and returns its unlock
+func lock(root string) (unlock func()) {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	mu, _ := locks.LoadOrStore(root, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// FileStatus is a changed file, described with the letters of git status --porcelain
+type FileStatus struct {
+	Path     string // Repository-relative path with forward slashes
+	Staging  byte   // State of the index: 'M', 'A', 'D', 'U', ' ' for unchanged and '?' for untracked
+	Worktree byte   // State of the working tree compared to the index, with the same letters
+}
+
+// Commit is a commit in the history of the repository
+type Commit struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Subject string
+	Path    string // Path of the followed file in this commit, which differs before it was renamed
+	Deleted bool   // The commit removed the followed file, so there is nothing to show
+}
+
+// ConflictError is returned when a rebase stops because files were changed on both
+// sides. The rebase stays in progress until every path is resolved with Resolve
+// and ContinueRebase is called, or AbortRebase gives up.
+type ConflictError struct {
+	Paths []string // Repository-relative paths of the conflicted files
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflicting changes in %s", strings.Join(e.Paths, ", "))
+}
+
+// ConflictVersions holds the versions of a conflicted file. A nil version did not
+// exist, for example because the file was removed on that side.
+type ConflictVersions struct {
+	Base   []byte // The last common version
+	Remote []byte // The version from the remote the rebase builds upon
+	Local  []byte // The version from the local commit being replayed
+}
+
+// Repository is the git repository of a password store
+type Repository interface {
+	// Status lists the files that differ from HEAD, including untracked ones, by path
+	Status() ([]FileStatus, error)
+	// Add stages the given repository-relative paths, including removed ones.
+	// Without paths every change in the working tree is staged.
+	Add(paths ...string) error
+	// Unstage puts the given paths in the index back to HEAD, keeping the working tree
+	Unstage(paths ...string) error
+	// Commit commits the staged changes with message
+	Commit(message string) error
+	// CommitPaths stages the given paths, including removed ones, and commits only
+	// those with message in one step. Changes staged for other paths stay staged.
+	CommitPaths(message string, paths ...string) error
+	// Log lists the commits that changed the file or directory at path, newest
+	// first. An empty path lists every commit.
+	Log(path string) ([]Commit, error)
+	// Follow lists the commits that changed the file at path, newest first, and
+	// follows it across renames like git log --follow. Path and Deleted of each
+	// commit tell where the file was and whether the commit removed it.
+	Follow(path string) ([]Commit, error)
+	// Show returns the contents of path at a revision such as a commit hash or HEAD
+	Show(rev, path string) ([]byte, error)
+	// Files lists the files at or below path at a revision
+	Files(rev, path string) ([]string, error)
+	// Fetch downloads the upstream branch without changing the store
+	Fetch() error
+	// Pull brings the current branch up to date with its upstream branch and
+	// rebases local commits onto it. A rebase that stops on conflicts stays in
+	// progress and returns a *ConflictError.
+	Pull() error
+	// Push uploads local commits to the upstream branch
+	Push() error
+	// AheadBehind counts the local commits not pushed yet and the fetched
+	// upstream commits not pulled yet
+	AheadBehind() (ahead, behind int, err error)
+	// RebaseInProgress reports whether a rebase stopped, for example on conflicts
+	RebaseInProgress() bool
+	// Conflicts lists the paths of a stopped rebase that are still conflicted
+	Conflicts() ([]string, error)
+	// Versions reads the base, remote and local versions of a conflicted path
+	Versions(path string) (*ConflictVersions, error)
+	// Resolve marks a conflicted path as resolved with whatever is in the working
+	// tree now. A removed file resolves the conflict by removing it.
+	Resolve(path string) error
+	// ContinueRebase continues a rebase after every conflict was resolved.
+	// Conflicts in later commits are returned as a *ConflictError. Local commits
+	// whose changes the resolution dropped entirely are skipped.
+	ContinueRebase() error
+	// AbortRebase gives up a rebase in progress and returns the branch and the
+	// working tree to where they were before pulling
+	AbortRebase() error
+}
+
+// Open opens the repository in root with the git command line tool, falling
+// back to go-git when git is not installed
+func Open(root string) (Repository, error) {
+	if _, err := os.Stat(filepath.Join(root, ".git")); err != nil {
+		return nil, ErrNotRepository
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return OpenGoGit(root)
+	}
+	return NewCLI(root), nil
+}
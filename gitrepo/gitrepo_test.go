@@ -0,0 +1,479 @@
+package gitrepo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// implementations opens a repository with every implementation; git itself is
+// only needed for the command line one
+func implementations(t *testing.T) map[string]func(root string) Repository {
+	opens := map[string]func(root string) Repository{
+		"gogit": func(root string) Repository {
+			repo, err := OpenGoGit(root)
+			require.NoError(t, err)
+			return repo
+		},
+	}
+	if _, err := exec.LookPath("git"); err == nil {
+		opens["cli"] = func(root string) Repository { return NewCLI(root) }
+	} else {
+		t.Log("git is not installed, testing go-git only")
+	}
+	return opens
+}
+
+// newTestRemote creates a bare repository holding one commit and returns its path
+func newTestRemote(t *testing.T) string {
+	t.Helper()
+	seed := t.TempDir()
+	repo, err := git.PlainInit(seed, false)
+	require.NoError(t, err)
+	writeFile(t, seed, "work/github.gpg", "first")
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, worktree.AddWithOptions(&git.AddOptions{All: true}))
+	_, err = worktree.Commit("Add work/github", &git.CommitOptions{
+		Author: &object.Signature{Name: "Seed", Email: "seed@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	bare := filepath.Join(t.TempDir(), "store.git")
+	_, err = git.PlainClone(bare, true, &git.CloneOptions{URL: filepath.Join(seed, ".git")})
+	require.NoError(t, err)
+	return bare
+}
+
+// newTestClone clones remote with an author configured for commits
+func newTestClone(t *testing.T, remote string) string {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainClone(dir, false, &git.CloneOptions{URL: remote})
+	require.NoError(t, err)
+	cfg, err := repo.Config()
+	require.NoError(t, err)
+	cfg.User.Name = "Test"
+	cfg.User.Email = "test@example.com"
+	require.NoError(t, repo.SetConfig(cfg))
+	return dir
+}
+
+func writeFile(t *testing.T, root, p, content string) {
+	t.Helper()
+	full := filepath.Join(root, filepath.FromSlash(p))
+	require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+	require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+}
+
+func TestOpenNotRepository(t *testing.T) {
+	_, err := Open(t.TempDir())
+	assert.ErrorIs(t, err, ErrNotRepository)
+	_, err = OpenGoGit(t.TempDir())
+	assert.ErrorIs(t, err, ErrNotRepository)
+}
+
+func TestStatusAddCommit(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			dir := newTestClone(t, newTestRemote(t))
+			repo := open(dir)
+
+			files, err := repo.Status()
+			require.NoError(t, err)
+			assert.Empty(t, files)
+
+			writeFile(t, dir, "work/github.gpg", "second")
+			writeFile(t, dir, "personal/mail.gpg", "new")
+			files, err = repo.Status()
+			require.NoError(t, err)
+			assert.Equal(t, []FileStatus{
+				{Path: "personal/mail.gpg", Staging: '?', Worktree: '?'},
+				{Path: "work/github.gpg", Staging: ' ', Worktree: 'M'},
+			}, files)
+
+			require.NoError(t, repo.Add("personal/mail.gpg"))
+			files, err = repo.Status()
+			require.NoError(t, err)
+			assert.Equal(t, []FileStatus{
+				{Path: "personal/mail.gpg", Staging: 'A', Worktree: ' '},
+				{Path: "work/github.gpg", Staging: ' ', Worktree: 'M'},
+			}, files)
+
+			require.NoError(t, repo.Commit("Add personal/mail"))
+			files, err = repo.Status()
+			require.NoError(t, err)
+			assert.Equal(t, []FileStatus{{Path: "work/github.gpg", Staging: ' ', Worktree: 'M'}}, files)
+
+			commits, err := repo.Log("")
+			require.NoError(t, err)
+			require.Len(t, commits, 2)
+			assert.Equal(t, "Add personal/mail", commits[0].Subject)
+			assert.Equal(t, "Test", commits[0].Author)
+			assert.Equal(t, "Add work/github", commits[1].Subject)
+		})
+	}
+}
+
+func TestAddEverything(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			dir := newTestClone(t, newTestRemote(t))
+			repo := open(dir)
+			writeFile(t, dir, "personal/mail.gpg", "new")
+			require.NoError(t, os.RemoveAll(filepath.Join(dir, "work")))
+
+			require.NoError(t, repo.Add())
+			files, err := repo.Status()
+			require.NoError(t, err)
+			assert.Equal(t, []FileStatus{
+				{Path: "personal/mail.gpg", Staging: 'A', Worktree: ' '},
+				{Path: "work/github.gpg", Staging: 'D', Worktree: ' '},
+			}, files)
+		})
+	}
+}
+
+func TestAddRemovedFolder(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			dir := newTestClone(t, newTestRemote(t))
+			repo := open(dir)
+			require.NoError(t, os.RemoveAll(filepath.Join(dir, "work")))
+
+			require.NoError(t, repo.Add("work"))
+			require.NoError(t, repo.Commit("Remove work"))
+
+			files, err := repo.Status()
+			require.NoError(t, err)
+			assert.Empty(t, files)
+			_, err = repo.Show("HEAD", "work/github.gpg")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestUnstage(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			dir := newTestClone(t, newTestRemote(t))
+			repo := open(dir)
+			writeFile(t, dir, "work/github.gpg", "second")
+			writeFile(t, dir, "personal/mail.gpg", "new")
+			require.NoError(t, repo.Add())
+
+			require.NoError(t, repo.Unstage("work/github.gpg", "personal/mail.gpg"))
+			files, err := repo.Status()
+			require.NoError(t, err)
+			assert.Equal(t, []FileStatus{
+				{Path: "personal/mail.gpg", Staging: '?', Worktree: '?'},
+				{Path: "work/github.gpg", Staging: ' ', Worktree: 'M'},
+			}, files)
+		})
+	}
+}
+
+func TestCommitPaths(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			dir := newTestClone(t, newTestRemote(t))
+			repo := open(dir)
+			writeFile(t, dir, "personal/mail.gpg", "staged elsewhere")
+			require.NoError(t, repo.Add("personal/mail.gpg"))
+			writeFile(t, dir, "personal/bank.gpg", "new")
+			require.NoError(t, os.RemoveAll(filepath.Join(dir, "work")))
+
+			require.NoError(t, repo.CommitPaths("Remove work; add personal/bank", "work", "personal/bank.gpg"))
+			commits, err := repo.Log("")
+			require.NoError(t, err)
+			require.Len(t, commits, 2)
+			assert.Equal(t, "Remove work; add personal/bank", commits[0].Subject)
+			_, err = repo.Show("HEAD", "work/github.gpg")
+			assert.Error(t, err)
+			_, err = repo.Show("HEAD", "personal/mail.gpg")
+			assert.Error(t, err, "changes staged for other paths must not be committed")
+
+			// They are still staged
+			files, err := repo.Status()
+			require.NoError(t, err)
+			assert.Equal(t, []FileStatus{{Path: "personal/mail.gpg", Staging: 'A', Worktree: ' '}}, files)
+
+			assert.ErrorIs(t, repo.CommitPaths("Nothing", "personal/bank.gpg"), ErrNothingToCommit)
+			assert.ErrorIs(t, repo.CommitPaths("Nothing"), ErrNothingToCommit)
+			files, err = repo.Status()
+			require.NoError(t, err)
+			assert.Equal(t, []FileStatus{{Path: "personal/mail.gpg", Staging: 'A', Worktree: ' '}}, files)
+		})
+	}
+}
+
+func TestCommitNothing(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			dir := newTestClone(t, newTestRemote(t))
+			repo := open(dir)
+			writeFile(t, dir, "work/github.gpg", "unstaged")
+
+			assert.ErrorIs(t, repo.Commit("Nothing"), ErrNothingToCommit)
+		})
+	}
+}
+
+func TestLogAndShow(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			dir := newTestClone(t, newTestRemote(t))
+			repo := open(dir)
+			writeFile(t, dir, "personal/mail.gpg", "mail")
+			require.NoError(t, repo.Add("personal/mail.gpg"))
+			require.NoError(t, repo.Commit("Add personal/mail"))
+			writeFile(t, dir, "work/github.gpg", "second")
+			require.NoError(t, repo.Add("work/github.gpg"))
+			require.NoError(t, repo.Commit("Edit work/github"))
+
+			commits, err := repo.Log("work/github.gpg")
+			require.NoError(t, err)
+			require.Len(t, commits, 2)
+			assert.Equal(t, "Edit work/github", commits[0].Subject)
+			assert.Equal(t, "Add work/github", commits[1].Subject)
+
+			commits, err = repo.Log("personal/")
+			require.NoError(t, err)
+			require.Len(t, commits, 1)
+			assert.Equal(t, "Add personal/mail", commits[0].Subject)
+
+			content, err := repo.Show("HEAD", "work/github.gpg")
+			require.NoError(t, err)
+			assert.Equal(t, "second", string(content))
+
+			all, err := repo.Log("")
+			require.NoError(t, err)
+			content, err = repo.Show(all[len(all)-1].Hash, "work/github.gpg")
+			require.NoError(t, err)
+			assert.Equal(t, "first", string(content))
+		})
+	}
+}
+
+func TestPushFetchPull(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			remote := newTestRemote(t)
+			dir := newTestClone(t, remote)
+			other := newTestClone(t, remote)
+
+			repo := open(dir)
+			writeFile(t, dir, "personal/mail.gpg", "mail")
+			require.NoError(t, repo.Add())
+			require.NoError(t, repo.Commit("Add personal/mail"))
+			require.NoError(t, repo.Push())
+
+			otherRepo := open(other)
+			require.NoError(t, otherRepo.Fetch())
+			_, err := os.Stat(filepath.Join(other, "personal", "mail.gpg"))
+			assert.True(t, os.IsNotExist(err), "fetching must not change the store")
+
+			require.NoError(t, otherRepo.Pull())
+			content, err := os.ReadFile(filepath.Join(other, "personal", "mail.gpg"))
+			require.NoError(t, err)
+			assert.Equal(t, "mail", string(content))
+
+			// Nothing new is fine
+			require.NoError(t, otherRepo.Pull())
+			require.NoError(t, otherRepo.Push())
+		})
+	}
+}
+
+func TestPullWithoutUpstream(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			_, err := git.PlainInit(dir, false)
+			require.NoError(t, err)
+
+			assert.ErrorIs(t, open(dir).Pull(), ErrNoUpstream)
+		})
+	}
+}
+
+// commitFile writes, stages and commits one file
+func commitFile(t *testing.T, repo Repository, root, p, content, message string) {
+	t.Helper()
+	writeFile(t, root, p, content)
+	require.NoError(t, repo.Add(p))
+	require.NoError(t, repo.Commit(message))
+}
+
+func TestPullRebase(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			remote := newTestRemote(t)
+			dir, other := newTestClone(t, remote), newTestClone(t, remote)
+			repo, otherRepo := open(dir), open(other)
+			commitFile(t, repo, dir, "personal/mail.gpg", "mail", "Add personal/mail")
+			require.NoError(t, repo.Push())
+			commitFile(t, otherRepo, other, "personal/bank.gpg", "bank", "Add personal/bank")
+
+			require.NoError(t, otherRepo.Fetch())
+			ahead, behind, err := otherRepo.AheadBehind()
+			require.NoError(t, err)
+			assert.Equal(t, [2]int{1, 1}, [2]int{ahead, behind})
+
+			require.NoError(t, otherRepo.Pull())
+			assert.False(t, otherRepo.RebaseInProgress())
+			for p, want := range map[string]string{"personal/mail.gpg": "mail", "personal/bank.gpg": "bank"} {
+				content, err := os.ReadFile(filepath.Join(other, filepath.FromSlash(p)))
+				require.NoError(t, err)
+				assert.Equal(t, want, string(content))
+			}
+			commits, err := otherRepo.Log("")
+			require.NoError(t, err)
+			require.Len(t, commits, 3)
+			assert.Equal(t, "Add personal/bank", commits[0].Subject)
+			assert.Equal(t, "Add personal/mail", commits[1].Subject)
+			ahead, behind, err = otherRepo.AheadBehind()
+			require.NoError(t, err)
+			assert.Equal(t, [2]int{1, 0}, [2]int{ahead, behind})
+			files, err := otherRepo.Status()
+			require.NoError(t, err)
+			assert.Empty(t, files)
+		})
+	}
+}
+
+// newTestConflict makes both clones of a remote edit work/github.gpg and
+// returns the second one, pulled into a stopped rebase
+func newTestConflict(t *testing.T, open func(root string) Repository) (string, Repository) {
+	t.Helper()
+	remote := newTestRemote(t)
+	dir, other := newTestClone(t, remote), newTestClone(t, remote)
+	repo, otherRepo := open(dir), open(other)
+	commitFile(t, repo, dir, "work/github.gpg", "remote", "Edit work/github")
+	require.NoError(t, repo.Push())
+	commitFile(t, otherRepo, other, "work/github.gpg", "local", "Edit work/github too")
+	commitFile(t, otherRepo, other, "personal/bank.gpg", "bank", "Add personal/bank")
+
+	var conflict *ConflictError
+	require.ErrorAs(t, otherRepo.Pull(), &conflict)
+	assert.Equal(t, []string{"work/github.gpg"}, conflict.Paths)
+	return other, otherRepo
+}
+
+func TestPullConflict(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			dir, repo := newTestConflict(t, open)
+			assert.True(t, repo.RebaseInProgress())
+			assert.ErrorIs(t, repo.Pull(), ErrRebaseInProgress)
+
+			conflicts, err := repo.Conflicts()
+			require.NoError(t, err)
+			assert.Equal(t, []string{"work/github.gpg"}, conflicts)
+			versions, err := repo.Versions("work/github.gpg")
+			require.NoError(t, err)
+			assert.Equal(t, "first", string(versions.Base))
+			assert.Equal(t, "remote", string(versions.Remote))
+			assert.Equal(t, "local", string(versions.Local))
+
+			var conflict *ConflictError
+			assert.ErrorAs(t, repo.ContinueRebase(), &conflict)
+
+			writeFile(t, dir, "work/github.gpg", "merged")
+			require.NoError(t, repo.Resolve("work/github.gpg"))
+			conflicts, err = repo.Conflicts()
+			require.NoError(t, err)
+			assert.Empty(t, conflicts)
+			require.NoError(t, repo.ContinueRebase())
+
+			assert.False(t, repo.RebaseInProgress())
+			assert.ErrorIs(t, repo.ContinueRebase(), ErrNoRebase)
+			commits, err := repo.Log("")
+			require.NoError(t, err)
+			require.Len(t, commits, 4)
+			assert.Equal(t, "Add personal/bank", commits[0].Subject)
+			assert.Equal(t, "Edit work/github too", commits[1].Subject)
+			assert.Equal(t, "Edit work/github", commits[2].Subject)
+			content, err := repo.Show("HEAD", "work/github.gpg")
+			require.NoError(t, err)
+			assert.Equal(t, "merged", string(content))
+			content, err = repo.Show("HEAD", "personal/bank.gpg")
+			require.NoError(t, err)
+			assert.Equal(t, "bank", string(content))
+			files, err := repo.Status()
+			require.NoError(t, err)
+			assert.Empty(t, files)
+		})
+	}
+}
+
+func TestAbortRebase(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			dir, repo := newTestConflict(t, open)
+			require.NoError(t, repo.AbortRebase())
+
+			assert.False(t, repo.RebaseInProgress())
+			assert.ErrorIs(t, repo.AbortRebase(), ErrNoRebase)
+			commits, err := repo.Log("")
+			require.NoError(t, err)
+			require.Len(t, commits, 3)
+			assert.Equal(t, "Add personal/bank", commits[0].Subject)
+			assert.Equal(t, "Edit work/github too", commits[1].Subject)
+			for p, want := range map[string]string{"work/github.gpg": "local", "personal/bank.gpg": "bank"} {
+				content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(p)))
+				require.NoError(t, err)
+				assert.Equal(t, want, string(content))
+			}
+			files, err := repo.Status()
+			require.NoError(t, err)
+			assert.Empty(t, files)
+		})
+	}
+}
+
+func TestFollowAndFiles(t *testing.T) {
+	for name, open := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			dir := newTestClone(t, newTestRemote(t))
+			repo := open(dir)
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "old"), 0755))
+			require.NoError(t, os.Rename(filepath.Join(dir, "work", "github.gpg"), filepath.Join(dir, "old", "github.gpg")))
+			require.NoError(t, repo.Add())
+			require.NoError(t, repo.Commit("Move work/github to old/github"))
+			commitFile(t, repo, dir, "old/github.gpg", "second", "Edit old/github")
+
+			commits, err := repo.Follow("old/github.gpg")
+			require.NoError(t, err)
+			require.Len(t, commits, 3)
+			assert.Equal(t, "Edit old/github", commits[0].Subject)
+			assert.Equal(t, "old/github.gpg", commits[0].Path)
+			assert.Equal(t, "old/github.gpg", commits[1].Path)
+			assert.Equal(t, "Add work/github", commits[2].Subject)
+			assert.Equal(t, "work/github.gpg", commits[2].Path)
+
+			files, err := repo.Files(commits[2].Hash, "work/")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"work/github.gpg"}, files)
+			files, err = repo.Files("HEAD", "work/")
+			require.NoError(t, err)
+			assert.Empty(t, files)
+		})
+	}
+}
+
+func TestGoGitPullLocalChanges(t *testing.T) {
+	dir := newTestClone(t, newTestRemote(t))
+	repo, err := OpenGoGit(dir)
+	require.NoError(t, err)
+	writeFile(t, dir, "work/github.gpg", "edited")
+
+	assert.ErrorIs(t, repo.Pull(), ErrLocalChanges)
+}
@@ -0,0 +1,512 @@
+package gitrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// GoGitRepo accesses the repository in-process with go-git
+type GoGitRepo struct {
+	root string
+	repo *git.Repository
+}
+
+// OpenGoGit opens the repository in root with go-git
+func OpenGoGit(root string) (*GoGitRepo, error) {
+	repo, err := git.PlainOpen(root)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, ErrNotRepository
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening git repository: %w", err)
+	}
+	installLocalTransport()
+	return &GoGitRepo{root: root, repo: repo}, nil
+}
+
+// Status lists the files that differ from HEAD, including untracked ones, by path
+func (r *GoGitRepo) Status() ([]FileStatus, error) {
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("error reading git status: %w", err)
+	}
+
+	var files []FileStatus
+	for p, s := range status {
+		if s.Staging == git.Unmodified && s.Worktree == git.Unmodified {
+			continue
+		}
+		files = append(files, FileStatus{Path: p, Staging: byte(s.Staging), Worktree: byte(s.Worktree)})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Add stages the given paths, including removed ones, or every change without paths
+func (r *GoGitRepo) Add(paths ...string) error {
+	unlock := lock(r.root)
+	defer unlock()
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return worktree.AddWithOptions(&git.AddOptions{All: true})
+	}
+	return r.add(worktree, paths)
+}
+
+// add stages paths like Add without taking the lock
+func (r *GoGitRepo) add(worktree *git.Worktree, paths []string) error {
+	for _, p := range paths {
+		p = path.Clean(p)
+		if _, err := worktree.Filesystem.Lstat(p); err != nil {
+			// go-git only removes single files from the index, folders are done here
+			if err := r.removeFromIndex(p); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := worktree.AddWithOptions(&git.AddOptions{Path: p}); err != nil {
+			return fmt.Errorf("error staging %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// removeFromIndex drops p and everything below it from the index
+func (r *GoGitRepo) removeFromIndex(p string) error {
+	index, err := r.repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+	entries := index.Entries[:0]
+	for _, entry := range index.Entries {
+		if entry.Name != p && !strings.HasPrefix(entry.Name, p+"/") {
+			entries = append(entries, entry)
+		}
+	}
+	index.Entries = entries
+	return r.repo.Storer.SetIndex(index)
+}
+
+// Unstage puts the given paths in the index back to HEAD, keeping the working tree
+func (r *GoGitRepo) Unstage(paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	unlock := lock(r.root)
+	defer unlock()
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if err := worktree.Reset(&git.ResetOptions{Mode: git.MixedReset, Files: paths}); err != nil {
+		return fmt.Errorf("error unstaging: %w", err)
+	}
+	return nil
+}
+
+// Commit commits the staged changes with message, using the author from the git configuration
+func (r *GoGitRepo) Commit(message string) error {
+	unlock := lock(r.root)
+	defer unlock()
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	_, err = worktree.Commit(message, &git.CommitOptions{})
+	if errors.Is(err, git.ErrEmptyCommit) {
+		return ErrNothingToCommit
+	}
+	if err != nil {
+		return fmt.Errorf("error committing: %w", err)
+	}
+	return nil
+}
+
+// CommitPaths stages the given paths and commits only those with message
+func (r *GoGitRepo) CommitPaths(message string, paths ...string) error {
+	if len(paths) == 0 {
+		return ErrNothingToCommit
+	}
+	unlock := lock(r.root)
+	defer unlock()
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	// go-git commits the whole index, so changes staged for other paths are set
+	// aside and staged again after the commit
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("error reading git status: %w", err)
+	}
+	var others []string
+	for p, s := range status {
+		if s.Staging != git.Unmodified && s.Staging != git.Untracked && !below(p, paths) {
+			others = append(others, p)
+		}
+	}
+	saved, err := r.repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+	if len(others) > 0 {
+		if err := worktree.Reset(&git.ResetOptions{Mode: git.MixedReset, Files: others}); err != nil {
+			return fmt.Errorf("error unstaging: %w", err)
+		}
+	}
+
+	err = r.add(worktree, paths)
+	if err == nil {
+		_, err = worktree.Commit(message, &git.CommitOptions{})
+	}
+	if restoreErr := r.restoreIndex(saved, others); restoreErr != nil && err == nil {
+		err = restoreErr
+	}
+	if errors.Is(err, git.ErrEmptyCommit) {
+		return ErrNothingToCommit
+	}
+	if err != nil {
+		return fmt.Errorf("error committing: %w", err)
+	}
+	return nil
+}
+
+// restoreIndex puts the entries of names back as they are in saved
+func (r *GoGitRepo) restoreIndex(saved *index.Index, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+	entries := idx.Entries[:0]
+	for _, entry := range idx.Entries {
+		if !slices.Contains(names, entry.Name) {
+			entries = append(entries, entry)
+		}
+	}
+	for _, entry := range saved.Entries {
+		if slices.Contains(names, entry.Name) {
+			entries = append(entries, entry)
+		}
+	}
+	idx.Entries = entries
+	return r.repo.Storer.SetIndex(idx)
+}
+
+// below reports whether p is one of paths or inside one of them
+func below(p string, paths []string) bool {
+	for _, dir := range paths {
+		dir = path.Clean(dir)
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Log lists the commits that changed path, newest first
+func (r *GoGitRepo) Log(p string) ([]Commit, error) {
+	if _, err := r.repo.Head(); errors.Is(err, plumbing.ErrReferenceNotFound) {
+		// A repository without commits has no history yet
+		return nil, nil
+	}
+	options := &git.LogOptions{Order: git.LogOrderCommitterTime}
+	if p = strings.TrimSuffix(p, "/"); p != "" {
+		options.PathFilter = func(name string) bool {
+			return name == p || strings.HasPrefix(name, p+"/")
+		}
+	}
+	iter, err := r.repo.Log(options)
+	if err != nil {
+		return nil, fmt.Errorf("error reading git log: %w", err)
+	}
+	defer iter.Close()
+
+	var commits []Commit
+	err = iter.ForEach(func(c *object.Commit) error {
+		commits = append(commits, newCommit(c))
+		return nil
+	})
+	return commits, err
+}
+
+// Follow lists the commits that changed the file at path across renames, newest first
+func (r *GoGitRepo) Follow(p string) ([]Commit, error) {
+	if _, err := r.repo.Head(); errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+	iter, err := r.repo.Log(&git.LogOptions{Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, fmt.Errorf("error reading git log: %w", err)
+	}
+	defer iter.Close()
+
+	var commits []Commit
+	err = iter.ForEach(func(c *object.Commit) error {
+		// Like git log, merges are not compared with their parents
+		if c.NumParents() > 1 {
+			return nil
+		}
+		changes, err := changesOf(c, object.DefaultDiffTreeOptions)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if change.To.Name != p && (change.To.Name != "" || change.From.Name != p) {
+				continue
+			}
+			commit := newCommit(c)
+			commit.Path = p
+			commit.Deleted = change.To.Name == ""
+			commits = append(commits, commit)
+			// Older commits know the file by its name before a rename
+			if change.From.Name != "" {
+				p = change.From.Name
+			}
+			break
+		}
+		return nil
+	})
+	return commits, err
+}
+
+// changesOf compares a commit with its first parent, or with nothing for the first commit
+func changesOf(c *object.Commit, options *object.DiffTreeOptions) (object.Changes, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	changes, err := object.DiffTreeWithOptions(context.Background(), parentTree, tree, options)
+	if err != nil {
+		return nil, fmt.Errorf("error comparing %s with its parent: %w", c.Hash, err)
+	}
+	return changes, nil
+}
+
+// newCommit describes a go-git commit
+func newCommit(c *object.Commit) Commit {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return Commit{
+		Hash:    c.Hash.String(),
+		Author:  c.Author.Name,
+		Date:    c.Author.When,
+		Subject: subject,
+	}
+}
+
+// Fetch downloads the upstream branch without changing the store
+func (r *GoGitRepo) Fetch() error {
+	unlock := lock(r.root)
+	defer unlock()
+	remote := git.DefaultRemoteName
+	if branch, err := r.upstream(); err == nil {
+		remote = branch.Remote
+	}
+	err := r.repo.Fetch(&git.FetchOptions{RemoteName: remote})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("error fetching: %w", err)
+	}
+	return nil
+}
+
+// Pull fast-forwards the current branch to its upstream branch, or rebases local
+// commits that are not upstream yet onto it
+func (r *GoGitRepo) Pull() error {
+	unlock := lock(r.root)
+	defer unlock()
+	if r.RebaseInProgress() {
+		return ErrRebaseInProgress
+	}
+	branch, err := r.upstream()
+	if err != nil {
+		return err
+	}
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	// go-git moves HEAD before it notices that the working tree is in the way
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("error reading git status: %w", err)
+	}
+	for _, s := range status {
+		if s.Staging != git.Untracked && (s.Staging != git.Unmodified || s.Worktree != git.Unmodified) {
+			return ErrLocalChanges
+		}
+	}
+
+	err = worktree.Pull(&git.PullOptions{RemoteName: branch.Remote, ReferenceName: branch.Merge})
+	switch {
+	case err == nil, errors.Is(err, git.NoErrAlreadyUpToDate):
+		return nil
+	case errors.Is(err, git.ErrNonFastForwardUpdate):
+		return r.rebase(worktree, branch, status)
+	default:
+		return fmt.Errorf("error pulling: %w", err)
+	}
+}
+
+// Push uploads the current branch to its upstream branch
+func (r *GoGitRepo) Push() error {
+	unlock := lock(r.root)
+	defer unlock()
+	branch, err := r.upstream()
+	if err != nil {
+		return err
+	}
+	refSpec := config.RefSpec(fmt.Sprintf("refs/heads/%s:%s", branch.Name, branch.Merge))
+	err = r.repo.Push(&git.PushOptions{RemoteName: branch.Remote, RefSpecs: []config.RefSpec{refSpec}})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("error pushing: %w", err)
+	}
+	return nil
+}
+
+// Show returns the contents of path at a revision
+func (r *GoGitRepo) Show(rev, p string) ([]byte, error) {
+	commit, err := r.commitAt(rev)
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(p)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s at %s: %w", p, rev, err)
+	}
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// Files lists the files at or below path at a revision
+func (r *GoGitRepo) Files(rev, p string) ([]string, error) {
+	commit, err := r.commitAt(rev)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	p = path.Clean(p)
+	var files []string
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if f.Name == p || strings.HasPrefix(f.Name, p+"/") {
+			files = append(files, f.Name)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// AheadBehind counts the local commits not pushed yet and the fetched upstream
+// commits not pulled yet
+func (r *GoGitRepo) AheadBehind() (ahead, behind int, err error) {
+	branch, err := r.upstream()
+	if err != nil {
+		return 0, 0, err
+	}
+	head, err := r.repo.Head()
+	if err != nil {
+		return 0, 0, err
+	}
+	upstream, err := r.repo.Reference(plumbing.NewRemoteReferenceName(branch.Remote, branch.Merge.Short()), true)
+	if err != nil {
+		return 0, 0, ErrNoUpstream
+	}
+
+	local, err := r.ancestors(head.Hash())
+	if err != nil {
+		return 0, 0, err
+	}
+	remote, err := r.ancestors(upstream.Hash())
+	if err != nil {
+		return 0, 0, err
+	}
+	for hash := range local {
+		if !remote[hash] {
+			ahead++
+		}
+	}
+	for hash := range remote {
+		if !local[hash] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// ancestors returns the commit hash and every commit reachable from it
+func (r *GoGitRepo) ancestors(hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	iter, err := r.repo.Log(&git.LogOptions{From: hash})
+	if err != nil {
+		return nil, fmt.Errorf("error reading git log: %w", err)
+	}
+	defer iter.Close()
+	commits := make(map[plumbing.Hash]bool)
+	err = iter.ForEach(func(c *object.Commit) error {
+		commits[c.Hash] = true
+		return nil
+	})
+	return commits, err
+}
+
+// commitAt resolves a revision such as a commit hash or HEAD to its commit
+func (r *GoGitRepo) commitAt(rev string) (*object.Commit, error) {
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %s: %w", rev, err)
+	}
+	return r.repo.CommitObject(*hash)
+}
+
+// upstream returns the configuration of the current branch, which names the
+// remote branch it tracks
+func (r *GoGitRepo) upstream() (*config.Branch, error) {
+	head, err := r.repo.Head()
+	if err != nil || !head.Name().IsBranch() {
+		return nil, ErrNoUpstream
+	}
+	branch, err := r.repo.Branch(head.Name().Short())
+	if err != nil || branch.Remote == "" || branch.Merge == "" {
+		return nil, ErrNoUpstream
+	}
+	return branch, nil
+}
@@ -0,0 +1,452 @@
+package gitrepo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"main.go/internal/fsutil"
+)
+
+// rebaseStateFile holds a go-git rebase that stopped on conflicts, relative to
+// .git. git keeps its own rebases in rebase-merge, which this is not compatible with.
+const rebaseStateFile = "gitrepo-rebase.json"
+
+// rebaseState is a rebase of local commits onto the upstream branch. go-git has
+// no rebase, so files are merged as a whole: a file changed on both sides is a
+// conflict, which suits encrypted entries that cannot be merged line by line anyway.
+type rebaseState struct {
+	Branch    string   `json:"branch"`    // The branch being rebased, such as refs/heads/main
+	OrigHead  string   `json:"orig_head"` // Where the branch was before the rebase
+	Todo      []string `json:"todo"`      // Local commits still to replay, oldest first; the first one stopped
+	Conflicts []string `json:"conflicts"` // Paths of the stopped commit that are not resolved yet
+}
+
+// RebaseInProgress reports whether a rebase stopped in the repository
+func (r *GoGitRepo) RebaseInProgress() bool {
+	_, err := os.Stat(r.statePath())
+	return err == nil
+}
+
+// Conflicts lists the paths that are still conflicted
+func (r *GoGitRepo) Conflicts() ([]string, error) {
+	state, err := r.loadState()
+	if errors.Is(err, ErrNoRebase) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return state.Conflicts, nil
+}
+
+// Versions reads the base, remote and local versions of a conflicted path from
+// the commit being replayed, its parent and HEAD
+func (r *GoGitRepo) Versions(p string) (*ConflictVersions, error) {
+	state, err := r.loadState()
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(state.Conflicts, p) {
+		return nil, fmt.Errorf("%s is not conflicted", p)
+	}
+	local, err := r.repo.CommitObject(plumbing.NewHash(state.Todo[0]))
+	if err != nil {
+		return nil, err
+	}
+	base, err := local.Parent(0)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := r.commitAt("HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := &ConflictVersions{}
+	for _, version := range []struct {
+		commit  *object.Commit
+		content *[]byte
+	}{
+		{base, &versions.Base},
+		{remote, &versions.Remote},
+		{local, &versions.Local},
+	} {
+		if *version.content, err = fileContent(version.commit, p); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+// Resolve marks a conflicted path as resolved with whatever is in the working tree
+func (r *GoGitRepo) Resolve(p string) error {
+	unlock := lock(r.root)
+	defer unlock()
+	state, err := r.loadState()
+	if err != nil {
+		return err
+	}
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if err := r.add(worktree, []string{p}); err != nil {
+		return err
+	}
+	state.Conflicts = slices.DeleteFunc(state.Conflicts, func(c string) bool { return c == p })
+	return r.saveState(state)
+}
+
+// ContinueRebase commits the resolved commit and replays the remaining ones
+func (r *GoGitRepo) ContinueRebase() error {
+	unlock := lock(r.root)
+	defer unlock()
+	state, err := r.loadState()
+	if err != nil {
+		return err
+	}
+	if len(state.Conflicts) > 0 {
+		return &ConflictError{Paths: state.Conflicts}
+	}
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	commit, err := r.repo.CommitObject(plumbing.NewHash(state.Todo[0]))
+	if err != nil {
+		return err
+	}
+	if err := r.commitReplayed(worktree, commit); err != nil {
+		return err
+	}
+	state.Todo = state.Todo[1:]
+	return r.replay(worktree, state)
+}
+
+// AbortRebase puts back every file the rebase changed and the branch where it was
+func (r *GoGitRepo) AbortRebase() error {
+	unlock := lock(r.root)
+	defer unlock()
+	state, err := r.loadState()
+	if err != nil {
+		return err
+	}
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	head, err := r.commitAt("HEAD")
+	if err != nil {
+		return err
+	}
+	orig, err := r.repo.CommitObject(plumbing.NewHash(state.OrigHead))
+	if err != nil {
+		return err
+	}
+
+	// The files replayed so far and those of the stopped commit, resolved or not
+	paths, err := changedPaths(head, orig)
+	if err != nil {
+		return err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("error reading git status: %w", err)
+	}
+	for p, s := range status {
+		if s.Staging != git.Untracked && (s.Staging != git.Unmodified || s.Worktree != git.Unmodified) {
+			paths = append(paths, p)
+		}
+	}
+	for _, p := range append(paths, state.Conflicts...) {
+		if err := r.checkoutFile(worktree, orig, p); err != nil {
+			return err
+		}
+	}
+	return r.endRebase(state, orig.Hash)
+}
+
+// rebase replays the local commits that are not upstream yet onto the upstream
+// branch. status is the state of the working tree, which has no local changes.
+func (r *GoGitRepo) rebase(worktree *git.Worktree, branch *config.Branch, status git.Status) error {
+	head, err := r.repo.Head()
+	if err != nil {
+		return err
+	}
+	local, err := r.repo.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	upstream, err := r.repo.Reference(plumbing.NewRemoteReferenceName(branch.Remote, branch.Merge.Short()), true)
+	if err != nil {
+		return ErrNoUpstream
+	}
+	onto, err := r.repo.CommitObject(upstream.Hash())
+	if err != nil {
+		return err
+	}
+
+	// Local commits, newest first, until the history reaches the upstream branch.
+	// Merge commits are left out like git rebase does.
+	state := &rebaseState{Branch: head.Name().String(), OrigHead: head.Hash().String()}
+	for c := local; ; {
+		upstreamHas, err := c.IsAncestor(onto)
+		if err != nil {
+			return err
+		}
+		if upstreamHas || c.NumParents() == 0 {
+			break
+		}
+		if c.NumParents() == 1 {
+			state.Todo = append(state.Todo, c.Hash.String())
+		}
+		if c, err = c.Parent(0); err != nil {
+			return err
+		}
+	}
+	slices.Reverse(state.Todo)
+
+	// Start from the upstream branch with HEAD detached, so the branch only moves
+	// once every commit is replayed
+	paths, err := changedPaths(local, onto)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if s, ok := status[p]; ok && s.Staging == git.Untracked {
+			return fmt.Errorf("the untracked file %s would be overwritten: %w", p, ErrLocalChanges)
+		}
+	}
+	if err := r.saveState(state); err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if err := r.checkoutFile(worktree, onto, p); err != nil {
+			return err
+		}
+	}
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, onto.Hash)); err != nil {
+		return err
+	}
+	return r.replay(worktree, state)
+}
+
+// replay applies the commits still to do one after another and finishes the
+// rebase, or stops on the first commit with conflicts
+func (r *GoGitRepo) replay(worktree *git.Worktree, state *rebaseState) error {
+	for len(state.Todo) > 0 {
+		commit, err := r.repo.CommitObject(plumbing.NewHash(state.Todo[0]))
+		if err != nil {
+			return err
+		}
+		conflicts, err := r.apply(worktree, commit)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			state.Conflicts = conflicts
+			if err := r.saveState(state); err != nil {
+				return err
+			}
+			return &ConflictError{Paths: conflicts}
+		}
+		if err := r.commitReplayed(worktree, commit); err != nil {
+			return err
+		}
+		state.Todo = state.Todo[1:]
+	}
+	head, err := r.repo.Head()
+	if err != nil {
+		return err
+	}
+	return r.endRebase(state, head.Hash())
+}
+
+// apply changes the files a commit changed, unless HEAD changed them differently.
+// Those are conflicts and keep the version of HEAD for now.
+func (r *GoGitRepo) apply(worktree *git.Worktree, commit *object.Commit) ([]string, error) {
+	changes, err := changesOf(commit, nil)
+	if err != nil {
+		return nil, err
+	}
+	head, err := r.commitAt("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	tree, err := head.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	for _, change := range changes {
+		p := change.To.Name
+		if p == "" {
+			p = change.From.Name
+		}
+		var current plumbing.Hash
+		if entry, err := tree.FindEntry(p); err == nil {
+			current = entry.Hash
+		}
+		switch current {
+		case change.From.TreeEntry.Hash:
+			if err := r.checkoutFile(worktree, commit, p); err != nil {
+				return nil, err
+			}
+		case change.To.TreeEntry.Hash:
+			// The same change is upstream already
+		default:
+			conflicts = append(conflicts, p)
+		}
+	}
+	return conflicts, nil
+}
+
+// commitReplayed commits the index with the message and author of a replayed
+// commit. Commits whose changes are all upstream already are dropped.
+func (r *GoGitRepo) commitReplayed(worktree *git.Worktree, commit *object.Commit) error {
+	options := &git.CommitOptions{Author: &commit.Author}
+	if cfg, err := r.repo.ConfigScoped(config.SystemScope); err == nil && cfg.User.Name != "" {
+		options.Committer = &object.Signature{Name: cfg.User.Name, Email: cfg.User.Email, When: time.Now()}
+	}
+	_, err := worktree.Commit(commit.Message, options)
+	if err != nil && !errors.Is(err, git.ErrEmptyCommit) {
+		return fmt.Errorf("error committing %s: %w", commit.Hash, err)
+	}
+	return nil
+}
+
+// endRebase points the branch at hash, attaches HEAD to it again and forgets the rebase
+func (r *GoGitRepo) endRebase(state *rebaseState, hash plumbing.Hash) error {
+	branch := plumbing.ReferenceName(state.Branch)
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(branch, hash)); err != nil {
+		return err
+	}
+	if err := r.repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, branch)); err != nil {
+		return err
+	}
+	return os.Remove(r.statePath())
+}
+
+// checkoutFile writes path as it is in commit to the working tree and the index,
+// or removes it from both when the commit does not have it
+func (r *GoGitRepo) checkoutFile(worktree *git.Worktree, commit *object.Commit, p string) error {
+	full := filepath.Join(r.root, filepath.FromSlash(p))
+	file, err := commit.File(p)
+	if errors.Is(err, object.ErrFileNotFound) {
+		if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		fsutil.PruneEmptyDirs(r.root, filepath.Dir(full))
+		return r.removeFromIndex(p)
+	}
+	if err != nil {
+		return err
+	}
+
+	reader, err := file.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	perm := os.FileMode(0644)
+	if file.Mode == filemode.Executable {
+		perm = 0755
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(full, content, perm); err != nil {
+		return err
+	}
+	if _, err := worktree.Add(p); err != nil {
+		return fmt.Errorf("error staging %s: %w", p, err)
+	}
+	return nil
+}
+
+// changedPaths lists the files that differ between two commits
+func changedPaths(from, to *object.Commit) ([]string, error) {
+	fromTree, err := from.Tree()
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := to.Tree()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(changes))
+	for i, change := range changes {
+		paths[i] = change.To.Name
+		if paths[i] == "" {
+			paths[i] = change.From.Name
+		}
+	}
+	return paths, nil
+}
+
+// fileContent reads path at commit, nil if the commit does not have it
+func fileContent(commit *object.Commit, p string) ([]byte, error) {
+	file, err := commit.File(p)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, err := file.Contents()
+	return []byte(content), err
+}
+
+// statePath is where a stopped rebase is kept
+func (r *GoGitRepo) statePath() string {
+	return filepath.Join(r.root, ".git", rebaseStateFile)
+}
+
+// loadState reads the rebase in progress, or returns ErrNoRebase
+func (r *GoGitRepo) loadState() (*rebaseState, error) {
+	data, err := os.ReadFile(r.statePath())
+	if os.IsNotExist(err) {
+		return nil, ErrNoRebase
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := &rebaseState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", rebaseStateFile, err)
+	}
+	if len(state.Todo) == 0 {
+		return nil, fmt.Errorf("error reading %s: no commit to replay", rebaseStateFile)
+	}
+	return state, nil
+}
+
+// saveState writes the rebase in progress
+func (r *GoGitRepo) saveState(state *rebaseState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.statePath(), data, 0644)
+}
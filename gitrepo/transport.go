@@ -0,0 +1,65 @@
+package gitrepo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+)
+
+// installLocal installs localTransport once
+var installLocal sync.Once
+
+// installLocalTransport makes go-git serve remotes on disk in-process. go-git
+// runs git-upload-pack for them, which is not there without git. Transports are
+// global in go-git, so this happens when the first repository is opened with
+// go-git rather than for every program that imports the package.
+func installLocalTransport() {
+	installLocal.Do(func() {
+		client.InstallProtocol("file", localTransport{server.DefaultServer})
+	})
+}
+
+// localTransport serves remotes on disk with the go-git server
+type localTransport struct {
+	transport.Transport
+}
+
+// NewUploadPackSession starts a fetch from the remote repository at the endpoint
+func (t localTransport) NewUploadPackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.UploadPackSession, error) {
+	session, err := t.Transport.NewUploadPackSession(ep, auth)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := server.DefaultLoader.Load(ep)
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	return &uploadPackSession{UploadPackSession: session, objects: objects}, nil
+}
+
+// uploadPackSession is a fetch from a remote on disk
+type uploadPackSession struct {
+	transport.UploadPackSession
+	objects storer.EncodedObjectStorer
+}
+
+// UploadPack sends the objects the client wants. The go-git server fails on
+// commits the client has but the remote does not, such as local commits not
+// pushed yet, so those are left out of the request.
+func (s *uploadPackSession) UploadPack(ctx context.Context, req *packp.UploadPackRequest) (*packp.UploadPackResponse, error) {
+	var haves []plumbing.Hash
+	for _, hash := range req.Haves {
+		if s.objects.HasEncodedObject(hash) == nil {
+			haves = append(haves, hash)
+		}
+	}
+	req.Haves = haves
+	return s.UploadPackSession.UploadPack(ctx, req)
+}
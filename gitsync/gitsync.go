@@ -0,0 +1,282 @@
+package gitsync
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"main.go/gitrepo"
+	"main.go/storeops"
+)
+
+// Retries of failed syncs start after minBackoff and double up to maxBackoff,
+// but never wait longer than the sync interval
+const (
+	minBackoff = 30 * time.Second
+	maxBackoff = 30 * time.Minute
+)
+
+// ErrLocalChanges is returned when uncommitted changes keep the store from being
+// pulled. It is the error of gitrepo, so pulls report it the same way on every backend.
+var ErrLocalChanges = gitrepo.ErrLocalChanges
+
+// ErrConflicts is returned when pulling would conflict with local commits. The
+// background sync leaves merging to the Sync button and keeps the store as it was.
+var ErrConflicts = errors.New("remote changes conflict with local commits, press Sync to merge them")
+
+// Status describes how the store relates to its remote after a background sync
+type Status struct {
+	Ahead    int       // Local commits waiting to be pushed
+	Behind   int       // Remote commits not pulled yet
+	LastSync time.Time // When pulling and pushing last succeeded, zero if never
+	Err      error     // Why the last sync failed, nil if it succeeded
+	Retry    time.Time // When a failed sync is tried again
+}
+
+// String summarizes the status for the status bar, such as "↑1 ↓0 · synced 14:05"
+func (s Status) String() string {
+	text := fmt.Sprintf("↑%d ↓%d", s.Ahead, s.Behind)
+	if s.LastSync.IsZero() {
+		text += " · not synced yet"
+	} else {
+		text += " · synced " + s.LastSync.Format("15:04")
+	}
+	if s.Err != nil {
+		text += " · sync failed, retrying at " + s.Retry.Format("15:04")
+	}
+	return text
+}
+
+// Syncer pulls and pushes the store in the background: right away, every interval,
+// and after local commits. Pushes that fail, for example while offline, are
+// retried with backoff until they go through.
+type Syncer struct {
+	root     string
+	onStatus func(Status)
+
+	syncing    sync.Mutex // Held while a background sync runs or Hold keeps them off
+	mu         sync.Mutex
+	interval   time.Duration
+	status     Status
+	syncSoon   bool // Push asked for a sync
+	statusSoon bool // Synced asked for a status report
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSyncer starts syncing the store every interval. A zero interval syncs only
+// by hand. onStatus is called on the sync goroutine after every sync.
+func NewSyncer(root string, interval time.Duration, onStatus func(Status)) *Syncer {
+	s := &Syncer{
+		root:     root,
+		onStatus: onStatus,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// SetInterval changes how often the store is synced. Zero stops background syncs.
+func (s *Syncer) SetInterval(interval time.Duration) {
+	s.mu.Lock()
+	s.interval = interval
+	s.mu.Unlock()
+	s.wakeUp()
+}
+
+// Push syncs soon after a local commit, when background syncs are on
+func (s *Syncer) Push() {
+	s.mu.Lock()
+	s.syncSoon = true
+	s.mu.Unlock()
+	s.wakeUp()
+}
+
+// Synced reports a sync made by hand, so the status shows it
+func (s *Syncer) Synced() {
+	s.mu.Lock()
+	s.statusSoon = true
+	s.mu.Unlock()
+	s.wakeUp()
+}
+
+// Hold keeps background syncs off until release is called, after waiting for a
+// running one to finish. Syncs by hand hold it until they are done merging, so a
+// background sync never aborts a rebase that stopped for the user to merge.
+func (s *Syncer) Hold() (release func()) {
+	s.syncing.Lock()
+	var once sync.Once
+	return func() {
+		once.Do(s.syncing.Unlock)
+	}
+}
+
+// Stop ends background syncing and waits for a running sync to finish
+func (s *Syncer) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+// wakeUp makes the sync goroutine look at the requests and the interval again
+func (s *Syncer) wakeUp() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run syncs whenever the interval or the backoff elapsed or Push asked for it
+func (s *Syncer) run() {
+	defer close(s.done)
+
+	var backoff time.Duration
+	delay := time.Duration(0) // Pull when the application starts
+	started := false
+	for {
+		s.mu.Lock()
+		interval := s.interval
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		var wait <-chan time.Time
+		if interval > 0 {
+			timer = time.NewTimer(delay)
+			wait = timer.C
+		}
+
+		elapsed := false
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-wait:
+			elapsed = true
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		s.mu.Lock()
+		interval = s.interval
+		syncSoon, statusSoon := s.syncSoon, s.statusSoon
+		s.syncSoon, s.statusSoon = false, false
+		s.mu.Unlock()
+
+		due := interval > 0 && (elapsed || syncSoon)
+		if due && !s.syncing.TryLock() {
+			// A sync by hand is running and reports with Synced when it is done
+			due, started = false, true
+		}
+		switch {
+		case due:
+			err := s.syncOnce()
+			s.syncing.Unlock()
+			if err != nil {
+				backoff = min(max(2*backoff, minBackoff), maxBackoff)
+			} else {
+				backoff = 0
+			}
+			s.report(err == nil, err, retryDelay(backoff, interval))
+			started = true
+		case statusSoon:
+			backoff = 0
+			s.report(true, nil, 0)
+			started = true
+		case !started:
+			// The sync on start is still due
+			continue
+		}
+		// A changed interval starts counting again
+		delay = retryDelay(backoff, interval)
+	}
+}
+
+// retryDelay returns how long to wait for the next sync
+func retryDelay(backoff, interval time.Duration) time.Duration {
+	if backoff > 0 && backoff < interval {
+		return backoff
+	}
+	return interval
+}
+
+// syncOnce fetches, pulls if the remote has new commits and pushes local ones
+func (s *Syncer) syncOnce() error {
+	if storeops.RebaseInProgress(s.root) {
+		return ErrConflicts
+	}
+	if err := storeops.Fetch(s.root); err != nil {
+		return err
+	}
+	ahead, behind, err := storeops.AheadBehind(s.root)
+	if err != nil {
+		return err
+	}
+
+	if behind > 0 {
+		changed, err := storeops.HasLocalChanges(s.root)
+		if err != nil {
+			return err
+		}
+		if changed {
+			return ErrLocalChanges
+		}
+		if err := storeops.PullRebase(s.root); err != nil {
+			var conflict *storeops.ConflictError
+			if !errors.As(err, &conflict) {
+				return err
+			}
+			// Merging needs the user, so the store goes back to where it was
+			if err := storeops.AbortRebase(s.root); err != nil {
+				return err
+			}
+			return ErrConflicts
+		}
+	}
+
+	if ahead > 0 {
+		return storeops.Push(s.root)
+	}
+	return nil
+}
+
+// report updates the status after a sync and passes it to onStatus
+func (s *Syncer) report(synced bool, err error, retry time.Duration) {
+	s.mu.Lock()
+	status := s.status
+	s.mu.Unlock()
+
+	if ahead, behind, countErr := storeops.AheadBehind(s.root); countErr == nil {
+		status.Ahead, status.Behind = ahead, behind
+	} else if err == nil {
+		err = countErr
+		synced = false
+	}
+	status.Err = err
+	status.Retry = time.Time{}
+	if synced {
+		status.LastSync = time.Now()
+	}
+	if err != nil {
+		status.Retry = time.Now().Add(retry)
+	}
+
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+	if s.onStatus != nil {
+		s.onStatus(status)
+	}
+}
+
+// Status returns the status after the last sync
+func (s *Syncer) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
@@ -0,0 +1,148 @@
+package gitsync
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main.go/internal/gittest"
+	"main.go/storeops"
+)
+
+func TestSyncOnce(t *testing.T) {
+	_, first, second := gittest.Clones(t, "github.gpg")
+	gittest.CommitFile(t, first, "github.gpg", "remote edit")
+	require.NoError(t, storeops.Push(first))
+	gittest.CommitFile(t, second, "bank.gpg", "local entry")
+
+	s := &Syncer{root: second}
+	require.NoError(t, s.syncOnce())
+	content, err := os.ReadFile(filepath.Join(second, "github.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "remote edit", string(content))
+
+	ahead, behind, err := storeops.AheadBehind(second)
+	require.NoError(t, err)
+	assert.Zero(t, ahead)
+	assert.Zero(t, behind)
+}
+
+func TestSyncOnceOffline(t *testing.T) {
+	remote, _, second := gittest.Clones(t, "github.gpg")
+	gittest.CommitFile(t, second, "github.gpg", "local edit")
+
+	// The commit waits until the remote is reachable again
+	offline := remote + ".offline"
+	require.NoError(t, os.Rename(remote, offline))
+	s := &Syncer{root: second}
+	assert.Error(t, s.syncOnce())
+
+	require.NoError(t, os.Rename(offline, remote))
+	require.NoError(t, s.syncOnce())
+	assert.Contains(t, gittest.Git(t, remote, "log", "-1", "--format=%s"), "Edit password for github")
+}
+
+func TestSyncOnceLeavesConflicts(t *testing.T) {
+	_, first, second := gittest.Clones(t, "github.gpg")
+	gittest.CommitFile(t, first, "github.gpg", "remote edit")
+	require.NoError(t, storeops.Push(first))
+	gittest.CommitFile(t, second, "github.gpg", "local edit")
+
+	s := &Syncer{root: second}
+	assert.ErrorIs(t, s.syncOnce(), ErrConflicts)
+	assert.False(t, storeops.RebaseInProgress(second))
+	content, err := os.ReadFile(filepath.Join(second, "github.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "local edit", string(content))
+}
+
+func TestSyncOnceLocalChanges(t *testing.T) {
+	_, first, second := gittest.Clones(t, "github.gpg")
+	gittest.CommitFile(t, first, "github.gpg", "remote edit")
+	require.NoError(t, storeops.Push(first))
+	require.NoError(t, os.WriteFile(filepath.Join(second, "github.gpg"), []byte("not committed"), 0644))
+
+	s := &Syncer{root: second}
+	assert.ErrorIs(t, s.syncOnce(), ErrLocalChanges)
+}
+
+func TestSyncerSyncsOnStartAndPush(t *testing.T) {
+	remote, _, second := gittest.Clones(t, "github.gpg")
+
+	statuses := make(chan Status, 10)
+	s := NewSyncer(second, time.Hour, func(status Status) { statuses <- status })
+	defer s.Stop()
+
+	status := <-statuses
+	assert.NoError(t, status.Err)
+	assert.False(t, status.LastSync.IsZero())
+
+	gittest.CommitFile(t, second, "github.gpg", "local edit")
+	s.Push()
+	status = <-statuses
+	assert.NoError(t, status.Err)
+	assert.Zero(t, status.Ahead)
+	assert.Contains(t, gittest.Git(t, remote, "log", "-1", "--format=%s"), "Edit password for github")
+}
+
+func TestSyncerOff(t *testing.T) {
+	_, _, second := gittest.Clones(t, "github.gpg")
+
+	statuses := make(chan Status, 10)
+	s := NewSyncer(second, 0, func(status Status) { statuses <- status })
+	gittest.CommitFile(t, second, "github.gpg", "local edit")
+	s.Push()
+
+	// Only a sync made by hand is reported
+	s.Synced()
+	status := <-statuses
+	assert.Equal(t, 1, status.Ahead)
+	s.Stop()
+	assert.Empty(t, statuses)
+}
+
+func TestSyncerHold(t *testing.T) {
+	remote, _, second := gittest.Clones(t, "github.gpg")
+
+	statuses := make(chan Status, 10)
+	s := NewSyncer(second, time.Hour, func(status Status) { statuses <- status })
+	defer s.Stop()
+	<-statuses
+
+	// The commit stays local while a sync by hand holds background syncs off
+	release := s.Hold()
+	gittest.CommitFile(t, second, "github.gpg", "local edit")
+	s.Push()
+	s.Synced()
+	status := <-statuses
+	assert.Equal(t, 1, status.Ahead)
+	assert.NotContains(t, gittest.Git(t, remote, "log", "-1", "--format=%s"), "Edit password for github")
+
+	release()
+	release()
+	s.Push()
+	status = <-statuses
+	assert.NoError(t, status.Err)
+	assert.Zero(t, status.Ahead)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Hour, retryDelay(0, time.Hour))
+	assert.Equal(t, minBackoff, retryDelay(minBackoff, time.Hour))
+	assert.Equal(t, 5*time.Minute, retryDelay(maxBackoff, 5*time.Minute))
+}
+
+func TestStatusString(t *testing.T) {
+	assert.Equal(t, "↑0 ↓0 · not synced yet", Status{}.String())
+
+	synced := time.Date(2024, 5, 1, 14, 5, 0, 0, time.Local)
+	assert.Equal(t, "↑2 ↓1 · synced 14:05", Status{Ahead: 2, Behind: 1, LastSync: synced}.String())
+	assert.Equal(t, "↑2 ↓0 · synced 14:05 · sync failed, retrying at 14:10", Status{
+		Ahead: 2, LastSync: synced, Err: errors.New("offline"), Retry: synced.Add(5 * time.Minute),
+	}.String())
+}
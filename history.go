@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"main.go/entry"
+	"main.go/storeops"
+)
+
+// shortHash abbreviates a commit hash like git log --oneline
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// showHistoryDialog lists the commits that changed the entry or folder with node ID
+// id. For entries a chosen revision is decrypted and compared with the current
+// decrypted content. onRestore is called when the user restores a revision.
+func showHistoryDialog(window fyne.Window, storeRoot, id, current string, onRestore func(revision storeops.Revision)) {
+	name := strings.TrimSuffix(id, "/")
+
+	// git log may take a while in large stores
+	go func() {
+		revisions, err := storeops.Log(storeRoot, id)
+		fyne.Do(func() {
+			switch {
+			case err != nil:
+				dialog.ShowError(fmt.Errorf("Failed to read the history of %s: %v", name, err), window)
+			case len(revisions) == 0:
+				dialog.ShowInformation("History", fmt.Sprintf("%s has not been committed yet.", name), window)
+			default:
+				showRevisions(window, storeRoot, id, current, revisions, onRestore)
+			}
+		})
+	}()
+}
+
+// showRevisions shows the history dialog for the loaded revisions
+func showRevisions(window fyne.Window, storeRoot, id, current string, revisions []storeops.Revision, onRestore func(revision storeops.Revision)) {
+	isDir := strings.HasSuffix(id, "/")
+	name := strings.TrimSuffix(id, "/")
+
+	var historyDialog *dialog.CustomDialog
+	diffArea := container.NewStack(container.NewCenter(widget.NewLabel("Select a revision to compare it with the current version")))
+	if isDir {
+		diffArea.Objects = []fyne.CanvasObject{container.NewCenter(widget.NewLabel("Select a revision to restore the folder to"))}
+	}
+	setDiff := func(object fyne.CanvasObject) {
+		diffArea.Objects = []fyne.CanvasObject{object}
+		diffArea.Refresh()
+	}
+
+	// restoreButton restores the revision after asking
+	restoreButton := func(revision storeops.Revision) *widget.Button {
+		return widget.NewButtonWithIcon("Restore This Version", theme.HistoryIcon(), func() {
+			message := fmt.Sprintf("Restore %s to its state at %s?\n\n%s", name, shortHash(revision.Hash), revision.Subject)
+			if isDir {
+				message += "\n\nEntries added to the folder since then are removed."
+			}
+			dialog.ShowConfirm("Restore", message, func(confirmed bool) {
+				if confirmed {
+					historyDialog.Hide()
+					onRestore(revision)
+				}
+			}, window)
+		})
+	}
+
+	revisionList := widget.NewList(
+		func() int { return len(revisions) },
+		func() fyne.CanvasObject {
+			subject := widget.NewLabel("")
+			subject.TextStyle = fyne.TextStyle{Bold: true}
+			subject.Truncation = fyne.TextTruncateEllipsis
+			details := widget.NewLabel("")
+			details.Truncation = fyne.TextTruncateEllipsis
+			return container.NewVBox(subject, details)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			revision := revisions[id]
+			labels := o.(*fyne.Container).Objects
+			labels[0].(*widget.Label).SetText(revision.Subject)
+			labels[1].(*widget.Label).SetText(fmt.Sprintf("%s, %s, %s", shortHash(revision.Hash), revision.Author, revision.Date.Format("2006-01-02 15:04")))
+		},
+	)
+
+	selected := -1
+	revisionList.OnSelected = func(index widget.ListItemID) {
+		noteActivity()
+		selected = index
+		revision := revisions[index]
+		title := widget.NewLabel(fmt.Sprintf("Changes from %s to the current version", shortHash(revision.Hash)))
+		title.TextStyle = fyne.TextStyle{Bold: true}
+		switch {
+		case revision.Deleted:
+			setDiff(container.NewCenter(widget.NewLabel(fmt.Sprintf("%s removed %s", shortHash(revision.Hash), revision.Path))))
+			return
+		case isDir:
+			// Folders have no single content to compare
+			title.SetText(fmt.Sprintf("%s by %s", shortHash(revision.Hash), revision.Author))
+			setDiff(container.NewBorder(title, container.NewHBox(restoreButton(revision)), nil, nil, widget.NewLabel(revision.Subject)))
+			return
+		}
+		setDiff(container.NewCenter(widget.NewLabel("Decrypting…")))
+
+		go func() {
+			ciphertext, err := storeops.Show(storeRoot, revision.Hash, revision.Path)
+			if err != nil {
+				fyne.Do(func() {
+					dialog.ShowError(fmt.Errorf("Failed to read %s at %s: %v", revision.Path, shortHash(revision.Hash), err), window)
+				})
+				return
+			}
+			decryptCiphertext(filepath.Join(storeRoot, filepath.FromSlash(revision.Path)), ciphertext, window, func(old string) {
+				if selected != index {
+					// Another revision was chosen while this one was decrypted
+					return
+				}
+				var diff fyne.CanvasObject = widget.NewLabel("No changes")
+				if old != current {
+					diff = container.NewVScroll(entry.NewDiffView(entry.Diff(old, current)))
+				}
+				setDiff(container.NewBorder(title, container.NewHBox(restoreButton(revision)), nil, nil, diff))
+			})
+		}()
+	}
+
+	split := container.NewHSplit(revisionList, diffArea)
+	split.SetOffset(0.4)
+	historyDialog = dialog.NewCustom(fmt.Sprintf("History of %s", name), "Close", split, window)
+	historyDialog.Resize(fyne.NewSize(900, 550))
+	historyDialog.Show()
+}
@@ -0,0 +1,20 @@
+// Package fsutil holds file system helpers shared by the packages that change the store
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PruneEmptyDirs removes dir and its parents up to root for as long as they are
+// empty, like rmdir -p in pass. Root itself is kept, and a folder keeping its
+// .gpg-id is not empty.
+func PruneEmptyDirs(root, dir string) {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
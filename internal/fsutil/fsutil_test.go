@@ -0,0 +1,26 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneEmptyDirs(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "work", "team", "empty"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", ".gpg-id"), []byte("work@example.com\n"), 0644))
+
+	PruneEmptyDirs(root, filepath.Join(root, "work", "team", "empty"))
+	assert.NoDirExists(t, filepath.Join(root, "work", "team"))
+	assert.FileExists(t, filepath.Join(root, "work", ".gpg-id"))
+
+	// The root stays even when it is empty, and paths outside it are left alone
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "work")))
+	PruneEmptyDirs(root, root)
+	PruneEmptyDirs(filepath.Join(root, "store"), root)
+	assert.DirExists(t, root)
+}
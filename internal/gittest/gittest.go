@@ -0,0 +1,82 @@
+// Package gittest creates git repositories for the tests of packages that run git
+package gittest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Setup skips the test when git is not installed. Otherwise it configures an
+// author for commits and keeps the user's global git configuration out.
+func Setup(t testing.TB) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+}
+
+// Git runs git in dir and returns its standard output. It fails the test on errors.
+func Git(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	require.NoError(t, err, stderr.String())
+	return string(output)
+}
+
+// Init makes dir a repository and commits the files in it
+func Init(t testing.TB, dir string) {
+	t.Helper()
+	Setup(t)
+	Git(t, dir, "init", "-q")
+	Git(t, dir, "add", "-A")
+	Git(t, dir, "commit", "-q", "-m", "Initial commit")
+}
+
+// Clones creates a repository holding the given store-relative files, a bare
+// remote of it and two clones of that, standing for the stores of two teammates
+func Clones(t testing.TB, files ...string) (remote, first, second string) {
+	t.Helper()
+	origin := t.TempDir()
+	for _, p := range files {
+		writeFile(t, origin, p, "test content")
+	}
+	Init(t, origin)
+
+	remote = filepath.Join(t.TempDir(), "remote.git")
+	first, second = filepath.Join(t.TempDir(), "first"), filepath.Join(t.TempDir(), "second")
+	Git(t, origin, "clone", "-q", "--bare", origin, remote)
+	Git(t, origin, "clone", "-q", remote, first)
+	Git(t, origin, "clone", "-q", remote, second)
+	return remote, first, second
+}
+
+// CommitFile writes a store-relative file and commits only it, with the message
+// the viewer uses for edits
+func CommitFile(t testing.TB, root, p, content string) {
+	t.Helper()
+	writeFile(t, root, p, content)
+	Git(t, root, "add", "--", p)
+	Git(t, root, "commit", "-q", "-m", "Edit password for "+p+" using gpg_viewer.", "--", p)
+}
+
+// writeFile writes a store-relative file, creating its folders
+func writeFile(t testing.TB, root, p, content string) {
+	t.Helper()
+	full := filepath.Join(root, filepath.FromSlash(p))
+	require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+	require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+}
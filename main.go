@@ -3,11 +3,12 @@ package main
 import (
 	"errors"
 	"fmt"
-	"io/ioutil" // Note: In Go 1.16+, this is deprecated; use io and os packages instead
+	"math"
 	"os"
-	"os/exec"
 	"os/user"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,287 +16,442 @@ import (
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"main.go/assets"
+	"main.go/autolock"
+	"main.go/clipboard"
+	"main.go/crypto"
+	"main.go/entry"
+	"main.go/gitrepo"
+	"main.go/gitsync"
+	"main.go/passgen"
+	"main.go/recipients"
 	scanpassstore "main.go/scanpassstore" // Adjust the import path according to your project structure
 	"main.go/settings"
+	"main.go/storeops"
 )
 
 // Structure to hold password store data
 
+// Tree node IDs of the two top-level groups. Store node IDs are relative paths,
+// which never start with "/", so these cannot collide with entries or directories.
+const (
+	rootFilesNodeID   = "/root-files"
+	directoriesNodeID = "/directories"
+)
+
 // Structure to hold application state
 type AppState struct {
-	SelectedDirectory string
+	SelectedDirectory string // Tree node ID of the selection
 	SearchActive      bool
 	SearchResults     []string // relative paths without .gpg, e.g., "Finance/bank"
+	SelectedEntry     string   // Full path of the entry last opened, used by copy shortcuts
 }
 
-// defaultRecipient is populated from settings and used to prefill recipient dialogs
-var defaultRecipient string
+// listItem is a row of the file list that also opens a context menu
+type listItem struct {
+	widget.Label
 
-// decryptAndEditFile handles the decryption and editing of a GPG file
-func decryptAndEditFile(filePath string, window fyne.Window) {
-	// Define the decryption function inline to avoid scope issues
-	var decryptAndEdit func(string, string)
-	decryptAndEdit = func(filePath string, passphrase string) {
-		// Create a command to decrypt the GPG file
-		var cmd *exec.Cmd
-		if passphrase == "" {
-			// Try to decrypt without passphrase (using GPG agent)
-			cmd = exec.Command("gpg", "--batch", "--decrypt", filePath)
-		} else {
-			// Use provided passphrase
-			cmd = exec.Command("gpg", "--batch", "--passphrase", passphrase, "--decrypt", filePath)
-		}
+	id           widget.ListItemID
+	onTapped     func(id widget.ListItemID)
+	onTappedMenu func(id widget.ListItemID, pos fyne.Position)
+}
 
-		// Run the command and get the output
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			// If this was a first attempt without passphrase, prompt for passphrase
-			if passphrase == "" {
-				// Show passphrase dialog
-				fyne.Do(func() {
-					passphraseEntry := widget.NewPasswordEntry()
-					fileName := filepath.Base(filePath)
-
-					passphraseDialog := dialog.NewCustomConfirm(
-						"Enter Passphrase",
-						"Decrypt",
-						"Cancel",
-						container.NewVBox(
-							widget.NewLabel(fmt.Sprintf("File: %s", strings.TrimSuffix(fileName, ".gpg"))),
-							widget.NewLabel("GPG agent requires passphrase. Please enter:"),
-							passphraseEntry,
-						),
-						func(decrypt bool) {
-							if decrypt {
-								newPassphrase := passphraseEntry.Text
-								if newPassphrase == "" {
-									dialog.ShowError(errors.New("Passphrase cannot be empty"), window)
-									return
-								}
-
-								// Try again with the provided passphrase
-								go func() {
-									decryptAndEdit(filePath, newPassphrase)
-								}()
-							}
-						},
-						window,
-					)
-					passphraseDialog.Show()
-				})
-				return
-			} else {
-				// This was already a passphrase attempt, show error
-				fyne.Do(func() {
-					dialog.ShowError(fmt.Errorf("Failed to decrypt file: %v\n%s", err, output), window)
-				})
-				return
-			}
-		}
+// newListItem creates a file list row
+func newListItem(onTapped func(widget.ListItemID), onTappedMenu func(widget.ListItemID, fyne.Position)) *listItem {
+	item := &listItem{onTapped: onTapped, onTappedMenu: onTappedMenu}
+	item.ExtendBaseWidget(item)
+	return item
+}
 
-		// Filter out GPG header information
-		lines := strings.Split(string(output), "\n")
-		var contentLines []string
-		for _, line := range lines {
-			// Skip lines that contain GPG header information
-			if strings.HasPrefix(line, "gpg:") ||
-				strings.Contains(line, "encrypted with") ||
-				strings.Contains(line, "created") ||
-				strings.Contains(line, "<c4point@gmail.com>") {
-				continue
-			}
-			contentLines = append(contentLines, line)
+// Tapped selects the row, since the row receives taps instead of the list
+func (item *listItem) Tapped(*fyne.PointEvent) {
+	item.onTapped(item.id)
+}
+
+// TappedSecondary shows the context menu of the row
+func (item *listItem) TappedSecondary(ev *fyne.PointEvent) {
+	item.onTappedMenu(item.id, ev.AbsolutePosition)
+}
+
+// treeItem is a node of the store tree that also opens a context menu and can be
+// dragged onto another node
+type treeItem struct {
+	widget.Label
+
+	id           widget.TreeNodeID
+	onTapped     func(id widget.TreeNodeID)
+	onTappedMenu func(id widget.TreeNodeID, pos fyne.Position)
+	onDropped    func(id widget.TreeNodeID, pos fyne.Position)
+
+	dragPos fyne.Position // Absolute position of the pointer while dragging
+}
+
+// newTreeItem creates a store tree node
+func newTreeItem(onTapped func(widget.TreeNodeID), onTappedMenu, onDropped func(widget.TreeNodeID, fyne.Position)) *treeItem {
+	item := &treeItem{onTapped: onTapped, onTappedMenu: onTappedMenu, onDropped: onDropped}
+	item.ExtendBaseWidget(item)
+	return item
+}
+
+// Tapped selects the node, since the node receives taps instead of the tree
+func (item *treeItem) Tapped(*fyne.PointEvent) {
+	item.onTapped(item.id)
+}
+
+// TappedSecondary shows the context menu of the node
+func (item *treeItem) TappedSecondary(ev *fyne.PointEvent) {
+	item.onTappedMenu(item.id, ev.AbsolutePosition)
+}
+
+// Dragged follows the pointer while the node is dragged
+func (item *treeItem) Dragged(ev *fyne.DragEvent) {
+	item.dragPos = ev.AbsolutePosition
+}
+
+// DragEnd drops the node where the pointer was released
+func (item *treeItem) DragEnd() {
+	item.onDropped(item.id, item.dragPos)
+}
+
+// containsPoint reports whether pos lies within the rectangle at topLeft with the given size
+func containsPoint(pos, topLeft fyne.Position, size fyne.Size) bool {
+	return pos.X >= topLeft.X && pos.Y >= topLeft.Y && pos.X < topLeft.X+size.Width && pos.Y < topLeft.Y+size.Height
+}
+
+// withBadge appends the git status badge of a node to its label
+func withBadge(text, badge string) string {
+	if badge == "" {
+		return text
+	}
+	return text + "  [" + badge + "]"
+}
+
+// entryCountText describes a number of entries, such as "1 entry" or "3 entries"
+func entryCountText(count int) string {
+	if count == 1 {
+		return "1 entry"
+	}
+	return fmt.Sprintf("%d entries", count)
+}
+
+// defaultRecipients is populated from settings and used to prefill recipient dialogs
+var defaultRecipients []string
+
+// clipboardManager restores the clipboard after secrets were copied, set up in main
+var clipboardManager *clipboard.Manager
+
+// clipboardTimeout is how long copied secrets stay on the clipboard, from settings
+var clipboardTimeout = settings.DefaultClipboardTimeout * time.Second
+
+// autoCommit mirrors the AutoCommit setting: changes made in the application are
+// committed right away when the store is a git repository
+var autoCommit = true
+
+// idleLocker closes decrypted entries after the configured idle time, set up in main
+var idleLocker *autolock.Locker
+
+// gitSyncer pulls and pushes git stores in the background, set up in main
+var gitSyncer *gitsync.Syncer
+
+// refreshGitBadges reloads the git status shown in the tree, set up in main.
+// It must be called on the UI thread.
+var refreshGitBadges = func() {}
+
+// cryptoBackend performs all encryption and decryption, selected from settings
+var cryptoBackend crypto.Backend = crypto.NewGPGBackend()
+
+// decryptFile decrypts a GPG file, asking for a passphrase when needed, and
+// passes the content to onDecrypted on the UI thread
+func decryptFile(filePath string, window fyne.Window, onDecrypted func(content string)) {
+	ciphertext, err := os.ReadFile(filePath)
+	if err != nil {
+		fyne.Do(func() {
+			dialog.ShowError(fmt.Errorf("Failed to read file: %v", err), window)
+		})
+		return
+	}
+	decryptCiphertext(filePath, ciphertext, window, onDecrypted)
+}
+
+// decryptCiphertext decrypts an encrypted entry, such as an old revision, asking
+// for a passphrase when needed. The file path names the entry in the passphrase dialog.
+func decryptCiphertext(filePath string, ciphertext []byte, window fyne.Window, onDecrypted func(content string)) {
+	tryDecryptCiphertext(filePath, ciphertext, window, onDecrypted, func(err error) {
+		if !errors.Is(err, errDecryptCanceled) {
+			dialog.ShowError(fmt.Errorf("Failed to decrypt file: %v", err), window)
 		}
+	})
+}
 
-		// Join the filtered lines back together
-		filteredContent := strings.Join(contentLines, "\n")
+// errDecryptCanceled is passed to onFailed when the user cancels the passphrase dialog
+var errDecryptCanceled = errors.New("decryption was canceled")
 
-		// Create an entry widget with the filtered decrypted content
-		contentEntry := widget.NewMultiLineEntry()
-		contentEntry.SetText(filteredContent)
+// tryDecryptCiphertext decrypts like decryptCiphertext but leaves failures to
+// onFailed, which is called on the UI thread with the error or errDecryptCanceled
+func tryDecryptCiphertext(filePath string, ciphertext []byte, window fyne.Window, onDecrypted func(content string), onFailed func(err error)) {
+	cancel := func() {
+		onFailed(errDecryptCanceled)
+	}
+
+	// Define the decryption function inline to avoid scope issues
+	var decrypt func(string, []byte)
+	decrypt = func(filePath string, passphrase []byte) {
+		// Decrypt with the configured backend (nil passphrase uses the GPG agent).
+		// The passphrase only lives in memory for this call.
+		output, err := cryptoBackend.Decrypt(ciphertext, passphrase)
+		crypto.Wipe(passphrase)
+
+		var noSecretKey *crypto.NoSecretKeyError
+		switch {
+		case err == nil:
+			fyne.Do(func() {
+				onDecrypted(string(output))
+			})
+		case errors.As(err, &noSecretKey):
+			// Asking for a passphrase cannot help without the key
+			fyne.Do(func() {
+				onFailed(err)
+			})
+		case passphrase == nil:
+			// First attempt without passphrase, prompt for passphrase
+			fyne.Do(func() {
+				showPassphraseDialog(filePath, "GPG agent requires passphrase. Please enter:", window, decrypt, cancel)
+			})
+		case errors.Is(err, crypto.ErrBadPassphrase):
+			fyne.Do(func() {
+				showPassphraseDialog(filePath, "Wrong passphrase. Please try again:", window, decrypt, cancel)
+			})
+		default:
+			// This was already a passphrase attempt, show error
+			fyne.Do(func() {
+				onFailed(err)
+			})
+		}
+	}
 
-		// Create buttons first
-		var editDialog *dialog.CustomDialog
+	// Start the decryption process
+	decrypt(filePath, nil)
+}
 
-		saveBtn := widget.NewButtonWithIcon("Save Changes", theme.DocumentSaveIcon(), func() {
-			// Get the edited content
-			editedContent := contentEntry.Text
+// copyPassword decrypts an entry and copies its password
+func copyPassword(filePath string, window fyne.Window) {
+	decryptFile(filePath, window, func(content string) {
+		password := entry.Parse(content).Password()
+		if password == "" {
+			dialog.ShowError(errors.New("This entry has no password"), window)
+			return
+		}
+		copyToClipboard("Password", password)
+	})
+}
 
-			// Create a temporary file for the edited content
-			tmpFile, err := ioutil.TempFile("", "gpg_edit_*")
-			if err != nil {
-				dialog.ShowError(fmt.Errorf("Failed to create temporary file: %v", err), window)
-				return
-			}
-			tmpFileName := tmpFile.Name()
+// copyUsername decrypts an entry and copies its username field
+func copyUsername(filePath string, window fyne.Window) {
+	decryptFile(filePath, window, func(content string) {
+		username, ok := entry.Parse(content).Username()
+		if !ok {
+			dialog.ShowError(errors.New("This entry has no username field"), window)
+			return
+		}
+		copyToClipboard("Username", username)
+	})
+}
 
-			// Write the edited content to the temporary file
-			if _, err := tmpFile.WriteString(editedContent); err != nil {
-				dialog.ShowError(fmt.Errorf("Failed to write to temporary file: %v", err), window)
-				os.Remove(tmpFileName)
-				return
-			}
-			tmpFile.Close()
+// showCopyFieldDialog decrypts an entry and lets the user pick a field to copy
+func showCopyFieldDialog(filePath string, window fyne.Window) {
+	decryptFile(filePath, window, func(content string) {
+		fields := entry.Parse(content).Fields()
+		if len(fields) == 0 {
+			dialog.ShowError(errors.New("This entry has no fields"), window)
+			return
+		}
 
-			// Get the recipient from the original file
-			recipientCmd := exec.Command("gpg", "--batch", "--list-packets", filePath)
-			recipientOutput, err := recipientCmd.CombinedOutput()
-			if err != nil {
-				dialog.ShowError(fmt.Errorf("Failed to get recipient info: %v\n%s", err, recipientOutput), window)
-				return
-			}
+		keys := make([]string, len(fields))
+		for i, field := range fields {
+			keys[i] = field.Key
+		}
+		fieldSelect := widget.NewSelect(keys, nil)
+		fieldSelect.SetSelectedIndex(0)
 
-			// Parse the output to find the recipient
-			recipientStr := string(recipientOutput)
-			var recipient string
-
-			// Look for keyid in the output
-			for _, line := range strings.Split(recipientStr, "\n") {
-				if strings.Contains(line, "keyid") {
-					parts := strings.Split(line, "keyid")
-					if len(parts) > 1 {
-						keyidPart := strings.TrimSpace(parts[1])
-						if len(keyidPart) > 16 { // Typical keyid length with some buffer
-							recipient = keyidPart[:16]
-							break
-						}
-					}
-				}
+		dialog.ShowCustomConfirm("Copy Field", "Copy", "Cancel", fieldSelect, func(confirmed bool) {
+			if confirmed {
+				field := fields[fieldSelect.SelectedIndex()]
+				copyToClipboard(field.Key, field.Value)
 			}
+		}, window)
+	})
+}
 
-			if recipient == "" {
-				// If we couldn't find the recipient, ask the user
-				recipientEntry := widget.NewEntry()
-				if defaultRecipient != "" {
-					recipientEntry.SetText(defaultRecipient)
-				} else {
-					recipientEntry.SetPlaceHolder("email or key ID")
-				}
-				recipientDialog := dialog.NewCustomConfirm(
-					"Enter Recipient",
-					"Encrypt",
-					"Cancel",
-					container.NewVBox(
-						widget.NewLabel("Could not detect recipient automatically."),
-						widget.NewLabel("Please enter GPG recipient (email or key ID):"),
-						recipientEntry,
-					),
-					func(confirm bool) {
-						if confirm {
-							recipient = recipientEntry.Text
-							if recipient == "" {
-								dialog.ShowError(errors.New("Recipient cannot be empty"), window)
-								return
-							}
-
-							// Now encrypt with the provided recipient
-							cmd := exec.Command("gpg", "--batch", "--yes", "--recipient", recipient,
-								"--output", filePath, "--encrypt", tmpFileName)
-
-							output, err := cmd.CombinedOutput()
-							// Clean up the temporary file
-							os.Remove(tmpFileName)
-
-							if err != nil {
-								dialog.ShowError(fmt.Errorf("Failed to encrypt file: %v\n%s", err, output), window)
-								return
-							}
-
-							dialog.ShowInformation("Success", "File saved successfully", window)
-							if editDialog != nil {
-								editDialog.Hide()
-							}
-						}
-					},
-					window,
-				)
-				recipientDialog.Show()
-			} else {
-				// Create a command to encrypt the edited content with the detected recipient
-				cmd := exec.Command("gpg", "--batch", "--yes", "--recipient", recipient,
-					"--output", filePath, "--encrypt", tmpFileName)
-
-				output, err := cmd.CombinedOutput()
-				// Clean up the temporary file
-				os.Remove(tmpFileName)
+// showPassphraseDialog asks for a passphrase and retries decryption with it.
+// onCancel, if set, is called when the user cancels.
+func showPassphraseDialog(filePath, message string, window fyne.Window, retry func(string, []byte), onCancel func()) {
+	passphraseEntry := widget.NewPasswordEntry()
+	fileName := filepath.Base(filePath)
 
-				if err != nil {
-					dialog.ShowError(fmt.Errorf("Failed to encrypt file: %v\n%s", err, output), window)
+	var passphraseDialog dialog.Dialog
+	passphraseDialog = dialog.NewCustomConfirm(
+		"Enter Passphrase",
+		"Decrypt",
+		"Cancel",
+		container.NewVBox(
+			widget.NewLabel(fmt.Sprintf("File: %s", strings.TrimSuffix(fileName, ".gpg"))),
+			widget.NewLabel(message),
+			passphraseEntry,
+		),
+		func(decrypt bool) {
+			if decrypt {
+				newPassphrase := []byte(passphraseEntry.Text)
+				passphraseEntry.SetText("")
+				if len(newPassphrase) == 0 {
+					dialog.ShowError(errors.New("Passphrase cannot be empty"), window)
+					passphraseDialog.Show()
 					return
 				}
 
-				dialog.ShowInformation("Success", "File saved successfully", window)
-				if editDialog != nil {
-					editDialog.Hide()
-				}
+				// Try again with the provided passphrase
+				go func() {
+					retry(filePath, newPassphrase)
+				}()
+			} else if onCancel != nil {
+				onCancel()
 			}
-		})
+		},
+		window,
+	)
+	passphraseDialog.Show()
+}
 
-		closeBtn := widget.NewButtonWithIcon("Close", theme.CancelIcon(), func() {
-			if editDialog != nil {
-				editDialog.Hide()
-			}
-		})
+// saveEntryContent encrypts content to the recipients of the entry without asking,
+// falling back to the default recipients when the store has no .gpg-id
+func saveEntryContent(storeRoot, filePath, content string) error {
+	gpgIDs, err := recipients.ForEntry(storeRoot, filePath)
+	if err != nil && !errors.Is(err, recipients.ErrNoGpgID) {
+		return err
+	}
+	if len(gpgIDs) == 0 {
+		gpgIDs = defaultRecipients
+	}
+	if len(gpgIDs) == 0 {
+		return recipients.ErrNoGpgID
+	}
 
-		// Create the dialog with content and buttons
-		buttonContainer := container.NewHBox(saveBtn, closeBtn)
-		contentContainer := container.NewBorder(nil, buttonContainer, nil, nil, contentEntry)
-		editDialog = dialog.NewCustomWithoutButtons("Edit Password File", contentContainer, window)
-		editDialog.Resize(fyne.NewSize(600, 400))
-		editDialog.Show()
+	plaintext := []byte(content)
+	ciphertext, err := cryptoBackend.Encrypt(plaintext, gpgIDs)
+	crypto.Wipe(plaintext)
+	if err != nil {
+		return err
 	}
+	return storeops.WriteFile(filePath, ciphertext)
+}
 
-	// Start the decryption process
-	decryptAndEdit(filePath, "")
+// commitStoreChange commits the touched store-relative paths in the background with
+// a pass-style message when auto-commit is on, showing failures in the window.
+// The commit is pushed by the background sync.
+func commitStoreChange(storeRoot string, window fyne.Window, message string, paths ...string) {
+	if !autoCommit {
+		return
+	}
+	go func() {
+		if err := storeops.Commit(storeRoot, message, paths...); err != nil {
+			fyne.Do(func() {
+				dialog.ShowError(fmt.Errorf("Failed to commit %q: %v", message, err), window)
+			})
+			return
+		}
+		fyne.Do(refreshGitBadges)
+		if gitSyncer != nil {
+			gitSyncer.Push()
+		}
+	}()
+}
+
+// noteActivity restarts the auto-lock idle timer
+func noteActivity() {
+	if idleLocker != nil {
+		idleLocker.Activity()
+	}
+}
+
+// copyToClipboard puts a secret on the system clipboard until the clipboard timeout
+// expires. The name, such as "Password", is shown in the countdown.
+func copyToClipboard(name, text string) {
+	noteActivity()
+	clipboardManager.Copy(name, text, clipboardTimeout)
+}
+
+// keySuggestions lists the user IDs of known public keys for recipient pickers
+func keySuggestions() []string {
+	keys, err := cryptoBackend.ListKeys()
+	if err != nil {
+		return nil
+	}
+	var suggestions []string
+	for _, key := range keys {
+		if len(key.UserIDs) > 0 {
+			suggestions = append(suggestions, key.UserIDs...)
+		} else {
+			suggestions = append(suggestions, key.KeyID)
+		}
+	}
+	return suggestions
 }
 
 // showNewRecordDialog displays a dialog for creating a new password record
-func showNewRecordDialog(window fyne.Window, targetPath string, defaultRecipient string, refreshCallback func()) {
+func showNewRecordDialog(window fyne.Window, targetPath string, defaultRecipients []string, refreshCallback func()) {
 	// Create form entries
 	nameEntry := widget.NewEntry()
 	nameEntry.SetPlaceHolder("Enter record name (e.g., gmail.com, bank/chase)")
-	
+
 	usernameEntry := widget.NewEntry()
 	usernameEntry.SetPlaceHolder("Enter username/email")
-	
+
 	passwordEntry := widget.NewPasswordEntry()
 	passwordEntry.SetPlaceHolder("Enter password")
-	
+	generateBtn := widget.NewButtonWithIcon("Generate", theme.ViewRefreshIcon(), func() {
+		showGenerateDialog(window, strings.TrimSpace(nameEntry.Text), passwordEntry.SetText)
+	})
+
 	notesEntry := widget.NewMultiLineEntry()
 	notesEntry.SetPlaceHolder("Additional notes (optional)")
 	notesEntry.Resize(fyne.NewSize(400, 100))
-	
-	recipientEntry := widget.NewEntry()
-	if defaultRecipient != "" {
-		recipientEntry.SetText(defaultRecipient)
-	} else {
-		recipientEntry.SetPlaceHolder("GPG recipient (email or key ID)")
+
+	// Prefill recipients from .gpg-id until the user picks their own
+	recipientPicker := recipients.NewPicker(recipientsForRecord(targetPath, "", defaultRecipients))
+	recipientPicker.SetSuggestions(keySuggestions())
+	recipientsEdited := false
+	recipientPicker.OnChanged = func([]string) {
+		recipientsEdited = true
+	}
+	nameEntry.OnChanged = func(name string) {
+		if !recipientsEdited {
+			recipientPicker.SetRecipients(recipientsForRecord(targetPath, strings.TrimSpace(name), defaultRecipients))
+		}
 	}
-	
+
 	// Create form content
 	formContent := container.NewVBox(
 		widget.NewLabel("Create New Password Record"),
 		widget.NewSeparator(),
-		
+
 		widget.NewLabel("Record Name:"),
 		nameEntry,
-		
+
 		widget.NewLabel("Username:"),
 		usernameEntry,
-		
+
 		widget.NewLabel("Password:"),
-		passwordEntry,
-		
+		container.NewBorder(nil, nil, nil, generateBtn, passwordEntry),
+
 		widget.NewLabel("Notes:"),
 		notesEntry,
-		
-		widget.NewLabel("GPG Recipient:"),
-		recipientEntry,
+
+		widget.NewLabel("GPG Recipients:"),
+		recipientPicker,
 	)
-	
+
 	// Create dialog
 	newRecordDialog := dialog.NewCustomConfirm(
 		"New Password Record",
@@ -306,55 +462,54 @@ func showNewRecordDialog(window fyne.Window, targetPath string, defaultRecipient
 			if !create {
 				return
 			}
-			
+
 			// Validate inputs
 			recordName := strings.TrimSpace(nameEntry.Text)
 			username := strings.TrimSpace(usernameEntry.Text)
 			password := strings.TrimSpace(passwordEntry.Text)
 			notes := strings.TrimSpace(notesEntry.Text)
-			recipient := strings.TrimSpace(recipientEntry.Text)
-			
+			selected := recipientPicker.Recipients()
+
 			if recordName == "" {
 				dialog.ShowError(errors.New("Record name cannot be empty"), window)
 				return
 			}
-			
+
 			if username == "" {
 				dialog.ShowError(errors.New("Username cannot be empty"), window)
 				return
 			}
-			
+
 			if password == "" {
 				dialog.ShowError(errors.New("Password cannot be empty"), window)
 				return
 			}
-			
-			if recipient == "" {
-				dialog.ShowError(errors.New("GPG recipient cannot be empty"), window)
+
+			if len(selected) == 0 {
+				dialog.ShowError(errors.New("At least one GPG recipient is required"), window)
 				return
 			}
-			
+
 			// Create password content
-			var content strings.Builder
-			content.WriteString(password)
-			content.WriteString("\n")
-			content.WriteString("Username: " + username)
+			content := entry.New(password)
+			content.Add("Username", username)
 			if notes != "" {
-				content.WriteString("\n")
-				content.WriteString("Notes: " + notes)
+				content.Add("Notes", notes)
 			}
-			
+
 			// Create the GPG file
 			go func() {
-				err := createNewPasswordFile(targetPath, recordName, content.String(), recipient)
+				err := createNewPasswordFile(targetPath, recordName, content.String(), selected)
 				if err != nil {
 					fyne.Do(func() {
 						dialog.ShowError(fmt.Errorf("Failed to create password file: %v", err), window)
 					})
 					return
 				}
-				
-				// Success - refresh the UI
+
+				// Success - commit like pass insert and refresh the UI
+				name := strings.TrimSuffix(storeops.DiskPath(recordName), ".gpg")
+				commitStoreChange(targetPath, window, fmt.Sprintf("Add given password for %s to store.", name), name+".gpg")
 				fyne.Do(func() {
 					dialog.ShowInformation("Success", fmt.Sprintf("Password record '%s' created successfully", recordName), window)
 					if refreshCallback != nil {
@@ -365,13 +520,93 @@ func showNewRecordDialog(window fyne.Window, targetPath string, defaultRecipient
 		},
 		window,
 	)
-	
+
 	newRecordDialog.Resize(fyne.NewSize(500, 600))
 	newRecordDialog.Show()
 }
 
+// showGenerateDialog generates a password for the store-relative entry path using the
+// policy configured for its folder and passes it to onUse
+func showGenerateDialog(window fyne.Window, entryPath string, onUse func(password string)) {
+	appSettings, err := settings.LoadSettings()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("Failed to load settings: %v", err), window)
+		return
+	}
+	policy, policyFolder := appSettings.PolicyFor(entryPath)
+	generator := passgen.NewGenerator(policy)
+
+	// Offer to remember the policy for the entry's folder or for everything
+	const (
+		rememberNothing = "This time only"
+		rememberAll     = "All entries"
+	)
+	options := []string{rememberNothing, rememberAll}
+	folder := path.Dir(strings.Trim(entryPath, "/"))
+	if folder != "." {
+		options = append(options, folder+"/")
+	}
+	if policyFolder != "" && policyFolder != folder {
+		options = append(options, policyFolder+"/")
+	}
+	rememberSelect := widget.NewSelect(options, nil)
+	rememberSelect.SetSelected(rememberNothing)
+
+	generateDialog := dialog.NewCustomConfirm(
+		"Generate Password",
+		"Use",
+		"Cancel",
+		container.NewVBox(
+			generator,
+			widget.NewSeparator(),
+			container.NewBorder(nil, nil, widget.NewLabel("Remember policy for:"), nil, rememberSelect),
+		),
+		func(use bool) {
+			if !use {
+				return
+			}
+			password := generator.Password()
+			if password == "" {
+				dialog.ShowError(fmt.Errorf("Invalid password policy: %v", generator.Policy().Validate()), window)
+				return
+			}
+
+			var err error
+			switch rememberSelect.Selected {
+			case rememberNothing:
+			case rememberAll:
+				err = settings.UpdateSettings(map[string]interface{}{
+					"password_policy": generator.Policy(),
+				})
+			default:
+				appSettings.FolderPolicies[strings.TrimSuffix(rememberSelect.Selected, "/")] = generator.Policy()
+				err = settings.UpdateSettings(map[string]interface{}{
+					"folder_policies": appSettings.FolderPolicies,
+				})
+			}
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("Failed to save password policy: %v", err), window)
+			}
+
+			onUse(password)
+		},
+		window,
+	)
+	generateDialog.Resize(fyne.NewSize(500, 450))
+	generateDialog.Show()
+}
+
+// recipientsForRecord returns the .gpg-id recipients for a new record, or the defaults when the store has none
+func recipientsForRecord(targetPath, recordName string, fallback []string) []string {
+	dir := filepath.Dir(filepath.Join(targetPath, recordName+".gpg"))
+	if gpgIDs, err := recipients.ForDir(targetPath, dir); err == nil {
+		return gpgIDs
+	}
+	return fallback
+}
+
 // createNewPasswordFile creates a new GPG-encrypted password file
-func createNewPasswordFile(targetPath, recordName, content, recipient string) error {
+func createNewPasswordFile(targetPath, recordName, content string, gpgIDs []string) error {
 	// Determine the file path
 	var filePath string
 	if strings.Contains(recordName, "/") {
@@ -384,36 +619,24 @@ func createNewPasswordFile(targetPath, recordName, content, recipient string) er
 	} else {
 		filePath = filepath.Join(targetPath, recordName+".gpg")
 	}
-	
+
 	// Check if file already exists
 	if _, err := os.Stat(filePath); err == nil {
 		return fmt.Errorf("password file '%s' already exists", recordName)
 	}
-	
-	// Create a temporary file for the content
-	tmpFile, err := ioutil.TempFile("", "gpg_new_*")
+
+	// Encrypt the content in memory with the configured backend
+	plaintext := []byte(content)
+	ciphertext, err := cryptoBackend.Encrypt(plaintext, gpgIDs)
+	crypto.Wipe(plaintext)
 	if err != nil {
-		return fmt.Errorf("failed to create temporary file: %v", err)
-	}
-	tmpFileName := tmpFile.Name()
-	defer os.Remove(tmpFileName)
-	
-	// Write content to temporary file
-	if _, err := tmpFile.WriteString(content); err != nil {
-		tmpFile.Close()
-		return fmt.Errorf("failed to write to temporary file: %v", err)
+		return fmt.Errorf("failed to encrypt file: %v", err)
 	}
-	tmpFile.Close()
-	
-	// Encrypt the file using GPG
-	cmd := exec.Command("gpg", "--batch", "--yes", "--recipient", recipient,
-		"--output", filePath, "--encrypt", tmpFileName)
-	
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to encrypt file: %v\n%s", err, output)
+
+	if err := storeops.WriteFile(filePath, ciphertext); err != nil {
+		return fmt.Errorf("failed to write password file: %v", err)
 	}
-	
+
 	return nil
 }
 
@@ -461,8 +684,8 @@ func main() {
 		return
 	}
 
-	fmt.Println("Valid directories with .gpg files:", len(store.Directories))
-	fmt.Println("Total root files:", len(store.RootFiles))
+	fmt.Println("Valid directories with .gpg files:", len(store.Root.Dirs()))
+	fmt.Println("Total root files:", len(store.Root.Entries()))
 	fmt.Println("CLI scan completed successfully.")
 
 	// Initialize GUI
@@ -473,8 +696,20 @@ func main() {
 
 	// Apply theme from settings
 	settings.ApplyTheme(myApp, appSettings.Theme)
-	// Prefill default recipient for encryption dialogs
-	defaultRecipient = appSettings.DefaultRecipient
+	// Prefill default recipients for encryption dialogs
+	defaultRecipients = appSettings.DefaultRecipients
+
+	// Select the crypto backend, keeping gpg if the configured one is unusable
+	if backend, err := crypto.NewDefault(appSettings.CryptoBackend, appSettings.KeyringPath); err != nil {
+		fmt.Println("Error initializing crypto backend, using gpg:", err)
+	} else {
+		cryptoBackend = backend
+	}
+
+	// Clear copied secrets from the clipboard after the configured timeout
+	clipboardManager = clipboard.NewManager(myApp.Clipboard(), fyne.Do)
+	clipboardTimeout = time.Duration(appSettings.ClipboardTimeout) * time.Second
+	autoCommit = appSettings.AutoCommit
 
 	myWindow := myApp.NewWindow("GPG Password Store Viewer")
 	myWindow.Resize(fyne.NewSize(float32(appSettings.WindowWidth), float32(appSettings.WindowHeight)))
@@ -483,10 +718,14 @@ func main() {
 	myWindow.Canvas().SetOnTypedKey(func(ke *fyne.KeyEvent) {
 		// This is a workaround to detect window resize
 		// In a real implementation, you might want to use a timer-based approach
+		noteActivity()
 	})
 
 	// Save window size when closing
 	myWindow.SetOnClosed(func() {
+		// Do not leave a copied secret behind
+		clipboardManager.Restore()
+
 		size := myWindow.Canvas().Size()
 		settings.UpdateSettings(map[string]interface{}{
 			"window_width":  int(size.Width),
@@ -499,214 +738,167 @@ func main() {
 		SelectedDirectory: "",
 	}
 
-	// Create tree for directories with nested support
-	tree := widget.NewTree(
+	// Selected entries are shown read-only in a pane next to the file list
+	pane := newDetailPane(targetPath, myWindow)
+	openEntry := func(filePath string) {
+		noteActivity()
+		appState.SelectedEntry = filePath
+		pane.Open(filePath)
+	}
+
+	// lookupTreeNode resolves a tree node ID to a store node, skipping the group nodes
+	lookupTreeNode := func(id widget.TreeNodeID) (*scanpassstore.Node, bool) {
+		if id == "" || id == rootFilesNodeID || id == directoriesNodeID {
+			return nil, false
+		}
+		return store.Lookup(id)
+	}
+
+	// nodeIDs converts store nodes to tree node IDs
+	nodeIDs := func(nodes []*scanpassstore.Node) []widget.TreeNodeID {
+		ids := make([]widget.TreeNodeID, 0, len(nodes))
+		for _, node := range nodes {
+			ids = append(ids, node.ID())
+		}
+		return ids
+	}
+
+	// Create tree for directories with nested support.
+	// Node IDs come from scanpassstore.Node.ID, so equal names in different folders stay distinct.
+	var tree *widget.Tree
+	var storeChanges *storeops.StoreChanges // Uncommitted changes shown as badges, nil without git
+	var treeItems []*treeItem
+	var showTreeMenu, dropTreeNode func(id widget.TreeNodeID, pos fyne.Position)
+	tree = widget.NewTree(
 		func(id widget.TreeNodeID) []widget.TreeNodeID {
-			if id == "" {
+			switch id {
+			case "":
 				// Root level items
-				if len(store.RootFiles) > 0 {
-					return []widget.TreeNodeID{"Root", "Directories"}
+				if len(store.Root.Entries()) > 0 {
+					return []widget.TreeNodeID{rootFilesNodeID, directoriesNodeID}
 				}
-				return []widget.TreeNodeID{"Directories"}
-			} else if id == "Root" {
+				return []widget.TreeNodeID{directoriesNodeID}
+			case rootFilesNodeID:
 				// Root files - show them as child nodes
-				return store.RootFiles
-			} else if id == "Directories" {
+				return nodeIDs(store.Root.Entries())
+			case directoriesNodeID:
 				// Directory names
-				return store.Directories
-			} else if files, ok := store.DirContents[id]; ok {
-				// Files in a directory - show them as child nodes
-				var children []widget.TreeNodeID
-				children = append(children, files...)
-
-				// Add subdirectories if they exist
-				if subdirs, ok := store.NestedDirs[id]; ok {
-					children = append(children, subdirs...)
-				}
-				return children
-			} else {
-				// Check if this is a root file (no parent directory)
-				for _, rootFile := range store.RootFiles {
-					if rootFile == id {
-						return []widget.TreeNodeID{} // Root files are leaf nodes
-					}
-				}
-
-				// Check if this is a subdirectory path (e.g., "Finance/subfolder1")
-				// We need to check if this ID exists as a full path in DirContents
-				if files, ok := store.DirContents[id]; ok {
-					var children []widget.TreeNodeID
-					children = append(children, files...)
-
-					// Add nested subdirectories if they exist
-					if subdirs, ok := store.NestedDirs[id]; ok {
-						children = append(children, subdirs...)
-					}
-					return children
-				}
-
-				// Check if this is a subdirectory name that should be expanded
-				// First, find which parent directory this subdirectory belongs to
-				var parentDir string
-				for parent, subdirs := range store.NestedDirs {
-					for _, subdir := range subdirs {
-						if subdir == id {
-							parentDir = parent
-							break
-						}
-					}
-					if parentDir != "" {
-						break
-					}
-				}
-
-				// If we found the parent, construct the full path and get the contents
-				if parentDir != "" {
-					fullPath := parentDir + "/" + id
-					if files, ok := store.DirContents[fullPath]; ok {
-						return files
-					}
-				}
-
-				// Fallback: Look for entries in DirContents that start with this ID + "/"
-				var children []widget.TreeNodeID
-				for fullPath := range store.DirContents {
-					if strings.HasPrefix(fullPath, id+"/") {
-						// Extract the subdirectory name
-						parts := strings.Split(fullPath, "/")
-						if len(parts) > 1 {
-							subdirName := parts[1] // Get the part after the first "/"
-							children = append(children, subdirName)
-						}
-					}
-				}
-				if len(children) > 0 {
-					return children
-				}
+				return nodeIDs(store.Root.Dirs())
+			}
+			if node, ok := lookupTreeNode(id); ok && node.IsDir() {
+				// Files in a directory followed by its subdirectories
+				return nodeIDs(node.Children)
 			}
+			// Files are leaf nodes
 			return []widget.TreeNodeID{}
 		},
 		func(id widget.TreeNodeID) bool {
-			if id == "" || id == "Directories" {
-				return true
-			} else if id == "Root" && len(store.RootFiles) > 0 {
-				return true
-			} else if _, ok := store.NestedDirs[id]; ok {
-				// Subdirectories are expandable if they have files or subdirectories
-				return true
-			} else if _, ok := store.DirContents[id]; ok {
-				// Directories are expandable if they have files or subdirectories
+			switch id {
+			case "", directoriesNodeID:
 				return true
-			} else {
-				// Check if this is a subdirectory by looking for entries that start with parent + "/"
-				for fullPath := range store.DirContents {
-					if strings.HasPrefix(fullPath, id+"/") {
-						return true
-					}
-				}
-
-				// Check if this is a subdirectory name that appears in any parent's NestedDirs
-				for _, subdirs := range store.NestedDirs {
-					for _, subdir := range subdirs {
-						if subdir == id {
-							return true
-						}
-					}
-				}
+			case rootFilesNodeID:
+				return len(store.Root.Entries()) > 0
 			}
-			// Files are not expandable
-			return false
+			// Directories are expandable, files are not
+			node, ok := lookupTreeNode(id)
+			return ok && node.IsDir()
 		},
 		func(branch bool) fyne.CanvasObject {
-			return widget.NewLabel("Template")
+			item := newTreeItem(
+				func(id widget.TreeNodeID) { tree.Select(id) },
+				func(id widget.TreeNodeID, pos fyne.Position) { showTreeMenu(id, pos) },
+				func(id widget.TreeNodeID, pos fyne.Position) { dropTreeNode(id, pos) },
+			)
+			treeItems = append(treeItems, item)
+			return item
 		},
 		func(id widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
-			label := o.(*widget.Label)
+			label := o.(*treeItem)
+			label.id = id
 			switch id {
 			case "":
 				label.SetText("Password Store")
-			case "Root":
+			case rootFilesNodeID:
 				label.SetText("Root Files")
-			case "Directories":
+			case directoriesNodeID:
 				label.SetText("Directories")
 			default:
-				// Check if this is a directory, subdirectory, or file
-				// First check if it's a subdirectory (in NestedDirs)
-				if _, ok := store.NestedDirs[id]; ok {
-					// It's a subdirectory
-					dirName := id
-					if strings.Contains(id, "/") {
-						parts := strings.Split(id, "/")
-						dirName = parts[len(parts)-1]
-					}
-					label.SetText("📂 " + dirName)
-				} else if _, ok := store.DirContents[id]; ok {
-					// It's a directory (has files)
-					// Extract just the directory name from the path
-					dirName := id
-					if strings.Contains(id, "/") {
-						parts := strings.Split(id, "/")
-						dirName = parts[len(parts)-1]
-					}
+				node, ok := lookupTreeNode(id)
+				badge := storeChanges.Badge(id)
+				switch {
+				case !ok:
+					label.SetText(scanpassstore.Name(id))
+				case !node.IsDir():
+					label.SetText(withBadge("📄 "+node.Name, badge))
+				case node.Parent == store.Root:
+					// Top-level directories and nested subdirectories use different icons
+					label.SetText(withBadge("📁 "+node.Name, badge))
+				default:
+					label.SetText(withBadge("📂 "+node.Name, badge))
+				}
+			}
+		},
+	)
 
-					// Check if it's a top-level directory or nested
-					if strings.Contains(id, "/") {
-						label.SetText("📂 " + dirName)
-					} else {
-						label.SetText("📁 " + dirName)
-					}
-				} else {
-					// Check if this is a subdirectory by looking for entries that start with parent + "/"
-					// This handles cases where we have "New" as a subdirectory of "Finance"
-					isSubdirectory := false
-					for fullPath := range store.DirContents {
-						if strings.HasPrefix(fullPath, id+"/") {
-							isSubdirectory = true
-							break
-						}
-					}
-
-					if isSubdirectory {
-						label.SetText("📂 " + id)
-					} else {
-						// Check if this is a subdirectory name that appears in any parent's NestedDirs
-						// This handles cases where "New" is a subdirectory of "Finance"
-						for _, subdirs := range store.NestedDirs {
-							for _, subdir := range subdirs {
-								if subdir == id {
-									label.SetText("📂 " + id)
-									return
-								}
-							}
-						}
-
-						// It's a file
-						label.SetText("📄 " + id)
-					}
-				}
+	// Badges come from git status, which runs in the background. Only the
+	// latest refresh is shown when several overlap.
+	badgeRefreshes := 0
+	refreshGitBadges = func() {
+		if !storeops.IsGitRepo(targetPath) {
+			return
+		}
+		badgeRefreshes++
+		refresh := badgeRefreshes
+		go func() {
+			changes, err := storeops.LoadChanges(targetPath)
+			if err != nil {
+				fmt.Println("Error reading git status:", err)
+				return
 			}
-		},
-	)
+			fyne.Do(func() {
+				if refresh == badgeRefreshes {
+					storeChanges = changes
+					tree.Refresh()
+				}
+			})
+		}()
+	}
+	refreshGitBadges()
 
 	// Content area for displaying selected items
 	contentLabel := widget.NewLabel("Select an item to view details")
 	contentLabel.Wrapping = fyne.TextWrapWord
 
 	// File list for selected directory or search results
-	fileList := widget.NewList(
+	var fileList *widget.List
+	var showEntryMenu func(id widget.ListItemID, pos fyne.Position)
+	var deleteNode, showMoveDialog func(node *scanpassstore.Node)
+	var restoreNode func(id string, revision storeops.Revision)
+	fileList = widget.NewList(
 		func() int { return 0 },
 		func() fyne.CanvasObject {
-			return widget.NewLabel("Template")
+			return newListItem(
+				func(id widget.ListItemID) { fileList.Select(id) },
+				func(id widget.ListItemID, pos fyne.Position) { showEntryMenu(id, pos) },
+			)
 		},
 		func(id widget.ListItemID, o fyne.CanvasObject) {
 			// This will be populated when a directory is selected
 		},
 	)
 
+	// setListItem shows text in a file list row
+	setListItem := func(o fyne.CanvasObject, id widget.ListItemID, text string) {
+		item := o.(*listItem)
+		item.id = id
+		item.SetText(text)
+	}
+
 	// Search entry (global search across store)
 	searchEntry := widget.NewEntry()
 	searchEntry.SetPlaceHolder("Search passwords… (name or path)")
 	searchEntry.OnChanged = func(query string) {
+		noteActivity()
 		q := strings.TrimSpace(strings.ToLower(query))
 		if q == "" {
 			// Exit search mode and restore selection-driven list
@@ -723,417 +915,558 @@ func main() {
 			return
 		}
 
-		// Build list of all relative paths from store
-		// Use AllPaths to traverse; compute relative path and trim .gpg
-		var allRel []string
-		sep := string(os.PathSeparator)
-		prefix := targetPath + sep
-		for _, full := range store.AllPaths {
-			rel := strings.TrimPrefix(full, prefix)
-			if strings.HasSuffix(rel, ".gpg") {
-				rel = strings.TrimSuffix(rel, ".gpg")
-			}
-			allRel = append(allRel, rel)
-		}
-
-		// Filter
+		// Match against the relative path of every entry
 		var results []string
-		for _, rel := range allRel {
-			if strings.Contains(strings.ToLower(rel), q) {
-				results = append(results, rel)
+		store.Walk(func(node *scanpassstore.Node) error {
+			if !node.IsDir() && strings.Contains(strings.ToLower(node.Path), q) {
+				results = append(results, node.Path)
 			}
-		}
+			return nil
+		})
+		sort.Strings(results)
 
 		// Update state and list
 		appState.SearchActive = true
 		appState.SearchResults = results
 		fileList.Length = func() int { return len(appState.SearchResults) }
 		fileList.UpdateItem = func(id widget.ListItemID, o fyne.CanvasObject) {
-			label := o.(*widget.Label)
-			label.SetText(appState.SearchResults[id])
+			setListItem(o, id, appState.SearchResults[id])
 		}
 		fileList.Refresh()
 		contentLabel.SetText(fmt.Sprintf("Found %d matching entr(y/ies)", len(results)))
 	}
 
+	// listedEntries returns the entries shown in the file list for a tree selection
+	listedEntries := func(id widget.TreeNodeID) []*scanpassstore.Node {
+		if id == rootFilesNodeID {
+			return store.Root.Entries()
+		}
+		if node, ok := lookupTreeNode(id); ok && node.IsDir() {
+			return node.Entries()
+		}
+		return nil
+	}
+
 	// Handle tree selection
 	tree.OnSelected = func(id widget.TreeNodeID) {
+		noteActivity()
 		// Store the selected directory in app state
 		appState.SelectedDirectory = id
+		node, found := lookupTreeNode(id)
 
-		if id == "Root" {
-			// Show root files
-			fileList.Length = func() int { return len(store.RootFiles) }
-			fileList.UpdateItem = func(id widget.ListItemID, o fyne.CanvasObject) {
-				label := o.(*widget.Label)
-				label.SetText(store.RootFiles[id])
-			}
-			contentLabel.SetText(fmt.Sprintf("Root directory contains %d password files", len(store.RootFiles)))
-		} else if files, ok := store.DirContents[id]; ok {
+		if id == rootFilesNodeID || (found && node.IsDir()) {
 			// Show files in selected directory
-			fileList.Length = func() int { return len(files) }
+			entries := listedEntries(id)
+			fileList.Length = func() int { return len(entries) }
 			fileList.UpdateItem = func(id widget.ListItemID, o fyne.CanvasObject) {
-				label := o.(*widget.Label)
-				label.SetText(files[id])
+				setListItem(o, id, entries[id].Name)
 			}
-			contentLabel.SetText(fmt.Sprintf("Directory '%s' contains %d password files", id, len(files)))
-		} else {
-			// Check if this is a file (either root file or file in directory)
-			var fileName string
-
-			// Check if it's a root file
-			for _, rootFile := range store.RootFiles {
-				if rootFile == id {
-					fileName = rootFile
-					break
-				}
+			if id == rootFilesNodeID {
+				contentLabel.SetText(fmt.Sprintf("Root directory contains %d password files", len(entries)))
+			} else {
+				contentLabel.SetText(fmt.Sprintf("Directory '%s' contains %d password files", node.Path, len(entries)))
 			}
-
-			// If not a root file, check if it's a file in any directory
-			if fileName == "" {
-				for _, dirFiles := range store.DirContents {
-					for _, dirFile := range dirFiles {
-						if dirFile == id {
-							fileName = dirFile
-							break
-						}
-					}
-					if fileName != "" {
-						break
-					}
-				}
+		} else if found {
+			// This is a file, show it in the file list
+			fileList.Length = func() int { return 1 }
+			fileList.UpdateItem = func(id widget.ListItemID, o fyne.CanvasObject) {
+				setListItem(o, id, node.Name)
 			}
+			contentLabel.SetText(fmt.Sprintf("Selected file: %s", node.Path))
 
-			if fileName != "" {
-				// This is a file, show it in the file list
-				fileList.Length = func() int { return 1 }
-				fileList.UpdateItem = func(id widget.ListItemID, o fyne.CanvasObject) {
-					label := o.(*widget.Label)
-					label.SetText(fileName)
-				}
-				contentLabel.SetText(fmt.Sprintf("Selected file: %s", fileName))
-
-				// Automatically select the file for editing
-				fileList.Select(0)
-
-				// Directly trigger decryption for the selected file
-				go func() {
-					// Determine the file path based on whether it's a root file or directory file
-					var filePath string
-
-					// Check if it's a root file
-					for _, rootFile := range store.RootFiles {
-						if rootFile == id {
-							filePath = filepath.Join(targetPath, fileName+".gpg")
-							break
-						}
-					}
-
-					// If not a root file, check if it's a file in any directory
-					if filePath == "" {
-						// Use the AllPaths map to get the full path
-						if fullPath, ok := store.AllPaths[fileName]; ok {
-							filePath = fullPath
-						} else {
-							// Fallback to old logic for backward compatibility
-							for dirName, dirFiles := range store.DirContents {
-								for _, dirFile := range dirFiles {
-									if dirFile == id {
-										filePath = filepath.Join(targetPath, dirName, fileName+".gpg")
-										break
-									}
-								}
-								if filePath != "" {
-									break
-								}
-							}
-						}
-					}
+			// Automatically select the file for editing
+			fileList.Select(0)
 
-					if filePath != "" {
-						// Start the decryption process
-						decryptAndEditFile(filePath, myWindow)
-					}
-				}()
-			} else {
-				// Reset file list for other selections
-				fileList.Length = func() int { return 0 }
-				contentLabel.SetText("Select a directory or file to view details")
-			}
+			// Directly trigger decryption for the selected file
+			openEntry(node.FullPath)
+		} else {
+			// Reset file list for other selections
+			fileList.Length = func() int { return 0 }
+			contentLabel.SetText("Select a directory or file to view details")
 		}
 		fileList.Refresh()
 	}
 
-	// Handle file selection
-	fileList.OnSelected = func(id widget.ListItemID) {
-		// If search is active, resolve selection directly by relative path
+	// listedEntry returns the entry shown in a row of the file list
+	listedEntry := func(id widget.ListItemID) *scanpassstore.Node {
 		if appState.SearchActive {
+			// Search results are relative entry paths
 			if id < 0 || id >= len(appState.SearchResults) {
-				return
+				return nil
 			}
-			rel := appState.SearchResults[id]
-			// Build full path and open
-			filePath := filepath.Join(targetPath, rel+".gpg")
-			go decryptAndEditFile(filePath, myWindow)
-			return
+			node, _ := store.Lookup(appState.SearchResults[id])
+			return node
+		}
+		if entries := listedEntries(appState.SelectedDirectory); id >= 0 && id < len(entries) {
+			return entries[id]
 		}
+		// A file selected in the tree is listed on its own
+		if node, ok := lookupTreeNode(appState.SelectedDirectory); ok && !node.IsDir() && id == 0 {
+			return node
+		}
+		return nil
+	}
 
-		selectedDir := appState.SelectedDirectory
-		var fileName string
-		var filePath string
-
-		if selectedDir == "Root" {
-			fileName = store.RootFiles[id]
-			filePath = filepath.Join(targetPath, fileName+".gpg")
-		} else if files, ok := store.DirContents[selectedDir]; ok && id < len(files) {
-			fileName = files[id]
-			// Use AllPaths for nested directory support
-			if fullPath, ok := store.AllPaths[fileName]; ok {
-				filePath = fullPath
-			} else {
-				// Fallback to old logic
-				filePath = filepath.Join(targetPath, selectedDir, fileName+".gpg")
-			}
+	// Handle file selection
+	fileList.OnSelected = func(id widget.ListItemID) {
+		if node, ok := lookupTreeNode(appState.SelectedDirectory); ok && !node.IsDir() && !appState.SearchActive {
+			// Selecting the file in the tree already opened it
+			return
 		}
 
-		if fileName != "" {
+		if entry := listedEntry(id); entry != nil && !entry.IsDir() {
 			// Start the decryption process
-			go func() {
-				// Define the decryption function inline to avoid scope issues
-				var decryptAndEdit func(string, string)
-				decryptAndEdit = func(filePath string, passphrase string) {
-					// Create a command to decrypt the GPG file
-					var cmd *exec.Cmd
-					if passphrase == "" {
-						// Try to decrypt without passphrase (using GPG agent)
-						cmd = exec.Command("gpg", "--batch", "--decrypt", filePath)
-					} else {
-						// Use provided passphrase
-						cmd = exec.Command("gpg", "--batch", "--passphrase", passphrase, "--decrypt", filePath)
-					}
-
-					// Run the command and get the output
-					output, err := cmd.CombinedOutput()
-					if err != nil {
-						// If this was a first attempt without passphrase, prompt for passphrase
-						if passphrase == "" {
-							// Show passphrase dialog
-							fyne.Do(func() {
-								passphraseEntry := widget.NewPasswordEntry()
-								fileName := filepath.Base(filePath)
-
-								passphraseDialog := dialog.NewCustomConfirm(
-									"Enter Passphrase",
-									"Decrypt",
-									"Cancel",
-									container.NewVBox(
-										widget.NewLabel(fmt.Sprintf("File: %s", strings.TrimSuffix(fileName, ".gpg"))),
-										widget.NewLabel("GPG agent requires passphrase. Please enter:"),
-										passphraseEntry,
-									),
-									func(decrypt bool) {
-										if decrypt {
-											newPassphrase := passphraseEntry.Text
-											if newPassphrase == "" {
-												dialog.ShowError(errors.New("Passphrase cannot be empty"), myWindow)
-												return
-											}
-
-											// Try again with the provided passphrase
-											go func() {
-												decryptAndEdit(filePath, newPassphrase)
-											}()
-										}
-									},
-									myWindow,
-								)
-								passphraseDialog.Show()
-							})
-							return
-						} else {
-							// This was already a passphrase attempt, show error
-							fyne.Do(func() {
-								dialog.ShowError(fmt.Errorf("Failed to decrypt file: %v\n%s", err, output), myWindow)
-							})
-							return
-						}
-					}
+			openEntry(entry.FullPath)
+		}
+	}
 
-					// Filter out GPG header information
-					lines := strings.Split(string(output), "\n")
-					var contentLines []string
-					for _, line := range lines {
-						// Skip lines that contain GPG header information
-						if strings.HasPrefix(line, "gpg:") ||
-							strings.Contains(line, "encrypted with") ||
-							strings.Contains(line, "created") ||
-							strings.Contains(line, "<c4point@gmail.com>") {
-							continue
-						}
-						contentLines = append(contentLines, line)
-					}
+	// entryMenu offers to open an entry, copy from it, show its history or delete it
+	entryMenu := func(entry *scanpassstore.Node) *fyne.Menu {
+		historyItem := fyne.NewMenuItem("History…", func() {
+			go decryptFile(entry.FullPath, myWindow, func(content string) {
+				showHistoryDialog(myWindow, targetPath, entry.ID(), content, func(revision storeops.Revision) {
+					restoreNode(entry.ID(), revision)
+				})
+			})
+		})
+		historyItem.Disabled = !storeops.IsGitRepo(targetPath)
+		return fyne.NewMenu("",
+			fyne.NewMenuItem("Open", func() {
+				openEntry(entry.FullPath)
+			}),
+			historyItem,
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Copy Password", func() {
+				go copyPassword(entry.FullPath, myWindow)
+			}),
+			fyne.NewMenuItem("Copy Username", func() {
+				go copyUsername(entry.FullPath, myWindow)
+			}),
+			fyne.NewMenuItem("Copy Field…", func() {
+				go showCopyFieldDialog(entry.FullPath, myWindow)
+			}),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Rename or Move…", func() {
+				showMoveDialog(entry)
+			}),
+			fyne.NewMenuItem("Delete…", func() {
+				deleteNode(entry)
+			}),
+		)
+	}
 
-					// Join the filtered lines back together
-					filteredContent := strings.Join(contentLines, "\n")
+	// Right-clicking an entry in the file list shows the entry menu
+	showEntryMenu = func(id widget.ListItemID, pos fyne.Position) {
+		entry := listedEntry(id)
+		if entry == nil || entry.IsDir() {
+			return
+		}
+		widget.ShowPopUpMenuAtPosition(entryMenu(entry), myWindow.Canvas(), pos)
+	}
 
-					// Create an entry widget with the filtered decrypted content
-					contentEntry := widget.NewMultiLineEntry()
-					contentEntry.SetText(filteredContent)
+	// Right-clicking the tree shows the entry menu or offers to delete a folder
+	showTreeMenu = func(id widget.TreeNodeID, pos fyne.Position) {
+		node, ok := lookupTreeNode(id)
+		if !ok {
+			return
+		}
+		menu := entryMenu(node)
+		if node.IsDir() {
+			historyItem := fyne.NewMenuItem("Folder History…", func() {
+				showHistoryDialog(myWindow, targetPath, node.ID(), "", func(revision storeops.Revision) {
+					restoreNode(node.ID(), revision)
+				})
+			})
+			historyItem.Disabled = !storeops.IsGitRepo(targetPath)
+			menu = fyne.NewMenu("",
+				historyItem,
+				fyne.NewMenuItemSeparator(),
+				fyne.NewMenuItem("Rename or Move Folder…", func() {
+					showMoveDialog(node)
+				}),
+				fyne.NewMenuItem("Delete Folder…", func() {
+					deleteNode(node)
+				}),
+			)
+		}
+		widget.ShowPopUpMenuAtPosition(menu, myWindow.Canvas(), pos)
+	}
 
-					// Create buttons first
-					var editDialog *dialog.CustomDialog
+	// applyStoreEvents patches the tree after the store was updated from disk,
+	// keeping open branches and the selection on renamed nodes
+	applyStoreEvents := func(events []scanpassstore.Event) {
+		selected := appState.SelectedDirectory
+		for _, event := range events {
+			fullPath := filepath.Join(targetPath, filepath.FromSlash(event.Path))
+			switch event.Op {
+			case scanpassstore.Renamed:
+				if tree.IsBranchOpen(event.OldID()) {
+					tree.OpenBranch(event.ID())
+				}
+				if selected == event.OldID() || (event.IsDir && strings.HasPrefix(selected, event.OldID())) {
+					selected = event.ID() + strings.TrimPrefix(selected, event.OldID())
+				}
+				pane.Moved(filepath.Join(targetPath, filepath.FromSlash(event.OldPath)), fullPath, event.IsDir)
+			case scanpassstore.Removed:
+				if selected == event.ID() || (event.IsDir && strings.HasPrefix(selected, event.ID())) {
+					selected = ""
+				}
+				pane.Removed(fullPath, event.IsDir)
+			}
+		}
+		if pane.filePath != "" {
+			// Copy shortcuts follow the entry shown in the pane
+			appState.SelectedEntry = pane.filePath
+		}
+		tree.Refresh()
+		refreshGitBadges()
 
-					saveBtn := widget.NewButton("Save Changes", func() {
-						// Get the edited content
-						editedContent := contentEntry.Text
+		if selected != appState.SelectedDirectory {
+			if selected == "" {
+				tree.UnselectAll()
+				appState.SelectedDirectory = ""
+				fileList.Length = func() int { return 0 }
+				contentLabel.SetText("Select a directory or file to view details")
+				fileList.Refresh()
+			} else {
+				// Move the selection without opening a renamed entry again
+				appState.SelectedDirectory = selected
+				onSelected := tree.OnSelected
+				tree.OnSelected = nil
+				tree.Select(selected)
+				tree.OnSelected = onSelected
+			}
+		}
 
-						// Create a temporary file for the edited content
-						tmpFile, err := ioutil.TempFile("", "gpg_edit_*")
-						if err != nil {
-							dialog.ShowError(fmt.Errorf("Failed to create temporary file: %v", err), myWindow)
-							return
-						}
-						tmpFileName := tmpFile.Name()
+		// Refresh the file list in place
+		if appState.SearchActive {
+			searchEntry.OnChanged(searchEntry.Text)
+		} else if entries := listedEntries(appState.SelectedDirectory); entries != nil {
+			fileList.Length = func() int { return len(entries) }
+			fileList.UpdateItem = func(id widget.ListItemID, o fyne.CanvasObject) {
+				setListItem(o, id, entries[id].Name)
+			}
+			fileList.Refresh()
+		} else if node, ok := lookupTreeNode(appState.SelectedDirectory); ok {
+			fileList.UpdateItem = func(id widget.ListItemID, o fyne.CanvasObject) {
+				setListItem(o, id, node.Name)
+			}
+			fileList.Refresh()
+		}
+	}
 
-						// Write the edited content to the temporary file
-						if _, err := tmpFile.WriteString(editedContent); err != nil {
-							dialog.ShowError(fmt.Errorf("Failed to write to temporary file: %v", err), myWindow)
-							os.Remove(tmpFileName)
-							return
-						}
-						tmpFile.Close()
+	// deleteNode removes an entry or a folder with everything in it after asking, like pass rm -r
+	deleteNode = func(node *scanpassstore.Node) {
+		id, relPath := node.ID(), node.Path
+		message := fmt.Sprintf("Delete %s?\n\nThis removes 1 entry.", relPath)
+		if node.IsDir() {
+			message = fmt.Sprintf("Delete the folder %s/ and everything in it?\n\nThis removes %s.", relPath, entryCountText(node.EntryCount()))
+		}
+		dialog.ShowConfirm("Delete", message, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			noteActivity()
+			if err := storeops.Remove(targetPath, id); err != nil {
+				dialog.ShowError(fmt.Errorf("Failed to delete %s: %v", relPath, err), myWindow)
+				return
+			}
+			applyStoreEvents(store.Update(path.Dir(relPath)))
+			contentLabel.SetText(fmt.Sprintf("Removed %s from the store", relPath))
+			commitStoreChange(targetPath, myWindow, fmt.Sprintf("Remove %s from store.", relPath), storeops.DiskPath(id))
+		}, myWindow)
+	}
 
-						// Get the recipient from the original file
-						recipientCmd := exec.Command("gpg", "--batch", "--list-packets", filePath)
-						recipientOutput, err := recipientCmd.CombinedOutput()
-						if err != nil {
-							dialog.ShowError(fmt.Errorf("Failed to get recipient info: %v\n%s", err, recipientOutput), myWindow)
-							return
-						}
+	// moveNode moves an entry or folder to the node ID to like pass mv. Entries are
+	// re-encrypted when their new folder has other recipients, asking for a passphrase
+	// if gpg-agent cannot decrypt them.
+	moveNode := func(node *scanpassstore.Node, to string) {
+		from, fromPath, isDir := node.ID(), node.Path, node.IsDir()
+		toPath := strings.TrimSuffix(to, "/")
+
+		var move func(passphrase []byte)
+		move = func(passphrase []byte) {
+			var decryptErr error
+			paths, err := storeops.Move(targetPath, from, to, func(ciphertext []byte, gpgIDs []string) ([]byte, error) {
+				plaintext, err := cryptoBackend.Decrypt(ciphertext, passphrase)
+				if err != nil {
+					decryptErr = err
+					return nil, err
+				}
+				defer crypto.Wipe(plaintext)
+				return cryptoBackend.Encrypt(plaintext, gpgIDs)
+			})
+			crypto.Wipe(passphrase)
+
+			var noSecretKey *crypto.NoSecretKeyError
+			fyne.Do(func() {
+				switch {
+				case err == nil:
+				case decryptErr != nil && !errors.As(decryptErr, &noSecretKey) && (passphrase == nil || errors.Is(decryptErr, crypto.ErrBadPassphrase)):
+					message := "Moving re-encrypts entries for new recipients. Please enter your passphrase:"
+					if passphrase != nil {
+						message = "Wrong passphrase. Please try again:"
+					}
+					showPassphraseDialog(node.FullPath, message, myWindow, func(_ string, passphrase []byte) {
+						move(passphrase)
+					}, nil)
+					return
+				default:
+					dialog.ShowError(fmt.Errorf("Failed to move %s: %v", fromPath, err), myWindow)
+					return
+				}
 
-						// Parse the output to find the recipient
-						recipientStr := string(recipientOutput)
-						var recipient string
-
-						// Look for keyid in the output
-						for _, line := range strings.Split(recipientStr, "\n") {
-							if strings.Contains(line, "keyid") {
-								parts := strings.Split(line, "keyid")
-								if len(parts) > 1 {
-									keyidPart := strings.TrimSpace(parts[1])
-									if len(keyidPart) > 16 { // Typical keyid length with some buffer
-										recipient = keyidPart[:16]
-										break
-									}
-								}
-							}
-						}
+				noteActivity()
+				pane.Moved(filepath.Join(targetPath, filepath.FromSlash(fromPath)), filepath.Join(targetPath, filepath.FromSlash(toPath)), isDir)
+				applyStoreEvents(store.Update(path.Dir(fromPath), path.Dir(toPath)))
+				contentLabel.SetText(fmt.Sprintf("Moved %s to %s", fromPath, toPath))
+				commitStoreChange(targetPath, myWindow, fmt.Sprintf("Rename %s to %s.", fromPath, toPath), paths...)
+			})
+		}
+		go move(nil)
+	}
 
-						if recipient == "" {
-							// If we couldn't find the recipient, ask the user
-							recipientEntry := widget.NewEntry()
-							if defaultRecipient != "" {
-								recipientEntry.SetText(defaultRecipient)
-							} else {
-								recipientEntry.SetPlaceHolder("email or key ID")
-							}
-							recipientDialog := dialog.NewCustomConfirm(
-								"Enter Recipient",
-								"Encrypt",
-								"Cancel",
-								container.NewVBox(
-									widget.NewLabel("Could not detect recipient automatically."),
-									widget.NewLabel("Please enter GPG recipient (email or key ID):"),
-									recipientEntry,
-								),
-								func(confirm bool) {
-									if confirm {
-										recipient = recipientEntry.Text
-										if recipient == "" {
-											dialog.ShowError(errors.New("Recipient cannot be empty"), myWindow)
-											return
-										}
-
-										// Now encrypt with the provided recipient
-										cmd := exec.Command("gpg", "--batch", "--yes", "--recipient", recipient,
-											"--output", filePath, "--encrypt", tmpFileName)
-
-										output, err := cmd.CombinedOutput()
-										// Clean up the temporary file
-										os.Remove(tmpFileName)
-
-										if err != nil {
-											dialog.ShowError(fmt.Errorf("Failed to encrypt file: %v\n%s", err, output), myWindow)
-											return
-										}
-
-										dialog.ShowInformation("Success", "File saved successfully", myWindow)
-										if editDialog != nil {
-											editDialog.Hide()
-										}
-									}
-								},
-								myWindow,
-							)
-							recipientDialog.Show()
-						} else {
-							// Create a command to encrypt the edited content with the detected recipient
-							cmd := exec.Command("gpg", "--batch", "--yes", "--recipient", recipient,
-								"--output", filePath, "--encrypt", tmpFileName)
-
-							output, err := cmd.CombinedOutput()
-							// Clean up the temporary file
-							os.Remove(tmpFileName)
-
-							if err != nil {
-								dialog.ShowError(fmt.Errorf("Failed to encrypt file: %v\n%s", err, output), myWindow)
-								return
-							}
-
-							dialog.ShowInformation("Success", "File saved successfully", myWindow)
-							if editDialog != nil {
-								editDialog.Hide()
-							}
-						}
-					})
+	// restoreNode puts the entry or folder with node ID id back to its state at a
+	// revision. Entries are re-encrypted when their recipients changed since then,
+	// asking for a passphrase if gpg-agent cannot decrypt them.
+	restoreNode = func(id string, revision storeops.Revision) {
+		relPath := strings.TrimSuffix(id, "/")
+		oldID := strings.TrimSuffix(revision.Path, ".gpg")
+		if strings.HasSuffix(id, "/") {
+			oldID += "/"
+		}
 
-					closeBtn := widget.NewButton("Close", func() {
-						if editDialog != nil {
-							editDialog.Hide()
-						}
-					})
+		var restore func(passphrase []byte)
+		restore = func(passphrase []byte) {
+			var decryptErr error
+			paths, err := storeops.Restore(targetPath, revision.Hash, oldID, id, func(ciphertext []byte, gpgIDs []string) ([]byte, error) {
+				plaintext, err := cryptoBackend.Decrypt(ciphertext, passphrase)
+				if err != nil {
+					decryptErr = err
+					return nil, err
+				}
+				defer crypto.Wipe(plaintext)
+				return cryptoBackend.Encrypt(plaintext, gpgIDs)
+			})
+			crypto.Wipe(passphrase)
+
+			var noSecretKey *crypto.NoSecretKeyError
+			fyne.Do(func() {
+				switch {
+				case err == nil:
+				case decryptErr != nil && !errors.As(decryptErr, &noSecretKey) && (passphrase == nil || errors.Is(decryptErr, crypto.ErrBadPassphrase)):
+					message := "Restoring re-encrypts entries for their current recipients. Please enter your passphrase:"
+					if passphrase != nil {
+						message = "Wrong passphrase. Please try again:"
+					}
+					showPassphraseDialog(filepath.Join(targetPath, filepath.FromSlash(storeops.DiskPath(id))), message, myWindow, func(_ string, passphrase []byte) {
+						restore(passphrase)
+					}, nil)
+					return
+				default:
+					dialog.ShowError(fmt.Errorf("Failed to restore %s: %v", relPath, err), myWindow)
+					return
+				}
 
-					// Create the dialog with content and buttons
-					buttonContainer := container.NewHBox(saveBtn, closeBtn)
-					contentContainer := container.NewBorder(nil, buttonContainer, nil, nil, contentEntry)
-					editDialog = dialog.NewCustomWithoutButtons("Edit Password File", contentContainer, myWindow)
-					editDialog.Resize(fyne.NewSize(600, 400))
-					editDialog.Show()
+				noteActivity()
+				applyStoreEvents(store.Update(path.Dir(relPath)))
+				// Show the restored content if the entry is open
+				restored := filepath.Join(targetPath, filepath.FromSlash(storeops.DiskPath(id)))
+				if !pane.editing && (pane.filePath == restored || strings.HasPrefix(pane.filePath, restored+string(filepath.Separator))) {
+					pane.Open(pane.filePath)
 				}
+				contentLabel.SetText(fmt.Sprintf("Restored %s to %s", relPath, shortHash(revision.Hash)))
+				commitStoreChange(targetPath, myWindow, fmt.Sprintf("Restore %s to its state at %s (%s).", relPath, shortHash(revision.Hash), revision.Subject), paths...)
+			})
+		}
+		go restore(nil)
+	}
+	pane.onRestore = restoreNode
+
+	// showMoveDialog asks for the new path of an entry or folder
+	showMoveDialog = func(node *scanpassstore.Node) {
+		pathEntry := widget.NewEntry()
+		pathEntry.SetText(node.Path)
+		title := "Rename or Move Entry"
+		if node.IsDir() {
+			title = "Rename or Move Folder"
+		}
+		dialog.ShowCustomConfirm(title, "Move", "Cancel", container.NewVBox(
+			widget.NewLabel(fmt.Sprintf("New path for %s (%s):", node.Path, entryCountText(node.EntryCount()))),
+			pathEntry,
+		), func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			newPath := strings.Trim(path.Clean("/"+strings.TrimSpace(pathEntry.Text)), "/")
+			if newPath == "" {
+				dialog.ShowError(errors.New("The new path cannot be empty"), myWindow)
+				return
+			}
+			if newPath == node.Path {
+				return
+			}
+			if node.IsDir() {
+				newPath += "/"
+			}
+			moveNode(node, newPath)
+		}, myWindow)
+	}
 
-				// Start the decryption process
-				decryptAndEdit(filePath, "")
-			}()
+	// treeNodeAt finds the tree node shown at an absolute position
+	treeNodeAt := func(pos fyne.Position) (widget.TreeNodeID, bool) {
+		driver := fyne.CurrentApp().Driver()
+		treePos := driver.AbsolutePositionForObject(tree)
+		if !containsPoint(pos, treePos, tree.Size()) {
+			return "", false
+		}
+		for _, item := range treeItems {
+			// Recycled items are no longer inside the tree
+			itemPos := driver.AbsolutePositionForObject(item)
+			if item.Visible() && containsPoint(itemPos, treePos, tree.Size()) && containsPoint(pos, itemPos, item.Size()) {
+				return item.id, true
+			}
+		}
+		return "", false
+	}
+
+	// Dropping a node onto a folder moves it there; dropping onto an entry moves it
+	// next to that entry, and onto the group nodes to the store root
+	dropTreeNode = func(id widget.TreeNodeID, pos fyne.Position) {
+		node, ok := lookupTreeNode(id)
+		targetID, found := treeNodeAt(pos)
+		if !ok || !found {
+			return
+		}
+		folder := ""
+		if target, ok := lookupTreeNode(targetID); ok {
+			folder = target.Path
+			if !target.IsDir() {
+				if folder = path.Dir(target.Path); folder == "." {
+					folder = ""
+				}
+			}
+		}
+		to := path.Join(folder, node.Name)
+		if node.IsDir() {
+			to += "/"
 		}
+		if to == node.ID() {
+			return
+		}
+		dialog.ShowConfirm("Move", fmt.Sprintf("Move %s to %s?", node.Path, strings.TrimSuffix(to, "/")), func(confirmed bool) {
+			if confirmed {
+				moveNode(node, to)
+			}
+		}, myWindow)
 	}
 
 	// Layout the UI
-	split := container.NewHSplit(
+	listSplit := container.NewHSplit(
 		container.NewBorder(
-			widget.NewLabel("Password Store Structure"),
+			contentLabel,
 			nil, nil, nil,
-			container.NewScroll(tree),
+			container.NewScroll(fileList),
 		),
+		pane.container,
+	)
+	listSplit.SetOffset(0.4)
+	split := container.NewHSplit(
 		container.NewBorder(
-			contentLabel,
+			widget.NewLabel("Password Store Structure"),
 			nil, nil, nil,
-			container.NewScroll(fileList),
+			container.NewScroll(tree),
 		),
+		listSplit,
 	)
 	split.SetOffset(0.3)
 
+	// Countdown shown while a copied secret is on the clipboard
+	clipboardLabel := widget.NewLabel("")
+	clipboardProgress := widget.NewProgressBar()
+	clipboardProgress.TextFormatter = func() string { return "" }
+	clearClipboardBtn := widget.NewButtonWithIcon("Clear Now", theme.ContentClearIcon(), clipboardManager.Restore)
+	clipboardBar := container.NewBorder(nil, nil, nil, clearClipboardBtn, container.NewVBox(clipboardLabel, clipboardProgress))
+	clipboardBar.Hide()
+	clipboardManager.OnCountdown = func(name string, remaining time.Duration) {
+		if remaining <= 0 {
+			clipboardBar.Hide()
+			return
+		}
+		clipboardLabel.SetText(fmt.Sprintf("%s copied to clipboard. Clearing in %d seconds.", name, int(math.Ceil(remaining.Seconds()))))
+		clipboardProgress.SetValue(math.Min(remaining.Seconds()/clipboardTimeout.Seconds(), 1))
+		clipboardBar.Show()
+	}
+
+	// Copy shortcuts act on the entry opened last
+	myWindow.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyC, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift}, func(fyne.Shortcut) {
+		if appState.SelectedEntry != "" {
+			go copyPassword(appState.SelectedEntry, myWindow)
+		}
+	})
+	myWindow.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyU, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift}, func(fyne.Shortcut) {
+		if appState.SelectedEntry != "" {
+			go copyUsername(appState.SelectedEntry, myWindow)
+		}
+	})
+
+	// lockSession closes everything decrypted, like a locked screen would hide it
+	lockSession := func() {
+		pane.Clear()
+
+		// Dialogs may show decrypted fields or hold a typed passphrase
+		overlays := myWindow.Canvas().Overlays()
+		for _, overlay := range append([]fyne.CanvasObject(nil), overlays.List()...) {
+			overlays.Remove(overlay)
+		}
+
+		clipboardManager.Restore()
+		if cache, ok := cryptoBackend.(crypto.PassphraseCache); ok && appSettings.LockClearsAgent {
+			go func() {
+				if err := cache.ForgetPassphrases(); err != nil {
+					fmt.Println("Error clearing cached passphrases:", err)
+				}
+			}()
+		}
+		contentLabel.SetText("Locked. Select an entry to decrypt it again.")
+	}
+	idleLocker = autolock.NewLocker(time.Duration(appSettings.AutoLockMinutes)*time.Minute, func() {
+		fyne.Do(lockSession)
+	})
+	defer idleLocker.Stop()
+
+	// Lock together with the screen
+	if stop, err := autolock.WatchScreenLock(idleLocker.Lock); err != nil {
+		fmt.Println("Not watching the screen lock:", err)
+	} else {
+		defer stop()
+	}
+
+	// Pull and push in the background, showing how far the store is from its remote
+	syncStatusLabel := widget.NewLabel("")
+	syncStatusLabel.Hide()
+	if storeops.IsGitRepo(targetPath) {
+		gitSyncer = gitsync.NewSyncer(targetPath, time.Duration(appSettings.SyncMinutes)*time.Minute, func(status gitsync.Status) {
+			if status.Err != nil {
+				fmt.Println("Error syncing password store:", status.Err)
+			}
+			fyne.Do(func() {
+				syncStatusLabel.SetText(status.String())
+				syncStatusLabel.Show()
+			})
+		})
+		defer gitSyncer.Stop()
+	}
+
 	// Function to refresh the UI
 	refreshUI := func() {
+		clipboardTimeout = time.Duration(appSettings.ClipboardTimeout) * time.Second
+		idleLocker.SetTimeout(time.Duration(appSettings.AutoLockMinutes) * time.Minute)
+		autoCommit = appSettings.AutoCommit
+		if gitSyncer != nil {
+			gitSyncer.SetInterval(time.Duration(appSettings.SyncMinutes) * time.Minute)
+		}
+
 		// Refresh all UI components
 		tree.Refresh()
 		fileList.Refresh()
@@ -1152,12 +1485,13 @@ func main() {
 			}
 			tree.Refresh()
 			fileList.Refresh()
+			refreshGitBadges()
 			contentLabel.SetText("Password store refreshed")
 		}),
 		widget.NewToolbarSeparator(),
 		widget.NewToolbarAction(theme.ContentAddIcon(), func() {
 			// Show new record creation dialog
-			showNewRecordDialog(myWindow, targetPath, defaultRecipient, func() {
+			showNewRecordDialog(myWindow, targetPath, defaultRecipients, func() {
 				// Refresh callback after creating new record
 				store, err = scanpassstore.ScanPasswordStore(targetPath)
 				if err != nil {
@@ -1166,91 +1500,14 @@ func main() {
 				}
 				tree.Refresh()
 				fileList.Refresh()
+				refreshGitBadges()
 				contentLabel.SetText("Password store refreshed")
 			})
 		}),
 		widget.NewToolbarSeparator(),
 		widget.NewToolbarAction(theme.DocumentSaveIcon(), func() {
-			// Manual commit functionality
-			progressBar := widget.NewProgressBar()
-			progressLabel := widget.NewLabel("Committing changes...")
-
-			progressDialog := dialog.NewCustomWithoutButtons("Git Commit",
-				container.NewVBox(progressLabel, progressBar), myWindow)
-			progressDialog.Show()
-
-			go func() {
-				fyne.Do(func() {
-					progressBar.SetValue(0.2)
-				})
-
-				gitDir := targetPath
-				execGitCommand := func(command string, args ...string) (string, error) {
-					cmd := exec.Command(command, args...)
-					cmd.Dir = gitDir
-					output, err := cmd.CombinedOutput()
-					return string(output), err
-				}
-
-				// Check if there are any changes to commit
-				statusOutput, statusErr := execGitCommand("git", "status", "--porcelain")
-				hasChanges := statusErr == nil && strings.TrimSpace(statusOutput) != ""
-
-				if !hasChanges {
-					fyne.Do(func() {
-						fyne.CurrentApp().SendNotification(&fyne.Notification{
-							Title:   "No Changes",
-							Content: "No changes to commit.",
-						})
-						progressDialog.Hide()
-					})
-					return
-				}
-
-				fyne.Do(func() {
-					progressBar.SetValue(0.5)
-				})
-
-				// Add all changes
-				_, addErr := execGitCommand("git", "add", ".")
-				if addErr != nil {
-					fyne.Do(func() {
-						fyne.CurrentApp().SendNotification(&fyne.Notification{
-							Title:   "Git Add Failed",
-							Content: fmt.Sprintf("Error: %v", addErr),
-						})
-						progressDialog.Hide()
-					})
-					return
-				}
-
-				fyne.Do(func() {
-					progressBar.SetValue(0.8)
-				})
-
-				// Commit with timestamp
-				commitMsg := fmt.Sprintf("Manual commit: %s", time.Now().Format("2006-01-02 15:04:05"))
-				_, commitErr := execGitCommand("git", "commit", "-m", commitMsg)
-				if commitErr != nil {
-					fyne.Do(func() {
-						fyne.CurrentApp().SendNotification(&fyne.Notification{
-							Title:   "Git Commit Failed",
-							Content: fmt.Sprintf("Error: %v", commitErr),
-						})
-						progressDialog.Hide()
-					})
-					return
-				}
-
-				fyne.Do(func() {
-					progressBar.SetValue(1.0)
-					fyne.CurrentApp().SendNotification(&fyne.Notification{
-						Title:   "Commit Successful",
-						Content: "Changes committed successfully.",
-					})
-					progressDialog.Hide()
-				})
-			}()
+			// Commit the changes chosen in a dialog
+			showCommitDialog(myWindow, targetPath)
 		}),
 		widget.NewToolbarSeparator(),
 		widget.NewToolbarAction(theme.DownloadIcon(), func() {
@@ -1258,90 +1515,46 @@ func main() {
 			progressBar := widget.NewProgressBar()
 			progressLabel := widget.NewLabel("Syncing with remote repository...")
 
+			repo, openErr := gitrepo.Open(targetPath)
+			if openErr != nil {
+				dialog.ShowError(openErr, myWindow)
+				return
+			}
+
 			progressDialog := dialog.NewCustomWithoutButtons("Git Sync",
 				container.NewVBox(progressLabel, progressBar), myWindow)
 			progressDialog.Show()
 
-			go func() {
-				fyne.Do(func() {
-					progressBar.SetValue(0.05)
-				})
-
-				gitDir := targetPath
-				execGitCommand := func(command string, args ...string) (string, error) {
-					cmd := exec.Command(command, args...)
-					cmd.Dir = gitDir
-					output, err := cmd.CombinedOutput()
-					return string(output), err
-				}
-
-				// Check if there are any changes to commit
-				statusOutput, statusErr := execGitCommand("git", "status", "--porcelain")
-				hasChanges := statusErr == nil && strings.TrimSpace(statusOutput) != ""
-
-				fyne.Do(func() {
-					progressBar.SetValue(0.1)
-				})
-
-				// Fetch latest changes from remote
-				_, fetchErr := execGitCommand("git", "fetch", "--all")
-				if fetchErr != nil {
-					fyne.Do(func() {
-						fyne.CurrentApp().SendNotification(&fyne.Notification{
-							Title:   "Git Fetch Failed",
-							Content: fmt.Sprintf("Error: %v", fetchErr),
-						})
-						progressDialog.Hide()
-					})
-					return
-				}
-
-				fyne.Do(func() {
-					progressBar.SetValue(0.3)
-				})
-
-				// Pull latest changes
-				_, pullErr := execGitCommand("git", "pull", "--rebase")
-				if pullErr != nil {
-					fyne.Do(func() {
-						fyne.CurrentApp().SendNotification(&fyne.Notification{
-							Title:   "Git Pull Failed",
-							Content: fmt.Sprintf("Error: %v", pullErr),
-						})
-						progressDialog.Hide()
-					})
-					return
-				}
+			// Background syncs stay off from the first git call until this sync
+			// pushed, failed or was merged, so they cannot abort its rebase
+			release := func() {}
+			endSync := func() {
+				progressDialog.Hide()
+				release()
+			}
 
-				// If there are local changes, commit them
+			// finishSync commits pending changes to entries and pushes once the pull succeeded
+			finishSync := func(pending []gitrepo.FileStatus) {
+				hasChanges := len(pending) > 0
 				if hasChanges {
 					fyne.Do(func() {
 						progressBar.SetValue(0.5)
 					})
 
-					// Add all changes
-					_, addErr := execGitCommand("git", "add", ".")
-					if addErr != nil {
-						fyne.Do(func() {
-							fyne.CurrentApp().SendNotification(&fyne.Notification{
-								Title:   "Git Add Failed",
-								Content: fmt.Sprintf("Error: %v", addErr),
-							})
-							progressDialog.Hide()
-						})
-						return
+					// Commit only entries and .gpg-id files, with a message like pass would use
+					paths := make([]string, len(pending))
+					for i, file := range pending {
+						paths[i] = file.Path
 					}
-
-					// Commit with timestamp
-					commitMsg := fmt.Sprintf("Auto-commit: %s", time.Now().Format("2006-01-02 15:04:05"))
-					_, commitErr := execGitCommand("git", "commit", "-m", commitMsg)
+					commitMsg := storeops.CommitMessage(targetPath, pending)
+					commitErr := storeops.CommitFiles(repo, commitMsg, paths, false)
 					if commitErr != nil {
 						fyne.Do(func() {
 							fyne.CurrentApp().SendNotification(&fyne.Notification{
 								Title:   "Git Commit Failed",
 								Content: fmt.Sprintf("Error: %v", commitErr),
 							})
-							progressDialog.Hide()
+							endSync()
 						})
 						return
 					}
@@ -1352,14 +1565,14 @@ func main() {
 				})
 
 				// Push to remote
-				_, pushErr := execGitCommand("git", "push")
+				pushErr := repo.Push()
 				if pushErr != nil {
 					fyne.Do(func() {
 						fyne.CurrentApp().SendNotification(&fyne.Notification{
 							Title:   "Git Push Failed",
 							Content: fmt.Sprintf("Error: %v", pushErr),
 						})
-						progressDialog.Hide()
+						endSync()
 					})
 					return
 				}
@@ -1373,6 +1586,7 @@ func main() {
 						tree.Refresh()
 						fileList.Refresh()
 					}
+					refreshGitBadges()
 
 					// Show success notification
 					var message string
@@ -1386,9 +1600,114 @@ func main() {
 						Title:   "Git Sync Complete",
 						Content: message,
 					})
+					if gitSyncer != nil {
+						gitSyncer.Synced()
+					}
+
+					endSync()
+				})
+			}
+
+			// resolveSyncConflicts merges conflicting entries and finishes the sync
+			// once the rebase went through
+			resolveSyncConflicts := func(paths []string) {
+				progressDialog.Hide()
+				resolveConflicts(myWindow, targetPath, paths, func(err error) {
+					if err != nil {
+						release()
+						fyne.CurrentApp().SendNotification(&fyne.Notification{
+							Title:   "Git Sync Stopped",
+							Content: fmt.Sprintf("Error: %v", err),
+						})
+						return
+					}
+					progressLabel.SetText("Pushing merged changes...")
+					progressDialog.Show()
+					go finishSync(nil)
+				})
+			}
+
+			go func() {
+				if gitSyncer != nil {
+					release = gitSyncer.Hold()
+				}
+				fyne.Do(func() {
+					progressBar.SetValue(0.05)
+				})
+
+				// A sync stopped on conflicts resumes where it left off
+				if repo.RebaseInProgress() {
+					paths, err := repo.Conflicts()
+					fyne.Do(func() {
+						if err != nil {
+							dialog.ShowError(fmt.Errorf("Failed to list conflicts: %v", err), myWindow)
+							endSync()
+							return
+						}
+						resolveSyncConflicts(paths)
+					})
+					return
+				}
+
+				// Check if there are any changes to entries to commit; other files are
+				// left for the Commit dialog
+				changes, statusErr := repo.Status()
+				if statusErr != nil {
+					fyne.Do(func() {
+						dialog.ShowError(fmt.Errorf("Failed to read the changes of the store: %v", statusErr), myWindow)
+						endSync()
+					})
+					return
+				}
+				var pending []gitrepo.FileStatus
+				for _, file := range changes {
+					if storeops.IsStoreFile(file.Path) {
+						pending = append(pending, file)
+					}
+				}
+
+				fyne.Do(func() {
+					progressBar.SetValue(0.1)
+				})
+
+				// Fetch latest changes from remote
+				fetchErr := repo.Fetch()
+				if fetchErr != nil {
+					fyne.Do(func() {
+						fyne.CurrentApp().SendNotification(&fyne.Notification{
+							Title:   "Git Fetch Failed",
+							Content: fmt.Sprintf("Error: %v", fetchErr),
+						})
+						endSync()
+					})
+					return
+				}
 
-					progressDialog.Hide()
+				fyne.Do(func() {
+					progressBar.SetValue(0.3)
 				})
+
+				// Pull latest changes, merging entries edited on both sides by hand
+				pullErr := repo.Pull()
+				var conflict *gitrepo.ConflictError
+				if errors.As(pullErr, &conflict) {
+					fyne.Do(func() {
+						resolveSyncConflicts(conflict.Paths)
+					})
+					return
+				}
+				if pullErr != nil {
+					fyne.Do(func() {
+						fyne.CurrentApp().SendNotification(&fyne.Notification{
+							Title:   "Git Pull Failed",
+							Content: fmt.Sprintf("Error: %v", pullErr),
+						})
+						endSync()
+					})
+					return
+				}
+
+				finishSync(pending)
 			}()
 		}),
 		widget.NewToolbarSeparator(),
@@ -1404,17 +1723,36 @@ func main() {
 
 	// Top area: toolbar + search
 	topContainer := container.NewVBox(
-		toolbar,
+		container.NewBorder(nil, nil, nil, syncStatusLabel, toolbar),
 		container.NewBorder(nil, nil, nil, nil, searchEntry),
 	)
 
 	// Main container with toolbar/search and split view
 	mainContainer := container.NewBorder(
 		topContainer,
-		nil, nil, nil,
+		clipboardBar, nil, nil,
 		split,
 	)
 
+	// Watch the store so changes made in a terminal show up right away
+	if watcher, err := scanpassstore.NewWatcher(targetPath); err != nil {
+		fmt.Println("Error watching password store:", err)
+	} else {
+		defer watcher.Close()
+		go func() {
+			for dirs := range watcher.Changes {
+				fyne.Do(func() {
+					applyStoreEvents(store.Update(dirs...))
+				})
+			}
+		}()
+		go func() {
+			for err := range watcher.Errors {
+				fmt.Println("Error watching password store:", err)
+			}
+		}()
+	}
+
 	myWindow.SetContent(mainContainer)
 	myWindow.ShowAndRun()
 }
@@ -2,7 +2,6 @@ package main
 
 import (
 	"os"
-	"os/exec"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,24 +10,6 @@ import (
 	"main.go/settings"
 )
 
-func TestDecryptCommandConstruction(t *testing.T) {
-	filePath := "/tmp/test.gpg"
-	passphrase := ""
-	cmd := buildDecryptCommand(filePath, passphrase)
-	assert.Equal(t, []string{"gpg", "--batch", "--decrypt", filePath}, cmd.Args)
-
-	passphrase = "secret"
-	cmd = buildDecryptCommand(filePath, passphrase)
-	assert.Equal(t, []string{"gpg", "--batch", "--passphrase", "secret", "--decrypt", filePath}, cmd.Args)
-}
-
-func buildDecryptCommand(filePath, passphrase string) *exec.Cmd {
-	if passphrase == "" {
-		return exec.Command("gpg", "--batch", "--decrypt", filePath)
-	}
-	return exec.Command("gpg", "--batch", "--passphrase", passphrase, "--decrypt", filePath)
-}
-
 func TestScanPasswordStoreCLI(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "cli_scan_test")
 	require.NoError(t, err)
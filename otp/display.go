@@ -0,0 +1,131 @@
+package otp
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// hiddenCode is shown for HOTP keys until the next code is requested
+const hiddenCode = "••••••"
+
+// Display is a widget showing the current code of a key. TOTP codes refresh
+// with a countdown; HOTP codes are only generated when asked for.
+type Display struct {
+	widget.BaseWidget
+
+	// OnCopy is called with the code when the copy button is pressed
+	OnCopy func(code string)
+	// OnNext is called with a copy of the key whose HOTP counter was incremented,
+	// to save the new URI. The new code is only shown once done reports it saved,
+	// so a counter that did not make it to disk is never used.
+	OnNext func(next *Key, done func(saved bool))
+
+	key      *Key
+	code     string
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	codeLabel *widget.Label
+	countdown *widget.ProgressBar
+	nextBtn   *widget.Button
+	content   fyne.CanvasObject
+}
+
+// NewDisplay creates a display for the key. Call Stop when it is no longer shown.
+func NewDisplay(key *Key) *Display {
+	d := &Display{key: key, stop: make(chan struct{})}
+
+	d.codeLabel = widget.NewLabel("")
+	d.codeLabel.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
+
+	copyBtn := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		if d.code != "" && d.OnCopy != nil {
+			d.OnCopy(d.code)
+		}
+	})
+
+	var right fyne.CanvasObject
+	if key.Type == TypeTOTP {
+		d.countdown = widget.NewProgressBar()
+		d.countdown.Max = float64(key.Period)
+		d.countdown.TextFormatter = func() string {
+			return fmt.Sprintf("%.0fs", d.countdown.Value)
+		}
+		right = container.NewHBox(container.NewGridWrap(fyne.NewSize(80, d.countdown.MinSize().Height), d.countdown), copyBtn)
+	} else {
+		d.codeLabel.SetText(hiddenCode)
+		d.nextBtn = widget.NewButtonWithIcon("Next Code", theme.MediaSkipNextIcon(), d.next)
+		right = container.NewHBox(d.nextBtn, copyBtn)
+	}
+
+	d.content = container.NewBorder(nil, nil, widget.NewLabel(key.Name()), right, d.codeLabel)
+
+	d.ExtendBaseWidget(d)
+	if key.Type == TypeTOTP {
+		d.refresh()
+		go d.tick()
+	}
+	return d
+}
+
+// CreateRenderer implements fyne.Widget
+func (d *Display) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(d.content)
+}
+
+// Stop stops refreshing TOTP codes
+func (d *Display) Stop() {
+	d.stopOnce.Do(func() { close(d.stop) })
+}
+
+// tick refreshes the TOTP code every second until stopped
+func (d *Display) tick() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			fyne.Do(d.refresh)
+		}
+	}
+}
+
+// refresh shows the TOTP code for the current time
+func (d *Display) refresh() {
+	now := time.Now()
+	d.code = d.key.Code(now)
+	d.codeLabel.SetText(d.code)
+	d.countdown.SetValue(d.key.Remaining(now).Round(time.Second).Seconds())
+}
+
+// next increments the HOTP counter and shows the new code once it is saved
+func (d *Display) next() {
+	next := *d.key
+	code := next.Next()
+	show := func() {
+		d.key = &next
+		d.code = code
+		d.codeLabel.SetText(code)
+	}
+	if d.OnNext == nil {
+		show()
+		return
+	}
+
+	// One counter at a time, so two presses cannot save the same one
+	d.nextBtn.Disable()
+	d.OnNext(&next, func(saved bool) {
+		d.nextBtn.Enable()
+		if saved {
+			show()
+		}
+	})
+}
@@ -0,0 +1,240 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scheme starts every one-time password URI, one per line in a pass entry
+const Scheme = "otpauth://"
+
+// Key types
+const (
+	TypeTOTP = "totp" // Time based codes (RFC 6238)
+	TypeHOTP = "hotp" // Counter based codes (RFC 4226)
+)
+
+// Hash algorithms
+const (
+	AlgorithmSHA1   = "SHA1"
+	AlgorithmSHA256 = "SHA256"
+	AlgorithmSHA512 = "SHA512"
+)
+
+// ErrNoKey is returned when an entry has no otpauth:// line
+var ErrNoKey = errors.New("no otpauth:// line found")
+
+// Key is a parsed otpauth:// URI
+type Key struct {
+	Type      string
+	Issuer    string
+	Account   string
+	Secret    []byte
+	Algorithm string
+	Digits    int
+	Period    int    // Seconds each TOTP code is valid
+	Counter   uint64 // Counter of the last HOTP code
+
+	uri *url.URL
+}
+
+// Parse parses an otpauth:// URI in the format used by pass-otp and Google Authenticator
+func Parse(uri string) (*Key, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return nil, fmt.Errorf("invalid otpauth URI: %w", err)
+	}
+	if u.Scheme != "otpauth" {
+		return nil, fmt.Errorf("invalid otpauth URI: unexpected scheme %q", u.Scheme)
+	}
+
+	key := &Key{
+		Type:      strings.ToLower(u.Host),
+		Algorithm: AlgorithmSHA1,
+		Digits:    6,
+		Period:    30,
+		uri:       u,
+	}
+	if key.Type != TypeTOTP && key.Type != TypeHOTP {
+		return nil, fmt.Errorf("invalid otpauth URI: unknown type %q", u.Host)
+	}
+
+	// The label is "issuer:account" or just "account"
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		key.Issuer = strings.TrimSpace(issuer)
+		key.Account = strings.TrimSpace(account)
+	} else {
+		key.Account = label
+	}
+
+	query := u.Query()
+	if issuer := query.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+
+	key.Secret, err = decodeSecret(query.Get("secret"))
+	if err != nil {
+		return nil, err
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		key.Algorithm = strings.ToUpper(algorithm)
+		if newHash(key.Algorithm) == nil {
+			return nil, fmt.Errorf("invalid otpauth URI: unsupported algorithm %q", algorithm)
+		}
+	}
+	if digits := query.Get("digits"); digits != "" {
+		key.Digits, err = strconv.Atoi(digits)
+		if err != nil || key.Digits < 6 || key.Digits > 8 {
+			return nil, fmt.Errorf("invalid otpauth URI: digits must be 6, 7 or 8, got %q", digits)
+		}
+	}
+	if period := query.Get("period"); period != "" {
+		key.Period, err = strconv.Atoi(period)
+		if err != nil || key.Period < 1 {
+			return nil, fmt.Errorf("invalid otpauth URI: invalid period %q", period)
+		}
+	}
+	if key.Type == TypeHOTP {
+		key.Counter, err = strconv.ParseUint(query.Get("counter"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid otpauth URI: hotp needs a counter")
+		}
+	}
+
+	return key, nil
+}
+
+// decodeSecret decodes a base32 secret, ignoring case, spaces and missing padding
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	secret = strings.TrimRight(secret, "=")
+	if secret == "" {
+		return nil, errors.New("invalid otpauth URI: missing secret")
+	}
+	decoded, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otpauth URI: secret is not base32: %w", err)
+	}
+	return decoded, nil
+}
+
+// newHash returns the hash constructor for an algorithm name, or nil if unsupported
+func newHash(algorithm string) func() hash.Hash {
+	switch algorithm {
+	case AlgorithmSHA1:
+		return sha1.New
+	case AlgorithmSHA256:
+		return sha256.New
+	case AlgorithmSHA512:
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// Name returns a label for the key such as "GitHub (alice)"
+func (k *Key) Name() string {
+	switch {
+	case k.Issuer != "" && k.Account != "":
+		return fmt.Sprintf("%s (%s)", k.Issuer, k.Account)
+	case k.Issuer != "":
+		return k.Issuer
+	default:
+		return k.Account
+	}
+}
+
+// Code returns the TOTP code valid at t
+func (k *Key) Code(t time.Time) string {
+	return k.generate(uint64(t.Unix()) / uint64(k.Period))
+}
+
+// Remaining returns how long the TOTP code valid at t stays valid
+func (k *Key) Remaining(t time.Time) time.Duration {
+	period := time.Duration(k.Period) * time.Second
+	return period - time.Duration(t.UnixNano())%period
+}
+
+// HOTP returns the HOTP code for the given counter
+func (k *Key) HOTP(counter uint64) string {
+	return k.generate(counter)
+}
+
+// Next increments the HOTP counter and returns the code for it, like pass otp does.
+// The entry must be saved with the new URI so codes are never reused.
+func (k *Key) Next() string {
+	k.Counter++
+	return k.generate(k.Counter)
+}
+
+// generate computes a code as described in RFC 4226
+func (k *Key) generate(counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(newHash(k.Algorithm), k.Secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < k.Digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", k.Digits, value%modulo)
+}
+
+// URI returns the otpauth:// URI of the key with the current counter. Everything
+// else is kept as it was parsed, so only the counter changes in the entry.
+func (k *Key) URI() string {
+	u := *k.uri
+	if k.Type == TypeHOTP {
+		params := strings.Split(u.RawQuery, "&")
+		for i, param := range params {
+			if strings.HasPrefix(param, "counter=") {
+				params[i] = "counter=" + strconv.FormatUint(k.Counter, 10)
+			}
+		}
+		u.RawQuery = strings.Join(params, "&")
+	}
+	return u.String()
+}
+
+// Find parses the first otpauth:// line of a decrypted entry. It returns ErrNoKey
+// if there is none.
+func Find(content string) (*Key, error) {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), Scheme) {
+			return Parse(line)
+		}
+	}
+	return nil, ErrNoKey
+}
+
+// ReplaceURI replaces the first otpauth:// line of a decrypted entry with the
+// current URI of the key
+func ReplaceURI(content string, key *Key) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), Scheme) {
+			lines[i] = key.URI()
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
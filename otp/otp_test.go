@@ -0,0 +1,133 @@
+package otp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSecret encodes a test vector secret from the RFCs as base32
+func testSecret(secret string) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte(secret))
+}
+
+func TestParse(t *testing.T) {
+	key, err := Parse("otpauth://totp/GitHub:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=GitHub")
+	require.NoError(t, err)
+	assert.Equal(t, TypeTOTP, key.Type)
+	assert.Equal(t, "GitHub", key.Issuer)
+	assert.Equal(t, "alice@example.com", key.Account)
+	assert.Equal(t, []byte("Hello!\xde\xad\xbe\xef"), key.Secret)
+	assert.Equal(t, AlgorithmSHA1, key.Algorithm)
+	assert.Equal(t, 6, key.Digits)
+	assert.Equal(t, 30, key.Period)
+	assert.Equal(t, "GitHub (alice@example.com)", key.Name())
+
+	key, err = Parse("otpauth://hotp/alice?secret=jbswy3dpehpk3pxp&algorithm=sha256&digits=8&counter=42")
+	require.NoError(t, err)
+	assert.Equal(t, TypeHOTP, key.Type)
+	assert.Equal(t, "", key.Issuer)
+	assert.Equal(t, "alice", key.Name())
+	assert.Equal(t, AlgorithmSHA256, key.Algorithm)
+	assert.Equal(t, 8, key.Digits)
+	assert.Equal(t, uint64(42), key.Counter)
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, uri := range []string{
+		"https://example.com",
+		"otpauth://motp/alice?secret=JBSWY3DPEHPK3PXP",
+		"otpauth://totp/alice",
+		"otpauth://totp/alice?secret=not-base32!",
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=4",
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=0",
+		"otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP",
+	} {
+		_, err := Parse(uri)
+		assert.Error(t, err, uri)
+	}
+}
+
+func TestHOTP(t *testing.T) {
+	// Test vectors from RFC 4226 appendix D
+	key, err := Parse("otpauth://hotp/test?secret=" + testSecret("12345678901234567890") + "&counter=0")
+	require.NoError(t, err)
+
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range expected {
+		assert.Equal(t, code, key.HOTP(uint64(counter)))
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	// Test vectors from RFC 6238 appendix B
+	secrets := map[string]string{
+		AlgorithmSHA1:   "12345678901234567890",
+		AlgorithmSHA256: "12345678901234567890123456789012",
+		AlgorithmSHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	tests := []struct {
+		time      int64
+		algorithm string
+		code      string
+	}{
+		{59, AlgorithmSHA1, "94287082"},
+		{59, AlgorithmSHA256, "46119246"},
+		{59, AlgorithmSHA512, "90693936"},
+		{1111111109, AlgorithmSHA1, "07081804"},
+		{1111111109, AlgorithmSHA256, "68084774"},
+		{1111111109, AlgorithmSHA512, "25091201"},
+		{20000000000, AlgorithmSHA1, "65353130"},
+		{20000000000, AlgorithmSHA256, "77737706"},
+		{20000000000, AlgorithmSHA512, "47863826"},
+	}
+
+	for _, tt := range tests {
+		key, err := Parse("otpauth://totp/test?secret=" + testSecret(secrets[tt.algorithm]) + "&algorithm=" + tt.algorithm + "&digits=8")
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, key.Code(time.Unix(tt.time, 0)), "%s at %d", tt.algorithm, tt.time)
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	key, err := Parse("otpauth://totp/test?secret=JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, key.Remaining(time.Unix(60, 0)))
+	assert.Equal(t, 1*time.Second, key.Remaining(time.Unix(89, 0)))
+	assert.Equal(t, 500*time.Millisecond, key.Remaining(time.Unix(89, int64(500*time.Millisecond))))
+}
+
+func TestNextAndURI(t *testing.T) {
+	uri := "otpauth://hotp/Example:alice?secret=" + testSecret("12345678901234567890") + "&counter=1&issuer=Example"
+	key, err := Parse(uri)
+	require.NoError(t, err)
+
+	// pass otp increments the counter before generating the code
+	assert.Equal(t, "359152", key.Next())
+	assert.Equal(t, uint64(2), key.Counter)
+	assert.Equal(t, strings.Replace(uri, "counter=1", "counter=2", 1), key.URI())
+
+	// TOTP URIs are returned unchanged
+	totp := "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&period=60&issuer=Example"
+	key, err = Parse(totp)
+	require.NoError(t, err)
+	assert.Equal(t, totp, key.URI())
+}
+
+func TestFindAndReplaceURI(t *testing.T) {
+	content := "hunter2\nlogin: alice\n  otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=7\nurl: https://example.com"
+	key, err := Find(content)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), key.Counter)
+
+	key.Next()
+	assert.Equal(t, "hunter2\nlogin: alice\notpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=8\nurl: https://example.com", ReplaceURI(content, key))
+
+	_, err = Find("hunter2\nlogin: alice")
+	assert.ErrorIs(t, err, ErrNoKey)
+}
@@ -0,0 +1,168 @@
+package passgen
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Generator is a widget for tuning a policy with a preview of the generated password
+type Generator struct {
+	widget.BaseWidget
+
+	// OnChanged is called whenever the policy is changed by the user
+	OnChanged func(Policy)
+
+	policy   Policy
+	password string
+	updating bool
+
+	modeSelect     *widget.Select
+	lengthSlider   *widget.Slider
+	lengthLabel    *widget.Label
+	wordsSlider    *widget.Slider
+	wordsLabel     *widget.Label
+	separatorEntry *widget.Entry
+	lowercaseCheck *widget.Check
+	uppercaseCheck *widget.Check
+	digitsCheck    *widget.Check
+	symbolsCheck   *widget.Check
+	ambiguousCheck *widget.Check
+	lengthRow      *fyne.Container
+	wordsRow       *fyne.Container
+	preview        *widget.Label
+	strength       *widget.Label
+	content        fyne.CanvasObject
+}
+
+// NewGenerator creates a generator showing a password for the given policy
+func NewGenerator(policy Policy) *Generator {
+	g := &Generator{}
+
+	g.modeSelect = widget.NewSelect(GetAvailableModes(), func(string) { g.changed() })
+	g.lengthSlider = widget.NewSlider(4, MaxLength)
+	g.lengthSlider.OnChanged = func(float64) { g.changed() }
+	g.lengthLabel = widget.NewLabel("")
+	g.wordsSlider = widget.NewSlider(1, MaxWords)
+	g.wordsSlider.OnChanged = func(float64) { g.changed() }
+	g.wordsLabel = widget.NewLabel("")
+	g.separatorEntry = widget.NewEntry()
+	g.separatorEntry.OnChanged = func(string) { g.changed() }
+	g.lowercaseCheck = widget.NewCheck("a-z", func(bool) { g.changed() })
+	g.uppercaseCheck = widget.NewCheck("A-Z", func(bool) { g.changed() })
+	g.digitsCheck = widget.NewCheck("0-9", func(bool) { g.changed() })
+	g.symbolsCheck = widget.NewCheck("!@#", func(bool) { g.changed() })
+	g.ambiguousCheck = widget.NewCheck("Exclude look-alikes (Il1O0o)", func(bool) { g.changed() })
+
+	g.preview = widget.NewLabel("")
+	g.preview.TextStyle = fyne.TextStyle{Monospace: true}
+	g.preview.Wrapping = fyne.TextWrapBreak
+	g.strength = widget.NewLabel("")
+
+	regenerateBtn := widget.NewButtonWithIcon("Generate", theme.ViewRefreshIcon(), g.Regenerate)
+
+	g.lengthRow = container.NewBorder(nil, nil, widget.NewLabel("Length:"), g.lengthLabel, g.lengthSlider)
+	g.wordsRow = container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Words:"), g.wordsLabel, g.wordsSlider),
+		container.NewBorder(nil, nil, widget.NewLabel("Separator:"), nil, g.separatorEntry),
+	)
+	g.content = container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Mode:"), nil, g.modeSelect),
+		g.lengthRow,
+		g.wordsRow,
+		container.NewHBox(g.lowercaseCheck, g.uppercaseCheck, g.digitsCheck, g.symbolsCheck),
+		g.ambiguousCheck,
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, nil, regenerateBtn, g.preview),
+		g.strength,
+	)
+
+	g.ExtendBaseWidget(g)
+	g.SetPolicy(policy)
+	return g
+}
+
+// CreateRenderer implements fyne.Widget
+func (g *Generator) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(g.content)
+}
+
+// Policy returns the policy as currently configured
+func (g *Generator) Policy() Policy {
+	return g.policy
+}
+
+// Password returns the password shown in the preview, or "" if the policy is invalid
+func (g *Generator) Password() string {
+	return g.password
+}
+
+// SetPolicy replaces the policy and generates a new preview
+func (g *Generator) SetPolicy(policy Policy) {
+	g.updating = true
+	g.modeSelect.SetSelected(policy.Mode)
+	g.lengthSlider.SetValue(float64(policy.Length))
+	g.wordsSlider.SetValue(float64(policy.Words))
+	g.separatorEntry.SetText(policy.Separator)
+	g.lowercaseCheck.SetChecked(policy.Lowercase)
+	g.uppercaseCheck.SetChecked(policy.Uppercase)
+	g.digitsCheck.SetChecked(policy.Digits)
+	g.symbolsCheck.SetChecked(policy.Symbols)
+	g.ambiguousCheck.SetChecked(policy.ExcludeAmbiguous)
+	g.updating = false
+
+	g.policy = policy
+	g.Regenerate()
+}
+
+// Regenerate creates a new password for the preview
+func (g *Generator) Regenerate() {
+	g.lengthLabel.SetText(fmt.Sprint(g.policy.Length))
+	g.wordsLabel.SetText(fmt.Sprint(g.policy.Words))
+	if g.policy.Mode == ModePassphrase {
+		// Whole words are easy to tell apart, so look-alikes only matter for characters
+		g.lengthRow.Hide()
+		g.wordsRow.Show()
+		g.ambiguousCheck.Disable()
+	} else {
+		g.lengthRow.Show()
+		g.wordsRow.Hide()
+		g.ambiguousCheck.Enable()
+	}
+
+	password, err := Generate(g.policy)
+	if err != nil {
+		g.password = ""
+		g.preview.SetText("")
+		g.strength.SetText(err.Error())
+		return
+	}
+	g.password = password
+	g.preview.SetText(password)
+	g.strength.SetText(fmt.Sprintf("Strength: about %.0f bits", g.policy.Entropy()))
+}
+
+// changed reads the policy back from the controls after user input
+func (g *Generator) changed() {
+	if g.updating {
+		return
+	}
+	g.policy = Policy{
+		Mode:             g.modeSelect.Selected,
+		Length:           int(g.lengthSlider.Value),
+		Lowercase:        g.lowercaseCheck.Checked,
+		Uppercase:        g.uppercaseCheck.Checked,
+		Digits:           g.digitsCheck.Checked,
+		Symbols:          g.symbolsCheck.Checked,
+		ExcludeAmbiguous: g.ambiguousCheck.Checked,
+		Words:            int(g.wordsSlider.Value),
+		Separator:        g.separatorEntry.Text,
+	}
+	g.Regenerate()
+	if g.OnChanged != nil {
+		g.OnChanged(g.policy)
+	}
+}
@@ -0,0 +1,308 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Generation modes
+const (
+	ModeRandom        = "random"        // Random characters from the enabled classes
+	ModePassphrase    = "passphrase"    // Diceware style words from the EFF word list
+	ModePronounceable = "pronounceable" // Alternating consonants and vowels
+)
+
+// Character classes
+const (
+	lowercaseChars = "abcdefghijklmnopqrstuvwxyz"
+	uppercaseChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars     = "0123456789"
+	symbolChars    = "!@#$%^&*()-_=+[]{};:,.<>/?~"
+	consonants     = "bcdfghjklmnpqrstvwxz"
+	vowels         = "aeiouy"
+)
+
+// ambiguousChars look alike in many fonts
+const ambiguousChars = "Il1O0o"
+
+// Limits of a policy, shared by Validate and the generator's sliders
+const (
+	MaxLength = 128 // Characters in a random or pronounceable password
+	MaxWords  = 16  // Words in a passphrase
+)
+
+// Policy describes how passwords are generated
+type Policy struct {
+	Mode             string `json:"mode"`
+	Length           int    `json:"length"` // Characters for random and pronounceable passwords
+	Lowercase        bool   `json:"lowercase"`
+	Uppercase        bool   `json:"uppercase"`
+	Digits           bool   `json:"digits"`
+	Symbols          bool   `json:"symbols"`
+	ExcludeAmbiguous bool   `json:"exclude_ambiguous"`
+	Words            int    `json:"words"`     // Number of words in a passphrase
+	Separator        string `json:"separator"` // Put between passphrase words
+}
+
+// DefaultPolicy returns the policy used when nothing else is configured
+func DefaultPolicy() Policy {
+	return Policy{
+		Mode:             ModeRandom,
+		Length:           20,
+		Lowercase:        true,
+		Uppercase:        true,
+		Digits:           true,
+		Symbols:          true,
+		ExcludeAmbiguous: false,
+		Words:            6,
+		Separator:        "-",
+	}
+}
+
+// GetAvailableModes returns the names of all generation modes
+func GetAvailableModes() []string {
+	return []string{ModeRandom, ModePassphrase, ModePronounceable}
+}
+
+// Validate checks that the policy can generate a password
+func (p Policy) Validate() error {
+	switch p.Mode {
+	case ModeRandom:
+		if len(p.classes()) == 0 {
+			return errors.New("at least one character class must be enabled")
+		}
+		if p.Length < len(p.classes()) {
+			return fmt.Errorf("length must be at least %d to include every character class", len(p.classes()))
+		}
+	case ModePronounceable:
+		if p.Length < 4 {
+			return errors.New("length must be at least 4")
+		}
+	case ModePassphrase:
+		if p.Words < 1 {
+			return errors.New("a passphrase needs at least one word")
+		}
+	default:
+		return fmt.Errorf("unknown generation mode %q", p.Mode)
+	}
+	if p.Length > MaxLength || p.Words > MaxWords {
+		return fmt.Errorf("password is too long, the limit is %d characters or %d words", MaxLength, MaxWords)
+	}
+	return nil
+}
+
+// Generate creates a new password following the policy
+func Generate(p Policy) (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+
+	switch p.Mode {
+	case ModePassphrase:
+		return generatePassphrase(p)
+	case ModePronounceable:
+		return generatePronounceable(p)
+	default:
+		return generateRandom(p)
+	}
+}
+
+// Entropy estimates the strength of generated passwords in bits
+func (p Policy) Entropy() float64 {
+	if p.Validate() != nil {
+		return 0
+	}
+
+	switch p.Mode {
+	case ModePassphrase:
+		bits := float64(p.Words) * math.Log2(float64(len(Words())))
+		if p.Digits {
+			bits += math.Log2(float64(len(digitChars)) * float64(p.Words))
+		}
+		return bits
+	case ModePronounceable:
+		bits := 0.0
+		for i := 0; i < p.letterCount(); i++ {
+			if i%2 == 0 {
+				bits += math.Log2(float64(len(p.filter(consonants))))
+			} else {
+				bits += math.Log2(float64(len(p.filter(vowels))))
+			}
+		}
+		if p.Digits {
+			bits += math.Log2(float64(len(p.filter(digitChars))))
+		}
+		if p.Symbols {
+			bits += math.Log2(float64(len(symbolChars)))
+		}
+		return bits
+	default:
+		return float64(p.Length) * math.Log2(float64(len(strings.Join(p.classes(), ""))))
+	}
+}
+
+// classes returns the enabled character classes
+func (p Policy) classes() []string {
+	var classes []string
+	for _, class := range []struct {
+		enabled bool
+		chars   string
+	}{
+		{p.Lowercase, lowercaseChars},
+		{p.Uppercase, uppercaseChars},
+		{p.Digits, digitChars},
+		{p.Symbols, symbolChars},
+	} {
+		if class.enabled {
+			classes = append(classes, p.filter(class.chars))
+		}
+	}
+	return classes
+}
+
+// filter removes ambiguous characters when the policy asks for it
+func (p Policy) filter(chars string) string {
+	if !p.ExcludeAmbiguous {
+		return chars
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(ambiguousChars, r) {
+			return -1
+		}
+		return r
+	}, chars)
+}
+
+// letterCount returns the number of letters in a pronounceable password
+func (p Policy) letterCount() int {
+	count := p.Length
+	if p.Digits {
+		count--
+	}
+	if p.Symbols {
+		count--
+	}
+	return count
+}
+
+// randomIndex returns a uniformly distributed random number in [0, n)
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read random data: %w", err)
+	}
+	return int(i.Int64()), nil
+}
+
+// randomChar picks one character from chars
+func randomChar(chars string) (byte, error) {
+	i, err := randomIndex(len(chars))
+	if err != nil {
+		return 0, err
+	}
+	return chars[i], nil
+}
+
+// generateRandom picks characters from all enabled classes, with at least one from each
+func generateRandom(p Policy) (string, error) {
+	classes := p.classes()
+	pool := strings.Join(classes, "")
+
+	password := make([]byte, 0, p.Length)
+	for _, class := range classes {
+		c, err := randomChar(class)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	for len(password) < p.Length {
+		c, err := randomChar(pool)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	// Shuffle so the guaranteed characters are not always in front
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+// generatePassphrase joins random words from the EFF word list
+func generatePassphrase(p Policy) (string, error) {
+	wordList := Words()
+	words := make([]string, p.Words)
+	for i := range words {
+		n, err := randomIndex(len(wordList))
+		if err != nil {
+			return "", err
+		}
+		words[i] = wordList[n]
+		if p.Uppercase {
+			words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+		}
+	}
+
+	if p.Digits {
+		// Append a digit to one of the words
+		i, err := randomIndex(len(words))
+		if err != nil {
+			return "", err
+		}
+		d, err := randomChar(digitChars)
+		if err != nil {
+			return "", err
+		}
+		words[i] += string(d)
+	}
+	return strings.Join(words, p.Separator), nil
+}
+
+// generatePronounceable alternates consonants and vowels, followed by an optional
+// digit and symbol
+func generatePronounceable(p Policy) (string, error) {
+	password := make([]byte, 0, p.Length)
+	for i := 0; i < p.letterCount(); i++ {
+		chars := p.filter(consonants)
+		if i%2 == 1 {
+			chars = p.filter(vowels)
+		}
+		c, err := randomChar(chars)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	if p.Uppercase {
+		password[0] = strings.ToUpper(string(password[0]))[0]
+	}
+
+	for _, extra := range []struct {
+		enabled bool
+		chars   string
+	}{
+		{p.Digits, p.filter(digitChars)},
+		{p.Symbols, symbolChars},
+	} {
+		if !extra.enabled {
+			continue
+		}
+		c, err := randomChar(extra.chars)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	return string(password), nil
+}
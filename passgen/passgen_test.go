@@ -0,0 +1,141 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateRandom(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.Length = 32
+
+	for i := 0; i < 50; i++ {
+		password, err := Generate(policy)
+		require.NoError(t, err)
+		assert.Len(t, password, 32)
+
+		// Every enabled class is present
+		assert.True(t, strings.ContainsAny(password, lowercaseChars), password)
+		assert.True(t, strings.ContainsAny(password, uppercaseChars), password)
+		assert.True(t, strings.ContainsAny(password, digitChars), password)
+		assert.True(t, strings.ContainsAny(password, symbolChars), password)
+	}
+}
+
+func TestGenerateRandomClasses(t *testing.T) {
+	policy := Policy{Mode: ModeRandom, Length: 64, Digits: true}
+	password, err := Generate(policy)
+	require.NoError(t, err)
+	assert.Len(t, password, 64)
+	assert.Empty(t, strings.Trim(password, digitChars))
+}
+
+func TestGenerateExcludeAmbiguous(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.Length = MaxLength
+	policy.ExcludeAmbiguous = true
+
+	for _, mode := range GetAvailableModes() {
+		policy.Mode = mode
+		for i := 0; i < 20; i++ {
+			password, err := Generate(policy)
+			require.NoError(t, err)
+			if mode != ModePassphrase {
+				assert.False(t, strings.ContainsAny(password, ambiguousChars), password)
+			}
+		}
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	policy := Policy{Mode: ModePassphrase, Words: 5, Separator: " "}
+	password, err := Generate(policy)
+	require.NoError(t, err)
+
+	words := strings.Split(password, " ")
+	require.Len(t, words, 5)
+	for _, word := range words {
+		assert.Contains(t, Words(), word)
+	}
+
+	policy.Uppercase = true
+	policy.Digits = true
+	policy.Separator = "-"
+	password, err = Generate(policy)
+	require.NoError(t, err)
+	assert.True(t, strings.ContainsAny(password, digitChars), password)
+	assert.Equal(t, strings.ToUpper(password[:1]), password[:1])
+}
+
+func TestGeneratePronounceable(t *testing.T) {
+	policy := Policy{Mode: ModePronounceable, Length: 12}
+	password, err := Generate(policy)
+	require.NoError(t, err)
+	require.Len(t, password, 12)
+	for i, c := range password {
+		if i%2 == 0 {
+			assert.Contains(t, consonants, string(c))
+		} else {
+			assert.Contains(t, vowels, string(c))
+		}
+	}
+
+	policy.Uppercase = true
+	policy.Digits = true
+	policy.Symbols = true
+	password, err = Generate(policy)
+	require.NoError(t, err)
+	require.Len(t, password, 12)
+	assert.Contains(t, uppercaseChars, password[:1])
+	assert.Contains(t, digitChars, password[10:11])
+	assert.Contains(t, symbolChars, password[11:])
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, DefaultPolicy().Validate())
+	assert.NoError(t, Policy{Mode: ModeRandom, Length: MaxLength, Lowercase: true}.Validate())
+	assert.NoError(t, Policy{Mode: ModePassphrase, Words: MaxWords}.Validate())
+
+	tests := []struct {
+		name   string
+		policy Policy
+	}{
+		{"no classes", Policy{Mode: ModeRandom, Length: 10}},
+		{"too short for classes", Policy{Mode: ModeRandom, Length: 2, Lowercase: true, Uppercase: true, Digits: true}},
+		{"short pronounceable", Policy{Mode: ModePronounceable, Length: 3}},
+		{"no words", Policy{Mode: ModePassphrase}},
+		{"too long", Policy{Mode: ModeRandom, Length: MaxLength + 1, Lowercase: true}},
+		{"too many words", Policy{Mode: ModePassphrase, Words: MaxWords + 1}},
+		{"unknown mode", Policy{Mode: "magic", Length: 10, Lowercase: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, tt.policy.Validate())
+			_, err := Generate(tt.policy)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	assert.InDelta(t, 20*4.7, Policy{Mode: ModeRandom, Length: 20, Lowercase: true}.Entropy(), 0.1)
+	assert.InDelta(t, 6*12.92, Policy{Mode: ModePassphrase, Words: 6}.Entropy(), 0.1)
+	assert.Greater(t, Policy{Mode: ModePronounceable, Length: 12}.Entropy(), 20.0)
+	assert.Zero(t, Policy{Mode: ModeRandom}.Entropy())
+}
+
+func TestWords(t *testing.T) {
+	assert.Len(t, Words(), 7776)
+	assert.Equal(t, "abacus", Words()[0])
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	policy := DefaultPolicy()
+	for i := 0; i < b.N; i++ {
+		_, err := Generate(policy)
+		require.NoError(b, err)
+	}
+}
@@ -0,0 +1,18 @@
+package passgen
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+// effLargeWordlist is the EFF large word list for passphrases, 7776 words
+// (https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases), CC BY 3.0
+//
+//go:embed eff_large_wordlist.txt
+var effLargeWordlist string
+
+// Words returns the word list passphrases are built from
+var Words = sync.OnceValue(func() []string {
+	return strings.Fields(effLargeWordlist)
+})
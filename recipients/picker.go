@@ -0,0 +1,106 @@
+package recipients
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Picker is a widget for choosing several GPG recipients
+type Picker struct {
+	widget.BaseWidget
+
+	// OnChanged is called whenever a recipient is added or removed
+	OnChanged func([]string)
+
+	recipients []string
+	rows       *fyne.Container
+	entry      *widget.SelectEntry
+	content    fyne.CanvasObject
+}
+
+// NewPicker creates a recipient picker prefilled with the given recipients
+func NewPicker(initial []string) *Picker {
+	p := &Picker{recipients: Normalize(initial)}
+
+	p.rows = container.NewVBox()
+	p.entry = widget.NewSelectEntry(nil)
+	p.entry.SetPlaceHolder("email or key ID")
+	p.entry.OnSubmitted = func(string) { p.addFromEntry() }
+
+	addBtn := widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), p.addFromEntry)
+	p.content = container.NewVBox(
+		p.rows,
+		container.NewBorder(nil, nil, nil, addBtn, p.entry),
+	)
+
+	p.ExtendBaseWidget(p)
+	p.rebuildRows()
+	return p
+}
+
+// CreateRenderer implements fyne.Widget
+func (p *Picker) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(p.content)
+}
+
+// Recipients returns the selected recipients, including any text still typed in the entry
+func (p *Picker) Recipients() []string {
+	return Normalize(append(append([]string{}, p.recipients...), Parse(p.entry.Text)...))
+}
+
+// SetRecipients replaces the selected recipients
+func (p *Picker) SetRecipients(ids []string) {
+	p.recipients = Normalize(ids)
+	p.rebuildRows()
+}
+
+// SetSuggestions sets the keys offered in the entry drop-down
+func (p *Picker) SetSuggestions(ids []string) {
+	p.entry.SetOptions(ids)
+}
+
+// addFromEntry moves the typed recipients into the selected list
+func (p *Picker) addFromEntry() {
+	added := Parse(p.entry.Text)
+	if len(added) == 0 {
+		return
+	}
+	p.entry.SetText("")
+	p.recipients = Normalize(append(p.recipients, added...))
+	p.rebuildRows()
+	p.changed()
+}
+
+// remove drops the recipient at index i
+func (p *Picker) remove(i int) {
+	if i < 0 || i >= len(p.recipients) {
+		return
+	}
+	p.recipients = append(p.recipients[:i:i], p.recipients[i+1:]...)
+	p.rebuildRows()
+	p.changed()
+}
+
+// rebuildRows renders one row with a remove button per recipient
+func (p *Picker) rebuildRows() {
+	p.rows.RemoveAll()
+	if len(p.recipients) == 0 {
+		p.rows.Add(widget.NewLabel("No recipients selected"))
+	}
+	for i, id := range p.recipients {
+		index := i
+		removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			p.remove(index)
+		})
+		p.rows.Add(container.NewBorder(nil, nil, nil, removeBtn, widget.NewLabel(id)))
+	}
+	p.rows.Refresh()
+}
+
+func (p *Picker) changed() {
+	if p.OnChanged != nil {
+		p.OnChanged(append([]string{}, p.recipients...))
+	}
+}
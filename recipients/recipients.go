@@ -0,0 +1,124 @@
+package recipients
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GpgIDFile is the name of the file pass uses to store the recipients of a folder
+const GpgIDFile = ".gpg-id"
+
+// ErrNoGpgID is returned when no .gpg-id file exists between an entry and the store root
+var ErrNoGpgID = errors.New("no .gpg-id file found in password store")
+
+// FindGpgIDFile walks up from dir to storeRoot and returns the nearest .gpg-id file
+func FindGpgIDFile(storeRoot, dir string) (string, error) {
+	root, err := filepath.Abs(storeRoot)
+	if err != nil {
+		return "", fmt.Errorf("error resolving store root: %w", err)
+	}
+	current, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("error resolving directory: %w", err)
+	}
+
+	// Never look outside the store, just like pass
+	if rel, err := filepath.Rel(root, current); err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("directory %s is outside of password store %s", dir, storeRoot)
+	}
+
+	for {
+		candidate := filepath.Join(current, GpgIDFile)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+		if current == root {
+			return "", ErrNoGpgID
+		}
+		current = filepath.Dir(current)
+	}
+}
+
+// ReadGpgIDFile reads every recipient listed in a .gpg-id file
+func ReadGpgIDFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", path, err)
+	}
+	defer file.Close()
+	return ReadGpgID(file, path)
+}
+
+// ReadGpgID reads every recipient from .gpg-id content, such as an old revision
+// from git. Like pass, anything after a '#' is a comment and blank lines are ignored.
+// The name identifies the content in errors.
+func ReadGpgID(r io.Reader, name string) ([]string, error) {
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line != "" {
+			ids = append(ids, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", name, err)
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%s does not contain any recipients", name)
+	}
+	return ids, nil
+}
+
+// ForDir returns the recipients that entries in dir must be encrypted to.
+// PASSWORD_STORE_KEY overrides the .gpg-id lookup, matching pass.
+func ForDir(storeRoot, dir string) ([]string, error) {
+	if key := strings.TrimSpace(os.Getenv("PASSWORD_STORE_KEY")); key != "" {
+		return strings.Fields(key), nil
+	}
+
+	gpgIDPath, err := FindGpgIDFile(storeRoot, dir)
+	if err != nil {
+		return nil, err
+	}
+	return ReadGpgIDFile(gpgIDPath)
+}
+
+// ForEntry returns the recipients for the encrypted entry at entryPath
+func ForEntry(storeRoot, entryPath string) ([]string, error) {
+	return ForDir(storeRoot, filepath.Dir(entryPath))
+}
+
+// Parse splits a user supplied recipient string on commas, semicolons and newlines.
+// Spaces are kept because user IDs such as "Jane Doe <jane@example.com>" contain them.
+func Parse(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n' || r == '\r'
+	})
+	return Normalize(fields)
+}
+
+// Normalize trims recipients and drops empty and duplicate entries, keeping their order
+func Normalize(ids []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[strings.ToLower(id)] {
+			continue
+		}
+		seen[strings.ToLower(id)] = true
+		result = append(result, id)
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package recipients
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createStore(t *testing.T) string {
+	// Create a temporary password store with nested .gpg-id files
+	tempDir, err := os.MkdirTemp("", "recipients_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "personal", "mail"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "team", "prod"), 0755))
+
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, GpgIDFile), []byte("me@example.com\n"), 0644))
+	teamIDs := "alice@example.com\n\n# ops rotation\nbob@example.com # on call\n  0xDEADBEEFCAFEBABE  \n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "team", GpgIDFile), []byte(teamIDs), 0644))
+
+	return tempDir
+}
+
+func TestFindGpgIDFile(t *testing.T) {
+	store := createStore(t)
+
+	tests := []struct {
+		name     string
+		dir      string
+		expected string
+	}{
+		{"store root", store, filepath.Join(store, GpgIDFile)},
+		{"nested without own file", filepath.Join(store, "personal", "mail"), filepath.Join(store, GpgIDFile)},
+		{"folder with own file", filepath.Join(store, "team"), filepath.Join(store, "team", GpgIDFile)},
+		{"subfolder inherits", filepath.Join(store, "team", "prod"), filepath.Join(store, "team", GpgIDFile)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := FindGpgIDFile(store, tt.dir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, found)
+		})
+	}
+}
+
+func TestFindGpgIDFileMissing(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "recipients_missing_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	_, err = FindGpgIDFile(tempDir, tempDir)
+	assert.ErrorIs(t, err, ErrNoGpgID)
+}
+
+func TestFindGpgIDFileOutsideStore(t *testing.T) {
+	store := createStore(t)
+
+	_, err := FindGpgIDFile(filepath.Join(store, "team"), filepath.Join(store, "personal"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "outside of password store")
+}
+
+func TestReadGpgIDFile(t *testing.T) {
+	store := createStore(t)
+
+	ids, err := ReadGpgIDFile(filepath.Join(store, "team", GpgIDFile))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com", "0xDEADBEEFCAFEBABE"}, ids)
+}
+
+func TestReadGpgIDFileEmpty(t *testing.T) {
+	store := createStore(t)
+	emptyPath := filepath.Join(store, "personal", GpgIDFile)
+	require.NoError(t, os.WriteFile(emptyPath, []byte("# nobody\n\n"), 0644))
+
+	_, err := ReadGpgIDFile(emptyPath)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not contain any recipients")
+}
+
+func TestReadGpgID(t *testing.T) {
+	ids, err := ReadGpgID(strings.NewReader("alice@example.com # lead\n\n  bob@example.com\n"), "HEAD:team/.gpg-id")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com"}, ids)
+
+	_, err = ReadGpgID(strings.NewReader("# nobody\n"), "HEAD:team/.gpg-id")
+	assert.ErrorContains(t, err, "HEAD:team/.gpg-id does not contain any recipients")
+}
+
+func TestForEntry(t *testing.T) {
+	store := createStore(t)
+	t.Setenv("PASSWORD_STORE_KEY", "")
+
+	ids, err := ForEntry(store, filepath.Join(store, "team", "prod", "db.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com", "0xDEADBEEFCAFEBABE"}, ids)
+
+	ids, err = ForEntry(store, filepath.Join(store, "personal", "mail", "gmail.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"me@example.com"}, ids)
+}
+
+func TestForEntryPasswordStoreKey(t *testing.T) {
+	store := createStore(t)
+	t.Setenv("PASSWORD_STORE_KEY", "override1@example.com override2@example.com")
+
+	ids, err := ForEntry(store, filepath.Join(store, "team", "db.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"override1@example.com", "override2@example.com"}, ids)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"empty", "", []string{}},
+		{"single", "alice@example.com", []string{"alice@example.com"}},
+		{"comma separated", "alice@example.com, bob@example.com", []string{"alice@example.com", "bob@example.com"}},
+		{"mixed separators", "alice@example.com;0xDEADBEEF\nbob@example.com", []string{"alice@example.com", "0xDEADBEEF", "bob@example.com"}},
+		{"user id with spaces", "Jane Doe <jane@example.com>", []string{"Jane Doe <jane@example.com>"}},
+		{"duplicates", "alice@example.com, ALICE@example.com,,", []string{"alice@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Parse(tt.input))
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	ids := Normalize([]string{" bob@example.com ", "", "alice@example.com", "bob@example.com"})
+	assert.Equal(t, []string{"bob@example.com", "alice@example.com"}, ids)
+}
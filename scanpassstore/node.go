@@ -0,0 +1,157 @@
+package scanpassstore
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// NodeKind tells directories and password entries apart
+type NodeKind int
+
+const (
+	DirNode   NodeKind = iota // A directory containing entries or other directories
+	EntryNode                 // A .gpg password entry
+)
+
+// Node is a directory or password entry in the store tree
+type Node struct {
+	Kind     NodeKind
+	Name     string    // Last path element, without .gpg for entries
+	Path     string    // Store-relative path with forward slashes, "" for the store root
+	FullPath string    // Path on disk, including .gpg for entries
+	ModTime  time.Time // Modification time on disk
+	Size     int64     // Size of the encrypted file, 0 for directories
+	Parent   *Node     // nil for the store root
+	Children []*Node   // Entries first, then subdirectories, each sorted by name
+}
+
+// IsDir reports whether the node is a directory
+func (n *Node) IsDir() bool {
+	return n.Kind == DirNode
+}
+
+// ID returns a key that is unique within the store. It is the relative path for
+// entries and the relative path plus "/" for directories, because pass allows an
+// entry and a directory with the same name side by side ("work.gpg" and "work/").
+func (n *Node) ID() string {
+	if n.IsDir() && n.Path != "" {
+		return n.Path + "/"
+	}
+	return n.Path
+}
+
+// Entries returns the entries directly inside a directory node
+func (n *Node) Entries() []*Node {
+	var entries []*Node
+	for _, child := range n.Children {
+		if !child.IsDir() {
+			entries = append(entries, child)
+		}
+	}
+	return entries
+}
+
+// Dirs returns the subdirectories directly inside a directory node
+func (n *Node) Dirs() []*Node {
+	var dirs []*Node
+	for _, child := range n.Children {
+		if child.IsDir() {
+			dirs = append(dirs, child)
+		}
+	}
+	return dirs
+}
+
+// EntryCount returns the number of entries in a directory node and all of its
+// subdirectories, or 1 for an entry
+func (n *Node) EntryCount() int {
+	if !n.IsDir() {
+		return 1
+	}
+	count := 0
+	for _, child := range n.Children {
+		count += child.EntryCount()
+	}
+	return count
+}
+
+// Walk calls fn for every node below the store root in depth-first order, parents
+// before their children. Returning fs.SkipDir from fn for a directory skips its
+// contents; any other error stops the walk and is returned.
+func (store *PasswordStore) Walk(fn func(node *Node) error) error {
+	if store.Root == nil {
+		return nil
+	}
+	err := walkChildren(store.Root, fn)
+	if err == fs.SkipDir {
+		return nil
+	}
+	return err
+}
+
+func walkChildren(dir *Node, fn func(node *Node) error) error {
+	for _, child := range dir.Children {
+		err := fn(child)
+		if err == fs.SkipDir {
+			if child.IsDir() {
+				continue
+			}
+			// Skipping at an entry skips the rest of its directory, like filepath.WalkDir
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if child.IsDir() {
+			if err := walkChildren(child, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup finds a node by relative path or ID. A trailing "/" only matches
+// directories; otherwise an entry is preferred over a directory of the same name.
+// "" and "/" return the store root.
+func (store *PasswordStore) Lookup(p string) (*Node, bool) {
+	if store.Root == nil {
+		return nil, false
+	}
+
+	wantDir := strings.HasSuffix(p, "/")
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if p == "" {
+		return store.Root, true
+	}
+
+	if !wantDir {
+		if node, ok := store.entries[p]; ok {
+			return node, true
+		}
+	}
+	node, ok := store.dirs[p]
+	return node, ok
+}
+
+// Children returns the children of the directory at the given path, or nil if
+// there is no such directory
+func (store *PasswordStore) Children(p string) []*Node {
+	node, ok := store.Lookup(p)
+	if !ok || !node.IsDir() {
+		return nil
+	}
+	return node.Children
+}
+
+// Parent returns the directory containing the node at the given path. The store
+// root has no parent.
+func (store *PasswordStore) Parent(p string) (*Node, bool) {
+	node, ok := store.Lookup(p)
+	if !ok || node.Parent == nil {
+		return nil, false
+	}
+	return node.Parent, true
+}
@@ -0,0 +1,168 @@
+package scanpassstore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStore creates and scans a store containing the given relative entry paths
+func newTestStore(t *testing.T, entries ...string) *PasswordStore {
+	tempDir, err := os.MkdirTemp("", "node_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	for _, rel := range entries {
+		filePath := filepath.Join(tempDir, filepath.FromSlash(rel)+".gpg")
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+		require.NoError(t, os.WriteFile(filePath, []byte("test content"), 0644))
+	}
+
+	store, err := ScanPasswordStore(tempDir)
+	require.NoError(t, err)
+	return store
+}
+
+func TestNodeTree(t *testing.T) {
+	store := newTestStore(t, "root1", "work/github", "work/old/github", "personal/bank")
+
+	require.NotNil(t, store.Root)
+	assert.True(t, store.Root.IsDir())
+	assert.Equal(t, "", store.Root.Path)
+	assert.Nil(t, store.Root.Parent)
+
+	node, ok := store.Lookup("work/old/github")
+	require.True(t, ok)
+	assert.Equal(t, EntryNode, node.Kind)
+	assert.Equal(t, "github", node.Name)
+	assert.Equal(t, "work/old/github", node.ID())
+	assert.Equal(t, filepath.Join(store.RootPath, "work", "old", "github.gpg"), node.FullPath)
+	assert.Equal(t, int64(len("test content")), node.Size)
+	assert.WithinDuration(t, time.Now(), node.ModTime, time.Minute)
+
+	dir, ok := store.Lookup("work/old")
+	require.True(t, ok)
+	assert.True(t, dir.IsDir())
+	assert.Equal(t, "work/old/", dir.ID())
+	assert.Same(t, dir, node.Parent)
+	assert.Zero(t, dir.Size)
+}
+
+func TestWalk(t *testing.T) {
+	store := newTestStore(t, "root1", "work/github", "work/old/github", "personal/bank")
+
+	var visited []string
+	err := store.Walk(func(node *Node) error {
+		visited = append(visited, node.ID())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"root1",
+		"personal/", "personal/bank",
+		"work/", "work/github", "work/old/", "work/old/github",
+	}, visited)
+
+	// SkipDir leaves out the contents of a directory
+	visited = nil
+	err = store.Walk(func(node *Node) error {
+		visited = append(visited, node.ID())
+		if node.Path == "work" {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"root1", "personal/", "personal/bank", "work/"}, visited)
+
+	// Other errors stop the walk
+	stop := errors.New("stop")
+	visited = nil
+	err = store.Walk(func(node *Node) error {
+		visited = append(visited, node.ID())
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, []string{"root1"}, visited)
+}
+
+func TestLookup(t *testing.T) {
+	// pass allows an entry and a directory with the same name
+	store := newTestStore(t, "work", "work/github")
+
+	root, ok := store.Lookup("")
+	require.True(t, ok)
+	assert.Same(t, store.Root, root)
+	root, ok = store.Lookup("/")
+	require.True(t, ok)
+	assert.Same(t, store.Root, root)
+
+	entry, ok := store.Lookup("work")
+	require.True(t, ok)
+	assert.False(t, entry.IsDir())
+
+	dir, ok := store.Lookup("work/")
+	require.True(t, ok)
+	assert.True(t, dir.IsDir())
+
+	_, ok = store.Lookup("missing")
+	assert.False(t, ok)
+	_, ok = store.Lookup("work/github/")
+	assert.False(t, ok)
+}
+
+func TestChildrenAndParent(t *testing.T) {
+	store := newTestStore(t, "root1", "work/github", "work/gitlab", "work/old/github")
+
+	var ids []string
+	for _, child := range store.Children("work/") {
+		ids = append(ids, child.ID())
+	}
+	// Entries come before subdirectories
+	assert.Equal(t, []string{"work/github", "work/gitlab", "work/old/"}, ids)
+	assert.Len(t, store.Children(""), 2)
+	assert.Nil(t, store.Children("root1"))
+	assert.Nil(t, store.Children("missing/"))
+
+	parent, ok := store.Parent("work/old/github")
+	require.True(t, ok)
+	assert.Equal(t, "work/old/", parent.ID())
+
+	parent, ok = store.Parent("root1")
+	require.True(t, ok)
+	assert.Same(t, store.Root, parent)
+
+	_, ok = store.Parent("")
+	assert.False(t, ok)
+	_, ok = store.Parent("missing")
+	assert.False(t, ok)
+}
+
+func TestNodeEntriesAndDirs(t *testing.T) {
+	store := newTestStore(t, "work/github", "work/old/github")
+
+	work, ok := store.Lookup("work/")
+	require.True(t, ok)
+	require.Len(t, work.Entries(), 1)
+	assert.Equal(t, "work/github", work.Entries()[0].Path)
+	require.Len(t, work.Dirs(), 1)
+	assert.Equal(t, "work/old", work.Dirs()[0].Path)
+}
+
+func TestEntryCount(t *testing.T) {
+	store := newTestStore(t, "root1", "work/github", "work/old/github", "work/old/gitlab")
+
+	assert.Equal(t, 4, store.Root.EntryCount())
+	work, ok := store.Lookup("work/")
+	require.True(t, ok)
+	assert.Equal(t, 3, work.EntryCount())
+	entry, ok := store.Lookup("root1")
+	require.True(t, ok)
+	assert.Equal(t, 1, entry.EntryCount())
+}
@@ -3,169 +3,153 @@ package scanpassstore
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
 
+// PasswordStore describes the entries and directories of a password store.
+// Entries and directories are identified by their store-relative path using
+// forward slashes and without the .gpg suffix, e.g. "work/github".
 type PasswordStore struct {
-	RootPath    string
-	RootFiles   []string
-	Directories []string
-	DirContents map[string][]string
+	RootPath string
+	Root     *Node // The store root directory, use Walk, Lookup, Children and Parent to navigate
+
+	// Flat views of the tree, kept for existing callers
+	RootFiles   []string            // Entry IDs in the store root
+	Directories []string            // Top-level directory IDs
+	DirContents map[string][]string // Maps directory ID to the IDs of its entries
 	// New fields for nested structure
-	NestedDirs map[string][]string // Maps directory path to its subdirectories
-	AllPaths   map[string]string   // Maps display name to full path
+	NestedDirs map[string][]string // Maps directory ID to the IDs of its subdirectories
+	AllPaths   map[string]string   // Maps entry ID to full path
+
+	entries map[string]*Node // Entry nodes by relative path
+	dirs    map[string]*Node // Directory nodes by relative path
 }
 
-// scanDirectory recursively scans a directory for .gpg files and subdirectories
-func scanDirectory(dirPath string, relativePath string) ([]string, []string, error) {
-	var files []string
-	var subdirs []string
+// Name returns the last element of an entry or directory ID, used for display
+func Name(id string) string {
+	return path.Base(strings.TrimSuffix(id, "/"))
+}
 
-	entries, err := os.ReadDir(dirPath)
+// scanDirectory recursively adds the .gpg files and subdirectories of dir to it.
+// Subdirectories without any entries are left out.
+func scanDirectory(dir *Node) error {
+	dirEntries, err := os.ReadDir(dir.FullPath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error reading directory %s: %w", dirPath, err)
+		return fmt.Errorf("error reading directory %s: %w", dir.FullPath, err)
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			// Recursively scan subdirectory
-			subdirPath := filepath.Join(dirPath, entry.Name())
-			subdirRelativePath := filepath.Join(relativePath, entry.Name())
+	var entries, subdirs []*Node
+	for _, dirEntry := range dirEntries {
+		info, err := dirEntry.Info()
+		if err != nil {
+			// The file vanished while scanning
+			continue
+		}
 
-			subdirFiles, subdirSubdirs, err := scanDirectory(subdirPath, subdirRelativePath)
-			if err != nil {
-				fmt.Printf("Error scanning subdirectory %s: %v\n", subdirPath, err)
+		fullPath := filepath.Join(dir.FullPath, dirEntry.Name())
+		if dirEntry.IsDir() {
+			subdir := &Node{
+				Kind:     DirNode,
+				Name:     dirEntry.Name(),
+				Path:     path.Join(dir.Path, dirEntry.Name()),
+				FullPath: fullPath,
+				ModTime:  info.ModTime(),
+				Parent:   dir,
+			}
+			if err := scanDirectory(subdir); err != nil {
+				fmt.Printf("Error scanning subdirectory %s: %v\n", fullPath, err)
 				continue
 			}
 
 			// Only include subdirectory if it has .gpg files or contains subdirectories with .gpg files
-			if len(subdirFiles) > 0 || len(subdirSubdirs) > 0 {
-				subdirs = append(subdirs, entry.Name())
-				// Don't flatten files from subdirectories into parent directory
-				// Files will be handled separately for each directory level
+			if len(subdir.Children) > 0 {
+				subdirs = append(subdirs, subdir)
 			}
-		} else if strings.HasSuffix(entry.Name(), ".gpg") {
-			fileName := strings.TrimSuffix(entry.Name(), ".gpg")
-			files = append(files, fileName)
+		} else if strings.HasSuffix(dirEntry.Name(), ".gpg") {
+			name := strings.TrimSuffix(dirEntry.Name(), ".gpg")
+			entries = append(entries, &Node{
+				Kind:     EntryNode,
+				Name:     name,
+				Path:     path.Join(dir.Path, name),
+				FullPath: fullPath,
+				ModTime:  info.ModTime(),
+				Size:     info.Size(),
+				Parent:   dir,
+			})
 		}
 	}
 
-	return files, subdirs, nil
+	dir.Children = append(entries, subdirs...)
+	return nil
 }
 
 func ScanPasswordStore(targetPath string) (*PasswordStore, error) {
-	store := &PasswordStore{
-		RootPath:    targetPath,
-		DirContents: make(map[string][]string),
-		NestedDirs:  make(map[string][]string),
-		AllPaths:    make(map[string]string),
-	}
-
-	// Get files in root directory
-	filesInRoot, err := os.ReadDir(targetPath)
+	info, err := os.Stat(targetPath)
 	if err != nil {
 		return nil, fmt.Errorf("error reading target directory: %w", err)
 	}
 
-	// Process root files
-	for _, file := range filesInRoot {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".gpg") {
-			fileName := strings.TrimSuffix(file.Name(), ".gpg")
-			store.RootFiles = append(store.RootFiles, fileName)
-			store.AllPaths[fileName] = filepath.Join(targetPath, fileName+".gpg")
-		}
+	root := &Node{
+		Kind:     DirNode,
+		FullPath: targetPath,
+		ModTime:  info.ModTime(),
 	}
-
-	// Process directories recursively
-	dirs, err := os.ReadDir(targetPath)
-	if err != nil {
+	if err := scanDirectory(root); err != nil {
 		return nil, fmt.Errorf("error reading target directory: %w", err)
 	}
 
-	for _, dir := range dirs {
-		if dir.IsDir() {
-			dirPath := filepath.Join(targetPath, dir.Name())
-
-			// Recursively scan the directory
-			files, subdirs, err := scanDirectory(dirPath, dir.Name())
-			if err != nil {
-				fmt.Printf("Error scanning directory %s: %v\n", dir.Name(), err)
-				continue
-			}
-
-			// Only include directory if it has .gpg files or contains subdirectories with .gpg files
-			if len(files) > 0 || len(subdirs) > 0 {
-				store.Directories = append(store.Directories, dir.Name())
-				store.DirContents[dir.Name()] = files
-				store.NestedDirs[dir.Name()] = subdirs
-
-				// Store full paths for files in this directory (not subdirectories)
-				for _, file := range files {
-					filePath := filepath.Join(dirPath, file+".gpg")
-					store.AllPaths[file] = filePath
-				}
+	return newPasswordStore(targetPath, root), nil
+}
 
-				// Recursively process subdirectories to build complete structure
-				store.processSubdirectories(dirPath, dir.Name(), subdirs)
-			}
-		}
+// newPasswordStore indexes a scanned tree and fills in the flat views
+func newPasswordStore(targetPath string, root *Node) *PasswordStore {
+	store := &PasswordStore{
+		RootPath: targetPath,
+		Root:     root,
 	}
-
-	return store, nil
+	store.reindex()
+	return store
 }
 
-// processSubdirectories recursively processes subdirectories to build the complete nested structure
-func (store *PasswordStore) processSubdirectories(parentPath string, parentRelativePath string, subdirs []string) {
-	for _, subdir := range subdirs {
-		subdirPath := filepath.Join(parentPath, subdir)
-		subdirRelativePath := filepath.Join(parentRelativePath, subdir)
-
-		// Scan this subdirectory
-		files, nestedSubdirs, err := scanDirectory(subdirPath, subdirRelativePath)
-		if err != nil {
-			fmt.Printf("Error scanning subdirectory %s: %v\n", subdirPath, err)
-			continue
-		}
-
-		// Store the subdirectory's contents
-		store.DirContents[subdirRelativePath] = files
-		store.NestedDirs[subdirRelativePath] = nestedSubdirs
-
-		// Store full paths for files in this subdirectory
-		for _, file := range files {
-			filePath := filepath.Join(subdirPath, file+".gpg")
-			store.AllPaths[file] = filePath
-		}
-
-		// Recursively process nested subdirectories
-		if len(nestedSubdirs) > 0 {
-			store.processSubdirectories(subdirPath, subdirRelativePath, nestedSubdirs)
+// reindex rebuilds the lookup maps and flat views from the tree
+func (store *PasswordStore) reindex() {
+	store.RootFiles = nil
+	store.Directories = nil
+	store.DirContents = make(map[string][]string)
+	store.NestedDirs = make(map[string][]string)
+	store.AllPaths = make(map[string]string)
+	store.entries = make(map[string]*Node)
+	store.dirs = map[string]*Node{"": store.Root}
+
+	for _, node := range store.Root.Children {
+		if node.IsDir() {
+			store.Directories = append(store.Directories, node.Path)
+		} else {
+			store.RootFiles = append(store.RootFiles, node.Path)
 		}
 	}
-}
 
-// findFilePath recursively searches for a .gpg file in the directory tree
-func findFilePath(dirPath string, fileName string) string {
-	entries, err := os.ReadDir(dirPath)
-	if err != nil {
-		return ""
-	}
+	store.Walk(func(node *Node) error {
+		if !node.IsDir() {
+			store.entries[node.Path] = node
+			store.AllPaths[node.Path] = node.FullPath
+			return nil
+		}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			// Recursively search in subdirectory
-			subdirPath := filepath.Join(dirPath, entry.Name())
-			if found := findFilePath(subdirPath, fileName); found != "" {
-				return found
-			}
-		} else if strings.HasSuffix(entry.Name(), ".gpg") {
-			entryFileName := strings.TrimSuffix(entry.Name(), ".gpg")
-			if entryFileName == fileName {
-				return filepath.Join(dirPath, entry.Name())
+		store.dirs[node.Path] = node
+		entryIDs := []string{}
+		subdirIDs := []string{}
+		for _, child := range node.Children {
+			if child.IsDir() {
+				subdirIDs = append(subdirIDs, child.Path)
+			} else {
+				entryIDs = append(entryIDs, child.Path)
 			}
 		}
-	}
-
-	return ""
+		store.DirContents[node.Path] = entryIDs
+		store.NestedDirs[node.Path] = subdirIDs
+		return nil
+	})
 }
@@ -60,39 +60,39 @@ func TestScanPasswordStore(t *testing.T) {
 
 	// Verify directory contents
 	assert.Len(t, store.DirContents["dir1"], 2)
-	assert.Contains(t, store.DirContents["dir1"], "file1")
-	assert.Contains(t, store.DirContents["dir1"], "file2")
+	assert.Contains(t, store.DirContents["dir1"], "dir1/file1")
+	assert.Contains(t, store.DirContents["dir1"], "dir1/file2")
 
 	assert.Len(t, store.DirContents["dir2"], 1)
-	assert.Contains(t, store.DirContents["dir2"], "file3")
+	assert.Contains(t, store.DirContents["dir2"], "dir2/file3")
 
 	// Verify nested directories
 	assert.Len(t, store.NestedDirs["dir1"], 1)
-	assert.Contains(t, store.NestedDirs["dir1"], "subdir1")
+	assert.Contains(t, store.NestedDirs["dir1"], "dir1/subdir1")
 
 	assert.Len(t, store.NestedDirs["dir2"], 1)
-	assert.Contains(t, store.NestedDirs["dir2"], "subdir2")
+	assert.Contains(t, store.NestedDirs["dir2"], "dir2/subdir2")
 
 	// Verify nested directory contents
 	assert.Len(t, store.DirContents["dir1/subdir1"], 1)
-	assert.Contains(t, store.DirContents["dir1/subdir1"], "subfile1")
+	assert.Contains(t, store.DirContents["dir1/subdir1"], "dir1/subdir1/subfile1")
 
 	assert.Len(t, store.DirContents["dir2/subdir2"], 1)
-	assert.Contains(t, store.DirContents["dir2/subdir2"], "subfile2")
+	assert.Contains(t, store.DirContents["dir2/subdir2"], "dir2/subdir2/subfile2")
 
 	assert.Len(t, store.DirContents["dir2/subdir2/nested"], 1)
-	assert.Contains(t, store.DirContents["dir2/subdir2/nested"], "nestedfile")
+	assert.Contains(t, store.DirContents["dir2/subdir2/nested"], "dir2/subdir2/nested/nestedfile")
 
 	// Verify all paths mapping
 	expectedPaths := map[string]string{
-		"root1":      filepath.Join(tempDir, "root1.gpg"),
-		"root2":      filepath.Join(tempDir, "root2.gpg"),
-		"file1":      filepath.Join(tempDir, "dir1", "file1.gpg"),
-		"file2":      filepath.Join(tempDir, "dir1", "file2.gpg"),
-		"file3":      filepath.Join(tempDir, "dir2", "file3.gpg"),
-		"subfile1":   filepath.Join(tempDir, "dir1", "subdir1", "subfile1.gpg"),
-		"subfile2":   filepath.Join(tempDir, "dir2", "subdir2", "subfile2.gpg"),
-		"nestedfile": filepath.Join(tempDir, "dir2", "subdir2", "nested", "nestedfile.gpg"),
+		"root1":                          filepath.Join(tempDir, "root1.gpg"),
+		"root2":                          filepath.Join(tempDir, "root2.gpg"),
+		"dir1/file1":                     filepath.Join(tempDir, "dir1", "file1.gpg"),
+		"dir1/file2":                     filepath.Join(tempDir, "dir1", "file2.gpg"),
+		"dir2/file3":                     filepath.Join(tempDir, "dir2", "file3.gpg"),
+		"dir1/subdir1/subfile1":          filepath.Join(tempDir, "dir1", "subdir1", "subfile1.gpg"),
+		"dir2/subdir2/subfile2":          filepath.Join(tempDir, "dir2", "subdir2", "subfile2.gpg"),
+		"dir2/subdir2/nested/nestedfile": filepath.Join(tempDir, "dir2", "subdir2", "nested", "nestedfile.gpg"),
 	}
 
 	for expectedFile, expectedPath := range expectedPaths {
@@ -100,6 +100,41 @@ func TestScanPasswordStore(t *testing.T) {
 		assert.True(t, exists, "File %s should exist in AllPaths", expectedFile)
 		assert.Equal(t, expectedPath, actualPath, "Path mismatch for file %s", expectedFile)
 	}
+	assert.Len(t, store.AllPaths, len(expectedPaths))
+}
+
+func TestScanPasswordStoreDuplicateNames(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "duplicate_names_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	// The same entry and subdirectory names in different folders
+	for _, rel := range []string{"github", "work/github", "personal/github", "work/old/github", "personal/old/github"} {
+		filePath := filepath.Join(tempDir, filepath.FromSlash(rel)+".gpg")
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+		require.NoError(t, os.WriteFile(filePath, []byte("test content"), 0644))
+	}
+
+	store, err := ScanPasswordStore(tempDir)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"github":              filepath.Join(tempDir, "github.gpg"),
+		"work/github":         filepath.Join(tempDir, "work", "github.gpg"),
+		"personal/github":     filepath.Join(tempDir, "personal", "github.gpg"),
+		"work/old/github":     filepath.Join(tempDir, "work", "old", "github.gpg"),
+		"personal/old/github": filepath.Join(tempDir, "personal", "old", "github.gpg"),
+	}, store.AllPaths)
+	assert.Equal(t, []string{"work/old"}, store.NestedDirs["work"])
+	assert.Equal(t, []string{"personal/old"}, store.NestedDirs["personal"])
+	assert.Equal(t, []string{"work/old/github"}, store.DirContents["work/old"])
+	assert.Equal(t, []string{"personal/old/github"}, store.DirContents["personal/old"])
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "github", Name("github"))
+	assert.Equal(t, "github", Name("work/github"))
+	assert.Equal(t, "old", Name("work/old"))
 }
 
 func TestScanPasswordStoreEmptyDirectory(t *testing.T) {
@@ -162,69 +197,6 @@ func TestScanPasswordStoreWithNonGpgFiles(t *testing.T) {
 	assert.Contains(t, store.RootFiles, "password2")
 }
 
-func TestFindFilePath(t *testing.T) {
-	// Create a temporary directory structure
-	tempDir, err := os.MkdirTemp("", "find_file_test")
-	require.NoError(t, err)
-	defer os.RemoveAll(tempDir)
-
-	// Create test structure
-	dirs := []string{"dir1", "dir1/subdir1", "dir2"}
-	for _, dir := range dirs {
-		dirPath := filepath.Join(tempDir, dir)
-		err := os.MkdirAll(dirPath, 0755)
-		require.NoError(t, err)
-	}
-
-	// Create test files
-	files := map[string]string{
-		"dir1":         "file1.gpg",
-		"dir1/subdir1": "file2.gpg",
-		"dir2":         "file3.gpg",
-	}
-
-	for dir, file := range files {
-		filePath := filepath.Join(tempDir, dir, file)
-		err := os.WriteFile(filePath, []byte("test content"), 0644)
-		require.NoError(t, err)
-	}
-
-	// Test finding files
-	tests := []struct {
-		name     string
-		fileName string
-		expected string
-	}{
-		{
-			name:     "find file1",
-			fileName: "file1",
-			expected: filepath.Join(tempDir, "dir1", "file1.gpg"),
-		},
-		{
-			name:     "find file2",
-			fileName: "file2",
-			expected: filepath.Join(tempDir, "dir1", "subdir1", "file2.gpg"),
-		},
-		{
-			name:     "find file3",
-			fileName: "file3",
-			expected: filepath.Join(tempDir, "dir2", "file3.gpg"),
-		},
-		{
-			name:     "find non-existent file",
-			fileName: "nonexistent",
-			expected: "",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := findFilePath(tempDir, tt.fileName)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
 func TestPasswordStoreStructure(t *testing.T) {
 	// Test PasswordStore struct creation
 	store := &PasswordStore{
@@ -0,0 +1,226 @@
+package scanpassstore
+
+import (
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// EventOp describes what happened to an entry or directory
+type EventOp int
+
+const (
+	Added    EventOp = iota // A new entry or directory appeared
+	Removed                 // An entry or directory disappeared
+	Renamed                 // An entry or directory moved from OldPath to Path
+	Modified                // The content of an entry changed
+)
+
+// Event reports a change to the store. Only the topmost node of a changed
+// subtree is reported: adding a directory does not report the entries inside it.
+type Event struct {
+	Op      EventOp
+	Path    string // Relative path after the change, or of the removed node
+	OldPath string // Relative path before a rename
+	IsDir   bool
+}
+
+// ID returns the node ID after the change, see Node.ID
+func (e Event) ID() string {
+	return nodeID(e.Path, e.IsDir)
+}
+
+// OldID returns the node ID before a rename, see Node.ID
+func (e Event) OldID() string {
+	return nodeID(e.OldPath, e.IsDir)
+}
+
+// nodeID builds the ID of a node from its relative path
+func nodeID(p string, isDir bool) string {
+	if isDir && p != "" {
+		return p + "/"
+	}
+	return p
+}
+
+// parentID returns the ID of the directory containing the node at p
+func parentID(p string) string {
+	dir := path.Dir(p)
+	if dir == "." {
+		return ""
+	}
+	return dir + "/"
+}
+
+// Update rescans the given directories, which are relative paths such as the ones
+// sent by a Watcher, and returns what changed. Directories that no longer exist are
+// rescanned from their nearest remaining parent. Update must not be called
+// concurrently with other uses of the store.
+func (store *PasswordStore) Update(dirs ...string) []Event {
+	oldNodes := store.nodesByID()
+
+	for _, dir := range topmostDirs(dirs) {
+		store.rescan(dir)
+	}
+	store.reindex()
+
+	return diffNodes(oldNodes, store.nodesByID())
+}
+
+// nodesByID returns every node below the root keyed by its ID
+func (store *PasswordStore) nodesByID() map[string]*Node {
+	nodes := make(map[string]*Node)
+	store.Walk(func(node *Node) error {
+		nodes[node.ID()] = node
+		return nil
+	})
+	return nodes
+}
+
+// topmostDirs cleans a list of relative directories and drops those below another one
+func topmostDirs(dirs []string) []string {
+	var cleaned []string
+	for _, dir := range dirs {
+		cleaned = append(cleaned, strings.Trim(path.Clean("/"+dir), "/"))
+	}
+	sort.Strings(cleaned)
+
+	var topmost []string
+	for _, dir := range cleaned {
+		covered := false
+		for _, parent := range topmost {
+			if parent == "" || dir == parent || strings.HasPrefix(dir, parent+"/") {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			topmost = append(topmost, dir)
+		}
+	}
+	return topmost
+}
+
+// rescan replaces the children of the directory at p with a fresh scan from disk
+func (store *PasswordStore) rescan(p string) {
+	// Start from the nearest directory the store already knows about
+	node, ok := store.dirs[p]
+	for !ok {
+		p = strings.Trim(path.Dir("/"+p), "/")
+		node, ok = store.dirs[p]
+	}
+
+	for {
+		fresh := &Node{
+			Kind:     DirNode,
+			Name:     node.Name,
+			Path:     node.Path,
+			FullPath: node.FullPath,
+			Parent:   node.Parent,
+		}
+		info, err := os.Stat(node.FullPath)
+		if err == nil {
+			err = scanDirectory(fresh)
+		}
+
+		// A removed or emptied directory disappears from its parent, so rescan that instead
+		if (err != nil || len(fresh.Children) == 0) && node.Parent != nil {
+			node = node.Parent
+			continue
+		}
+
+		if err == nil {
+			node.ModTime = info.ModTime()
+		}
+		node.Children = fresh.Children
+		for _, child := range node.Children {
+			child.Parent = node
+		}
+		return
+	}
+}
+
+// diffNodes compares two snapshots of the tree and reports the topmost changes
+func diffNodes(oldNodes, newNodes map[string]*Node) []Event {
+	var removed, added []*Node
+	var events []Event
+
+	for id, oldNode := range oldNodes {
+		newNode, ok := newNodes[id]
+		if !ok {
+			if _, parentKept := newNodes[parentID(oldNode.Path)]; parentKept || parentID(oldNode.Path) == "" {
+				removed = append(removed, oldNode)
+			}
+			continue
+		}
+		if !oldNode.IsDir() && (!oldNode.ModTime.Equal(newNode.ModTime) || oldNode.Size != newNode.Size) {
+			events = append(events, Event{Op: Modified, Path: newNode.Path})
+		}
+	}
+	for id, newNode := range newNodes {
+		if _, ok := oldNodes[id]; !ok {
+			if _, parentExisted := oldNodes[parentID(newNode.Path)]; parentExisted || parentID(newNode.Path) == "" {
+				added = append(added, newNode)
+			}
+		}
+	}
+
+	// A rename keeps the modification time and size, so pair up nodes that only match each other
+	paired := make(map[*Node]bool)
+	for _, oldNode := range removed {
+		var match *Node
+		matches := 0
+		for _, newNode := range added {
+			if !paired[newNode] && sameNode(oldNode, newNode) {
+				match = newNode
+				matches++
+			}
+		}
+		if matches == 1 {
+			paired[oldNode] = true
+			paired[match] = true
+			events = append(events, Event{Op: Renamed, Path: match.Path, OldPath: oldNode.Path, IsDir: match.IsDir()})
+		}
+	}
+
+	for _, node := range removed {
+		if !paired[node] {
+			events = append(events, Event{Op: Removed, Path: node.Path, IsDir: node.IsDir()})
+		}
+	}
+	for _, node := range added {
+		if !paired[node] {
+			events = append(events, Event{Op: Added, Path: node.Path, IsDir: node.IsDir()})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Path != events[j].Path {
+			return events[i].Path < events[j].Path
+		}
+		return events[i].Op < events[j].Op
+	})
+	return events
+}
+
+// sameNode reports whether two nodes look like the same file or directory under different paths
+func sameNode(a, b *Node) bool {
+	if a.Kind != b.Kind || !a.ModTime.Equal(b.ModTime) || a.Size != b.Size {
+		return false
+	}
+	if !a.IsDir() {
+		return true
+	}
+	return slices.Equal(childNames(a), childNames(b))
+}
+
+// childNames returns the names of the children of a directory node
+func childNames(dir *Node) []string {
+	names := make([]string, 0, len(dir.Children))
+	for _, child := range dir.Children {
+		names = append(names, nodeID(child.Name, child.IsDir()))
+	}
+	return names
+}
@@ -0,0 +1,95 @@
+package scanpassstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestEntry creates an entry file below the store root
+func writeTestEntry(t *testing.T, store *PasswordStore, rel, content string) {
+	filePath := filepath.Join(store.RootPath, filepath.FromSlash(rel)+".gpg")
+	require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0644))
+}
+
+func TestUpdateAddAndRemove(t *testing.T) {
+	store := newTestStore(t, "root1", "work/github")
+
+	writeTestEntry(t, store, "work/gitlab", "new")
+	writeTestEntry(t, store, "personal/old/bank", "new")
+	require.NoError(t, os.Remove(filepath.Join(store.RootPath, "root1.gpg")))
+
+	events := store.Update("", "work")
+	assert.Equal(t, []Event{
+		// Only the topmost new directory is reported
+		{Op: Added, Path: "personal", IsDir: true},
+		{Op: Removed, Path: "root1"},
+		{Op: Added, Path: "work/gitlab"},
+	}, events)
+
+	// The tree, index and flat views follow the disk
+	_, ok := store.Lookup("personal/old/bank")
+	assert.True(t, ok)
+	_, ok = store.Lookup("root1")
+	assert.False(t, ok)
+	assert.Empty(t, store.RootFiles)
+	assert.Equal(t, []string{"personal", "work"}, store.Directories)
+	assert.Equal(t, []string{"work/github", "work/gitlab"}, store.DirContents["work"])
+	assert.Contains(t, store.AllPaths, "personal/old/bank")
+}
+
+func TestUpdateRemovedDirectory(t *testing.T) {
+	store := newTestStore(t, "root1", "work/old/github")
+
+	require.NoError(t, os.RemoveAll(filepath.Join(store.RootPath, "work", "old")))
+
+	// work is empty now, so it disappears from the root as well
+	events := store.Update("work/old")
+	assert.Equal(t, []Event{{Op: Removed, Path: "work", IsDir: true}}, events)
+	assert.Empty(t, store.Directories)
+	_, ok := store.Lookup("work/")
+	assert.False(t, ok)
+}
+
+func TestUpdateRename(t *testing.T) {
+	store := newTestStore(t, "work/github", "work/old/gitlab", "other")
+
+	require.NoError(t, os.Rename(
+		filepath.Join(store.RootPath, "work", "github.gpg"),
+		filepath.Join(store.RootPath, "work", "github-work.gpg"),
+	))
+	require.NoError(t, os.Rename(
+		filepath.Join(store.RootPath, "work", "old"),
+		filepath.Join(store.RootPath, "work", "archive"),
+	))
+
+	events := store.Update("work")
+	assert.Equal(t, []Event{
+		{Op: Renamed, Path: "work/archive", OldPath: "work/old", IsDir: true},
+		{Op: Renamed, Path: "work/github-work", OldPath: "work/github"},
+	}, events)
+	assert.Equal(t, "work/archive/", events[0].ID())
+	assert.Equal(t, "work/old/", events[0].OldID())
+}
+
+func TestUpdateModified(t *testing.T) {
+	store := newTestStore(t, "work/github")
+
+	writeTestEntry(t, store, "work/github", "changed content")
+
+	events := store.Update("work")
+	assert.Equal(t, []Event{{Op: Modified, Path: "work/github"}}, events)
+
+	// Nothing changed since the last update
+	assert.Empty(t, store.Update("work"))
+}
+
+func TestTopmostDirs(t *testing.T) {
+	assert.Equal(t, []string{"personal", "work"}, topmostDirs([]string{"work/old", "work", "personal/", "/work/old/x"}))
+	assert.Equal(t, []string{""}, topmostDirs([]string{"work", "", "personal"}))
+	assert.Equal(t, []string{"work", "workshop"}, topmostDirs([]string{"workshop", "work"}))
+}
@@ -0,0 +1,173 @@
+package scanpassstore
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long the watcher waits for more changes before reporting.
+// Commands like pass insert or git pull touch many files in quick succession.
+const watchDebounce = 200 * time.Millisecond
+
+// Watcher watches a password store directory recursively and reports which
+// directories changed. Pass them to PasswordStore.Update to patch the tree.
+type Watcher struct {
+	// Changes receives the relative paths of changed directories, "" for the store root
+	Changes chan []string
+	// Errors receives errors from the underlying file system watcher
+	Errors chan error
+
+	rootPath string
+	fsw      *fsnotify.Watcher
+	done     chan struct{}
+	stopped  chan struct{}
+	closing  sync.Once
+	closeErr error
+}
+
+// NewWatcher starts watching the password store at rootPath
+func NewWatcher(rootPath string) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		Changes:  make(chan []string),
+		Errors:   make(chan error),
+		rootPath: rootPath,
+		fsw:      fsw,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	if err := w.addRecursive(rootPath); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Close stops watching and closes the Changes and Errors channels. It is safe to
+// call more than once, also concurrently.
+func (w *Watcher) Close() error {
+	w.closing.Do(func() {
+		close(w.done)
+		w.closeErr = w.fsw.Close()
+		<-w.stopped
+	})
+	return w.closeErr
+}
+
+// addRecursive watches dir and every directory below it, except git internals
+func (w *Watcher) addRecursive(dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The directory may already be gone again
+			if p != dir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		return w.fsw.Add(p)
+	})
+}
+
+// relativeDir returns the store-relative directory containing the changed path,
+// or false if the change cannot affect the tree
+func (w *Watcher) relativeDir(event fsnotify.Event) (string, bool) {
+	rel, err := filepath.Rel(w.rootPath, event.Name)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return "", false
+	}
+
+	switch {
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		// The removed path may have been a directory, so it cannot be filtered by name
+	case event.Has(fsnotify.Create):
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			// Watch new directories before files appear in them
+			w.addRecursive(event.Name)
+		} else if !strings.HasSuffix(rel, ".gpg") {
+			return "", false
+		}
+	case event.Has(fsnotify.Write):
+		if !strings.HasSuffix(rel, ".gpg") {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+
+	dir := filepath.ToSlash(filepath.Dir(filepath.FromSlash(rel)))
+	if dir == "." {
+		dir = ""
+	}
+	return dir, true
+}
+
+// run collects changed directories and reports them once things calm down
+func (w *Watcher) run() {
+	defer close(w.stopped)
+	defer close(w.Changes)
+	defer close(w.Errors)
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if dir, ok := w.relativeDir(event); ok {
+				pending[dir] = true
+				timer.Reset(watchDebounce)
+			}
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			select {
+			case w.Errors <- err:
+			case <-w.done:
+				return
+			}
+		case <-timer.C:
+			dirs := make([]string, 0, len(pending))
+			for dir := range pending {
+				dirs = append(dirs, dir)
+			}
+			sort.Strings(dirs)
+			pending = make(map[string]bool)
+
+			select {
+			case w.Changes <- dirs:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
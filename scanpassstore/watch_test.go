@@ -0,0 +1,101 @@
+package scanpassstore
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForChanges returns the next batch of changed directories from a watcher
+func waitForChanges(t *testing.T, w *Watcher) []string {
+	select {
+	case dirs := <-w.Changes:
+		return dirs
+	case err := <-w.Errors:
+		t.Fatalf("watcher error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for changes")
+	}
+	return nil
+}
+
+func TestWatcher(t *testing.T) {
+	store := newTestStore(t, "root1", "work/github")
+
+	w, err := NewWatcher(store.RootPath)
+	require.NoError(t, err)
+	defer w.Close()
+
+	// Several quick changes arrive as one batch
+	writeTestEntry(t, store, "work/gitlab", "new")
+	require.NoError(t, os.Remove(filepath.Join(store.RootPath, "root1.gpg")))
+
+	dirs := waitForChanges(t, w)
+	assert.Equal(t, []string{"", "work"}, dirs)
+	assert.Equal(t, []Event{
+		{Op: Removed, Path: "root1"},
+		{Op: Added, Path: "work/gitlab"},
+	}, store.Update(dirs...))
+
+	// New directories are watched too
+	require.NoError(t, os.MkdirAll(filepath.Join(store.RootPath, "personal"), 0755))
+	waitForChanges(t, w)
+	writeTestEntry(t, store, "personal/bank", "new")
+	dirs = waitForChanges(t, w)
+	assert.Contains(t, dirs, "personal")
+	assert.Equal(t, []Event{{Op: Added, Path: "personal", IsDir: true}}, store.Update(dirs...))
+}
+
+func TestWatcherIgnoresGitAndOtherFiles(t *testing.T) {
+	store := newTestStore(t, "root1")
+	require.NoError(t, os.MkdirAll(filepath.Join(store.RootPath, ".git", "objects"), 0755))
+
+	w, err := NewWatcher(store.RootPath)
+	require.NoError(t, err)
+	defer w.Close()
+
+	require.NoError(t, os.WriteFile(filepath.Join(store.RootPath, ".git", "objects", "x"), []byte("x"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(store.RootPath, "notes.txt"), []byte("x"), 0644))
+
+	select {
+	case dirs := <-w.Changes:
+		t.Fatalf("unexpected changes: %v", dirs)
+	case <-time.After(3 * watchDebounce):
+	}
+}
+
+func TestWatcherClose(t *testing.T) {
+	store := newTestStore(t, "root1")
+
+	w, err := NewWatcher(store.RootPath)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, w.Close())
+
+	_, ok := <-w.Changes
+	assert.False(t, ok)
+}
+
+func TestWatcherCloseConcurrently(t *testing.T) {
+	store := newTestStore(t, "root1")
+
+	w, err := NewWatcher(store.RootPath)
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, w.Close())
+		}()
+	}
+	wg.Wait()
+
+	_, ok := <-w.Changes
+	assert.False(t, ok)
+}
@@ -1,13 +1,18 @@
 package settings
 
 import (
+	"errors"
 	"fmt"
 	"os/user"
 	"path/filepath"
+	"strconv"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+	"main.go/crypto"
+	"main.go/passgen"
+	"main.go/recipients"
 )
 
 // ShowSettingsDialog displays the settings dialog
@@ -23,8 +28,7 @@ func ShowSettingsDialog(window fyne.Window, currentSettings *Settings, onSetting
 		}
 	}
 
-	defaultRecipientEntry := widget.NewEntry()
-	defaultRecipientEntry.SetText(currentSettings.DefaultRecipient)
+	defaultRecipientsPicker := recipients.NewPicker(currentSettings.DefaultRecipients)
 
 	autoCommitCheck := widget.NewCheck("Auto-commit changes", func(checked bool) {
 		currentSettings.AutoCommit = checked
@@ -47,23 +51,94 @@ func ShowSettingsDialog(window fyne.Window, currentSettings *Settings, onSetting
 	})
 	themeSelect.SetSelected(currentSettings.Theme)
 
+	cryptoBackendSelect := widget.NewSelect(crypto.GetAvailableBackends(), nil)
+	cryptoBackendSelect.SetSelected(currentSettings.CryptoBackend)
+
+	keyringPathEntry := widget.NewEntry()
+	keyringPathEntry.SetText(currentSettings.KeyringPath)
+	keyringPathEntry.SetPlaceHolder("Exported keyring, e.g. gpg --export-secret-keys")
+
+	clipboardTimeoutEntry := widget.NewEntry()
+	clipboardTimeoutEntry.SetText(strconv.Itoa(currentSettings.ClipboardTimeout))
+	clipboardTimeoutEntry.Validator = func(text string) error {
+		if seconds, err := strconv.Atoi(text); err != nil || seconds <= 0 {
+			return errors.New("enter a number of seconds")
+		}
+		return nil
+	}
+
+	autoLockEntry := widget.NewEntry()
+	autoLockEntry.SetText(strconv.Itoa(currentSettings.AutoLockMinutes))
+	autoLockEntry.Validator = func(text string) error {
+		if minutes, err := strconv.Atoi(text); err != nil || minutes < 0 {
+			return errors.New("enter a number of minutes, 0 never locks")
+		}
+		return nil
+	}
+
+	syncEntry := widget.NewEntry()
+	syncEntry.SetText(strconv.Itoa(currentSettings.SyncMinutes))
+	syncEntry.Validator = func(text string) error {
+		if minutes, err := strconv.Atoi(text); err != nil || minutes < 0 {
+			return errors.New("enter a number of minutes, 0 syncs only by hand")
+		}
+		return nil
+	}
+
+	lockClearsAgentCheck := widget.NewCheck("Make gpg-agent forget passphrases when locking", nil)
+	lockClearsAgentCheck.SetChecked(currentSettings.LockClearsAgent)
+
+	passwordGenerator := passgen.NewGenerator(currentSettings.PasswordPolicy)
+	folderPolicyList := newFolderPolicyList(window, currentSettings.FolderPolicies)
+
 	// Create form
 	form := &widget.Form{
 		Items: []*widget.FormItem{
 			{Text: "Password Store Path", Widget: passwordStoreEntry, HintText: "Path to your password store directory"},
-			{Text: "Default Recipient", Widget: defaultRecipientEntry, HintText: "Default GPG recipient for new files"},
-			{Text: "Auto-commit", Widget: autoCommitCheck, HintText: "Automatically commit changes when saving"},
+			{Text: "Default Recipients", Widget: defaultRecipientsPicker, HintText: "Default GPG recipients when no .gpg-id applies"},
+			{Text: "Auto-commit", Widget: autoCommitCheck, HintText: "Commit each change to git like pass does"},
+			{Text: "Background Sync", Widget: syncEntry, HintText: "Minutes between git pulls and pushes, 0 syncs only by hand"},
 			{Text: "Notifications", Widget: notificationsCheck, HintText: "Show system notifications"},
 			{Text: "Theme", Widget: themeSelect, HintText: "Application theme (applied immediately)"},
+			{Text: "Crypto Backend", Widget: cryptoBackendSelect, HintText: "gpg command or built-in OpenPGP (applied on restart)"},
+			{Text: "Keyring Path", Widget: keyringPathEntry, HintText: "Keyring file for the built-in OpenPGP backend"},
+			{Text: "Clear Clipboard", Widget: clipboardTimeoutEntry, HintText: "Seconds until copied passwords are cleared"},
+			{Text: "Auto-lock", Widget: autoLockEntry, HintText: "Idle minutes before decrypted entries are closed, 0 never locks"},
+			{Text: "Lock Agent", Widget: lockClearsAgentCheck, HintText: "Runs gpgconf --reload gpg-agent, which affects other programs too"},
+			{Text: "Password Generator", Widget: passwordGenerator, HintText: "Default policy, folders can override it when generating"},
+			{Text: "Folder Policies", Widget: folderPolicyList, HintText: "Policies for entries below a folder, the nearest folder wins"},
 		},
 		OnSubmit: func() {
+			clipboardTimeout, err := strconv.Atoi(clipboardTimeoutEntry.Text)
+			if err != nil || clipboardTimeout <= 0 {
+				clipboardTimeout = currentSettings.ClipboardTimeout
+			}
+
+			autoLockMinutes, err := strconv.Atoi(autoLockEntry.Text)
+			if err != nil || autoLockMinutes < 0 {
+				autoLockMinutes = currentSettings.AutoLockMinutes
+			}
+
+			syncMinutes, err := strconv.Atoi(syncEntry.Text)
+			if err != nil || syncMinutes < 0 {
+				syncMinutes = currentSettings.SyncMinutes
+			}
+
 			// Update settings
 			updates := map[string]interface{}{
 				"password_store_path": passwordStoreEntry.Text,
-				"default_recipient":   defaultRecipientEntry.Text,
+				"default_recipients":  defaultRecipientsPicker.Recipients(),
 				"auto_commit":         autoCommitCheck.Checked,
 				"show_notifications":  notificationsCheck.Checked,
 				"theme":               themeSelect.Selected,
+				"crypto_backend":      cryptoBackendSelect.Selected,
+				"keyring_path":        keyringPathEntry.Text,
+				"clipboard_timeout":   clipboardTimeout,
+				"auto_lock_minutes":   autoLockMinutes,
+				"lock_clears_agent":   lockClearsAgentCheck.Checked,
+				"sync_minutes":        syncMinutes,
+				"password_policy":     passwordGenerator.Policy(),
+				"folder_policies":     folderPolicyList.Policies(),
 			}
 
 			if err := UpdateSettings(updates); err != nil {
@@ -73,10 +148,18 @@ func ShowSettingsDialog(window fyne.Window, currentSettings *Settings, onSetting
 
 			// Update current settings
 			currentSettings.PasswordStorePath = passwordStoreEntry.Text
-			currentSettings.DefaultRecipient = defaultRecipientEntry.Text
+			currentSettings.DefaultRecipients = defaultRecipientsPicker.Recipients()
 			currentSettings.AutoCommit = autoCommitCheck.Checked
 			currentSettings.ShowNotifications = notificationsCheck.Checked
 			currentSettings.Theme = themeSelect.Selected
+			currentSettings.CryptoBackend = cryptoBackendSelect.Selected
+			currentSettings.KeyringPath = keyringPathEntry.Text
+			currentSettings.ClipboardTimeout = clipboardTimeout
+			currentSettings.AutoLockMinutes = autoLockMinutes
+			currentSettings.LockClearsAgent = lockClearsAgentCheck.Checked
+			currentSettings.SyncMinutes = syncMinutes
+			currentSettings.PasswordPolicy = passwordGenerator.Policy()
+			currentSettings.FolderPolicies = folderPolicyList.Policies()
 
 			// Refresh UI if callback provided
 			if onSettingsChanged != nil {
@@ -88,16 +171,24 @@ func ShowSettingsDialog(window fyne.Window, currentSettings *Settings, onSetting
 		OnCancel: func() {
 			// Reset form to current settings
 			passwordStoreEntry.SetText(currentSettings.PasswordStorePath)
-			defaultRecipientEntry.SetText(currentSettings.DefaultRecipient)
+			defaultRecipientsPicker.SetRecipients(currentSettings.DefaultRecipients)
 			autoCommitCheck.SetChecked(currentSettings.AutoCommit)
 			notificationsCheck.SetChecked(currentSettings.ShowNotifications)
 			themeSelect.SetSelected(currentSettings.Theme)
+			cryptoBackendSelect.SetSelected(currentSettings.CryptoBackend)
+			keyringPathEntry.SetText(currentSettings.KeyringPath)
+			clipboardTimeoutEntry.SetText(strconv.Itoa(currentSettings.ClipboardTimeout))
+			autoLockEntry.SetText(strconv.Itoa(currentSettings.AutoLockMinutes))
+			lockClearsAgentCheck.SetChecked(currentSettings.LockClearsAgent)
+			syncEntry.SetText(strconv.Itoa(currentSettings.SyncMinutes))
+			passwordGenerator.SetPolicy(currentSettings.PasswordPolicy)
+			folderPolicyList.SetPolicies(currentSettings.FolderPolicies)
 		},
 	}
 
 	// Create dialog
 	settingsDialog := dialog.NewCustom("Settings", "Save Changes", form, window)
-	settingsDialog.Resize(fyne.NewSize(500, 400))
+	settingsDialog.Resize(fyne.NewSize(500, 600))
 	settingsDialog.Show()
 }
 
@@ -0,0 +1,86 @@
+package settings
+
+import (
+	"maps"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"main.go/passgen"
+)
+
+// folderPolicyList is a widget listing the password policies of folders with
+// buttons to edit and remove them. Folders get a policy from the generator.
+type folderPolicyList struct {
+	widget.BaseWidget
+
+	window   fyne.Window
+	policies map[string]passgen.Policy
+	rows     *fyne.Container
+}
+
+// newFolderPolicyList creates a list showing a copy of the given policies
+func newFolderPolicyList(window fyne.Window, policies map[string]passgen.Policy) *folderPolicyList {
+	l := &folderPolicyList{window: window, rows: container.NewVBox()}
+	l.ExtendBaseWidget(l)
+	l.SetPolicies(policies)
+	return l
+}
+
+// CreateRenderer implements fyne.Widget
+func (l *folderPolicyList) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(l.rows)
+}
+
+// Policies returns the policies as currently edited
+func (l *folderPolicyList) Policies() map[string]passgen.Policy {
+	return maps.Clone(l.policies)
+}
+
+// SetPolicies replaces the listed policies with a copy of policies
+func (l *folderPolicyList) SetPolicies(policies map[string]passgen.Policy) {
+	l.policies = maps.Clone(policies)
+	if l.policies == nil {
+		l.policies = map[string]passgen.Policy{}
+	}
+	l.rebuildRows()
+}
+
+// edit shows a generator for the policy of folder
+func (l *folderPolicyList) edit(folder string) {
+	generator := passgen.NewGenerator(l.policies[folder])
+	editDialog := dialog.NewCustomConfirm("Policy for "+folder+"/", "Apply", "Cancel", generator, func(apply bool) {
+		if apply {
+			l.policies[folder] = generator.Policy()
+		}
+	}, l.window)
+	editDialog.Resize(fyne.NewSize(500, 400))
+	editDialog.Show()
+}
+
+// remove drops the policy of folder, so its entries use the default policy again
+func (l *folderPolicyList) remove(folder string) {
+	delete(l.policies, folder)
+	l.rebuildRows()
+}
+
+// rebuildRows renders one row with edit and remove buttons per folder
+func (l *folderPolicyList) rebuildRows() {
+	l.rows.RemoveAll()
+	if len(l.policies) == 0 {
+		l.rows.Add(widget.NewLabel("No folder policies, save one from the generator"))
+	}
+	for _, folder := range slices.Sorted(maps.Keys(l.policies)) {
+		editBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+			l.edit(folder)
+		})
+		removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			l.remove(folder)
+		})
+		l.rows.Add(container.NewBorder(nil, nil, nil, container.NewHBox(editBtn, removeBtn), widget.NewLabel(folder+"/")))
+	}
+	l.rows.Refresh()
+}
@@ -4,32 +4,66 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+
+	"main.go/passgen"
+	"main.go/recipients"
 )
 
 // Settings represents the application configuration
 type Settings struct {
-	PasswordStorePath string  `json:"password_store_path"`
-	DefaultRecipient  string  `json:"default_recipient"`
-	AutoCommit        bool    `json:"auto_commit"`
-	ShowNotifications bool    `json:"show_notifications"`
-	Theme             string  `json:"theme"`
-	WindowWidth       int     `json:"window_width"`
-	WindowHeight      int     `json:"window_height"`
-	SplitOffset       float64 `json:"split_offset"`
+	PasswordStorePath string   `json:"password_store_path"`
+	DefaultRecipients []string `json:"default_recipients"`
+	AutoCommit        bool     `json:"auto_commit"`
+	ShowNotifications bool     `json:"show_notifications"`
+	Theme             string   `json:"theme"`
+	WindowWidth       int      `json:"window_width"`
+	WindowHeight      int      `json:"window_height"`
+	SplitOffset       float64  `json:"split_offset"`
+	CryptoBackend     string   `json:"crypto_backend"`
+	KeyringPath       string   `json:"keyring_path"`
+	ClipboardTimeout  int      `json:"clipboard_timeout"` // Seconds until copied secrets are cleared
+	AutoLockMinutes   int      `json:"auto_lock_minutes"` // Idle minutes before decrypted entries are closed, 0 never locks
+	LockClearsAgent   bool     `json:"lock_clears_agent"` // Also make gpg-agent forget cached passphrases when locking
+	SyncMinutes       int      `json:"sync_minutes"`      // Minutes between background git syncs, 0 syncs only by hand
+
+	// PasswordPolicy is used for generated passwords unless a folder has its own policy
+	PasswordPolicy passgen.Policy `json:"password_policy"`
+	// FolderPolicies maps store-relative folders such as "banking" to their policy
+	FolderPolicies map[string]passgen.Policy `json:"folder_policies"`
+
+	// DefaultRecipient is the legacy single recipient, migrated into DefaultRecipients on load
+	DefaultRecipient string `json:"default_recipient,omitempty"`
 }
 
+// DefaultClipboardTimeout is how many seconds copied secrets stay on the clipboard,
+// the same as PASSWORD_STORE_CLIP_TIME in pass
+const DefaultClipboardTimeout = 45
+
+// DefaultAutoLockMinutes is how long the application may be idle before it locks
+const DefaultAutoLockMinutes = 5
+
 // DefaultSettings returns the default configuration
 func DefaultSettings() *Settings {
 	return &Settings{
 		PasswordStorePath: "", // Will be set to ~/.password-store by default
-		DefaultRecipient:  "",
+		DefaultRecipients: []string{},
 		AutoCommit:        true,
 		ShowNotifications: true,
 		Theme:             "light",
 		WindowWidth:       800,
 		WindowHeight:      600,
 		SplitOffset:       0.3,
+		CryptoBackend:     "gpg",
+		KeyringPath:       "", // Only used by the openpgp backend
+		ClipboardTimeout:  DefaultClipboardTimeout,
+		AutoLockMinutes:   DefaultAutoLockMinutes,
+		LockClearsAgent:   false,
+		SyncMinutes:       0,
+		PasswordPolicy:    passgen.DefaultPolicy(),
+		FolderPolicies:    map[string]passgen.Policy{},
 	}
 }
 
@@ -56,11 +90,33 @@ func LoadSettings() (*Settings, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var settings Settings
+	// Fields missing from the file keep these values. Settings from before auto-lock
+	// get the default timeout, while an explicit 0 still means never lock.
+	settings := Settings{AutoLockMinutes: DefaultAutoLockMinutes}
 	if err := json.Unmarshal(data, &settings); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// Migrate the old single recipient setting
+	if len(settings.DefaultRecipients) == 0 && settings.DefaultRecipient != "" {
+		settings.DefaultRecipients = recipients.Parse(settings.DefaultRecipient)
+	}
+	settings.DefaultRecipient = ""
+	if settings.DefaultRecipients == nil {
+		settings.DefaultRecipients = []string{}
+	}
+
+	// Config files from before the password generator have no policy
+	if settings.PasswordPolicy.Mode == "" {
+		settings.PasswordPolicy = passgen.DefaultPolicy()
+	}
+	if settings.FolderPolicies == nil {
+		settings.FolderPolicies = map[string]passgen.Policy{}
+	}
+	if settings.ClipboardTimeout <= 0 {
+		settings.ClipboardTimeout = DefaultClipboardTimeout
+	}
+
 	return &settings, nil
 }
 
@@ -118,9 +174,13 @@ func UpdateSettings(updates map[string]interface{}) error {
 			if str, ok := value.(string); ok {
 				settings.PasswordStorePath = str
 			}
+		case "default_recipients":
+			if ids, ok := value.([]string); ok {
+				settings.DefaultRecipients = recipients.Normalize(ids)
+			}
 		case "default_recipient":
 			if str, ok := value.(string); ok {
-				settings.DefaultRecipient = str
+				settings.DefaultRecipients = recipients.Parse(str)
 			}
 		case "auto_commit":
 			if b, ok := value.(bool); ok {
@@ -146,9 +206,53 @@ func UpdateSettings(updates map[string]interface{}) error {
 			if f, ok := value.(float64); ok {
 				settings.SplitOffset = f
 			}
+		case "crypto_backend":
+			if str, ok := value.(string); ok {
+				settings.CryptoBackend = str
+			}
+		case "keyring_path":
+			if str, ok := value.(string); ok {
+				settings.KeyringPath = str
+			}
+		case "clipboard_timeout":
+			if i, ok := value.(int); ok && i > 0 {
+				settings.ClipboardTimeout = i
+			}
+		case "auto_lock_minutes":
+			if i, ok := value.(int); ok && i >= 0 {
+				settings.AutoLockMinutes = i
+			}
+		case "lock_clears_agent":
+			if b, ok := value.(bool); ok {
+				settings.LockClearsAgent = b
+			}
+		case "sync_minutes":
+			if i, ok := value.(int); ok && i >= 0 {
+				settings.SyncMinutes = i
+			}
+		case "password_policy":
+			if policy, ok := value.(passgen.Policy); ok {
+				settings.PasswordPolicy = policy
+			}
+		case "folder_policies":
+			if policies, ok := value.(map[string]passgen.Policy); ok {
+				settings.FolderPolicies = policies
+			}
 		}
 	}
 
 	// Save updated settings
 	return SaveSettings(settings)
 }
+
+// PolicyFor returns the password policy for a store-relative entry path such as
+// "banking/chase", along with the folder it was configured for. The policy of the
+// nearest folder wins, like .gpg-id files; "" means the default policy applies.
+func (s *Settings) PolicyFor(entryPath string) (passgen.Policy, string) {
+	for folder := path.Dir(strings.Trim(entryPath, "/")); folder != "."; folder = path.Dir(folder) {
+		if policy, ok := s.FolderPolicies[folder]; ok {
+			return policy, folder
+		}
+	}
+	return s.PasswordPolicy, ""
+}
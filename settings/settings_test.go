@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"main.go/passgen"
 )
 
 func TestDefaultSettings(t *testing.T) {
@@ -16,13 +17,21 @@ func TestDefaultSettings(t *testing.T) {
 
 	// Verify default values
 	assert.Equal(t, "", settings.PasswordStorePath)
-	assert.Equal(t, "", settings.DefaultRecipient)
+	assert.Empty(t, settings.DefaultRecipients)
 	assert.True(t, settings.AutoCommit)
 	assert.True(t, settings.ShowNotifications)
 	assert.Equal(t, "light", settings.Theme)
 	assert.Equal(t, 800, settings.WindowWidth)
 	assert.Equal(t, 600, settings.WindowHeight)
 	assert.Equal(t, 0.3, settings.SplitOffset)
+	assert.Equal(t, "gpg", settings.CryptoBackend)
+	assert.Equal(t, "", settings.KeyringPath)
+	assert.Equal(t, 45, settings.ClipboardTimeout)
+	assert.Equal(t, 5, settings.AutoLockMinutes)
+	assert.False(t, settings.LockClearsAgent)
+	assert.Equal(t, 0, settings.SyncMinutes)
+	assert.Equal(t, passgen.DefaultPolicy(), settings.PasswordPolicy)
+	assert.Empty(t, settings.FolderPolicies)
 }
 
 func TestLoadSettingsNewFile(t *testing.T) {
@@ -43,7 +52,7 @@ func TestLoadSettingsNewFile(t *testing.T) {
 
 	// Verify default settings were created
 	assert.Equal(t, "", settings.PasswordStorePath)
-	assert.Equal(t, "", settings.DefaultRecipient)
+	assert.Empty(t, settings.DefaultRecipients)
 	assert.True(t, settings.AutoCommit)
 	assert.True(t, settings.ShowNotifications)
 	assert.Equal(t, "light", settings.Theme)
@@ -71,7 +80,7 @@ func TestLoadSettingsExistingFile(t *testing.T) {
 	// Create existing settings file
 	existingSettings := &Settings{
 		PasswordStorePath: "/custom/path",
-		DefaultRecipient:  "test@example.com",
+		DefaultRecipients: []string{"test@example.com", "team@example.com"},
 		AutoCommit:        false,
 		ShowNotifications: false,
 		Theme:             "dark",
@@ -101,7 +110,7 @@ func TestLoadSettingsExistingFile(t *testing.T) {
 
 	// Verify loaded settings match
 	assert.Equal(t, "/custom/path", settings.PasswordStorePath)
-	assert.Equal(t, "test@example.com", settings.DefaultRecipient)
+	assert.Equal(t, []string{"test@example.com", "team@example.com"}, settings.DefaultRecipients)
 	assert.False(t, settings.AutoCommit)
 	assert.False(t, settings.ShowNotifications)
 	assert.Equal(t, "dark", settings.Theme)
@@ -124,7 +133,7 @@ func TestSaveSettings(t *testing.T) {
 	// Create settings to save
 	settings := &Settings{
 		PasswordStorePath: "/test/path",
-		DefaultRecipient:  "test@example.com",
+		DefaultRecipients: []string{"test@example.com", "team@example.com"},
 		AutoCommit:        true,
 		ShowNotifications: false,
 		Theme:             "dark",
@@ -152,7 +161,7 @@ func TestSaveSettings(t *testing.T) {
 
 	// Verify saved settings match
 	assert.Equal(t, "/test/path", savedSettings.PasswordStorePath)
-	assert.Equal(t, "test@example.com", savedSettings.DefaultRecipient)
+	assert.Equal(t, []string{"test@example.com", "team@example.com"}, savedSettings.DefaultRecipients)
 	assert.True(t, savedSettings.AutoCommit)
 	assert.False(t, savedSettings.ShowNotifications)
 	assert.Equal(t, "dark", savedSettings.Theme)
@@ -180,12 +189,20 @@ func TestUpdateSettings(t *testing.T) {
 	// Test updating specific settings
 	updates := map[string]interface{}{
 		"password_store_path": "/updated/path",
-		"default_recipient":   "updated@example.com",
+		"default_recipients":  []string{"updated@example.com", " second@example.com ", "updated@example.com"},
 		"auto_commit":         false,
 		"theme":               "dark",
 		"window_width":        1024,
 		"window_height":       768,
 		"split_offset":        0.6,
+		"crypto_backend":      "openpgp",
+		"keyring_path":        "/keys/secring.asc",
+		"clipboard_timeout":   10,
+		"auto_lock_minutes":   0,
+		"lock_clears_agent":   true,
+		"sync_minutes":        15,
+		"password_policy":     passgen.Policy{Mode: passgen.ModePassphrase, Words: 8, Separator: " "},
+		"folder_policies":     map[string]passgen.Policy{"banking": {Mode: passgen.ModeRandom, Length: 32, Symbols: true}},
 	}
 
 	err = UpdateSettings(updates)
@@ -198,17 +215,98 @@ func TestUpdateSettings(t *testing.T) {
 
 	// Verify updates were applied
 	assert.Equal(t, "/updated/path", updatedSettings.PasswordStorePath)
-	assert.Equal(t, "updated@example.com", updatedSettings.DefaultRecipient)
+	assert.Equal(t, []string{"updated@example.com", "second@example.com"}, updatedSettings.DefaultRecipients)
 	assert.False(t, updatedSettings.AutoCommit)
 	assert.Equal(t, "dark", updatedSettings.Theme)
 	assert.Equal(t, 1024, updatedSettings.WindowWidth)
 	assert.Equal(t, 768, updatedSettings.WindowHeight)
 	assert.Equal(t, 0.6, updatedSettings.SplitOffset)
+	assert.Equal(t, "openpgp", updatedSettings.CryptoBackend)
+	assert.Equal(t, "/keys/secring.asc", updatedSettings.KeyringPath)
+	assert.Equal(t, 10, updatedSettings.ClipboardTimeout)
+	assert.Equal(t, 0, updatedSettings.AutoLockMinutes)
+	assert.True(t, updatedSettings.LockClearsAgent)
+	assert.Equal(t, 15, updatedSettings.SyncMinutes)
+	assert.Equal(t, passgen.Policy{Mode: passgen.ModePassphrase, Words: 8, Separator: " "}, updatedSettings.PasswordPolicy)
+	assert.Equal(t, 32, updatedSettings.FolderPolicies["banking"].Length)
 
 	// Verify unchanged settings
 	assert.True(t, updatedSettings.ShowNotifications) // Should remain unchanged
 }
 
+func TestLoadSettingsMigratesDefaultRecipient(t *testing.T) {
+	// Create a temporary directory for testing
+	tempDir, err := os.MkdirTemp("", "settings_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	// Set environment variable to override config path
+	originalHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", originalHome)
+	os.Setenv("HOME", tempDir)
+
+	configPath, err := getConfigPath()
+	require.NoError(t, err)
+	err = os.MkdirAll(filepath.Dir(configPath), 0755)
+	require.NoError(t, err)
+
+	// Write a settings file from before multi-recipient support
+	legacy := `{"default_recipient": "alice@example.com, bob@example.com", "theme": "dark"}`
+	err = os.WriteFile(configPath, []byte(legacy), 0644)
+	require.NoError(t, err)
+
+	settings, err := LoadSettings()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com"}, settings.DefaultRecipients)
+	assert.Empty(t, settings.DefaultRecipient)
+	assert.Equal(t, "dark", settings.Theme)
+
+	// Settings from before the password generator get the default policy
+	assert.Equal(t, passgen.DefaultPolicy(), settings.PasswordPolicy)
+	assert.NotNil(t, settings.FolderPolicies)
+
+	// Settings from before clipboard clearing get the pass default
+	assert.Equal(t, DefaultClipboardTimeout, settings.ClipboardTimeout)
+
+	// Settings from before auto-lock get the default timeout
+	assert.Equal(t, DefaultAutoLockMinutes, settings.AutoLockMinutes)
+
+	// An explicit 0 still turns auto-lock off
+	err = os.WriteFile(configPath, []byte(`{"theme": "dark", "auto_lock_minutes": 0}`), 0644)
+	require.NoError(t, err)
+	settings, err = LoadSettings()
+	require.NoError(t, err)
+	assert.Equal(t, 0, settings.AutoLockMinutes)
+}
+
+func TestPolicyFor(t *testing.T) {
+	settings := DefaultSettings()
+	banking := passgen.Policy{Mode: passgen.ModeRandom, Length: 32, Symbols: true}
+	pins := passgen.Policy{Mode: passgen.ModeRandom, Length: 6, Digits: true}
+	settings.FolderPolicies["banking"] = banking
+	settings.FolderPolicies["banking/cards"] = pins
+
+	policy, folder := settings.PolicyFor("banking/chase")
+	assert.Equal(t, banking, policy)
+	assert.Equal(t, "banking", folder)
+
+	// The nearest folder wins
+	policy, folder = settings.PolicyFor("banking/cards/visa")
+	assert.Equal(t, pins, policy)
+	assert.Equal(t, "banking/cards", folder)
+
+	policy, folder = settings.PolicyFor("banking/online/portal")
+	assert.Equal(t, banking, policy)
+	assert.Equal(t, "banking", folder)
+
+	// Everything else uses the default policy
+	for _, entry := range []string{"github", "work/github", "bankingfoo/x", ""} {
+		policy, folder = settings.PolicyFor(entry)
+		assert.Equal(t, settings.PasswordPolicy, policy, entry)
+		assert.Equal(t, "", folder, entry)
+	}
+}
+
 func TestUpdateSettingsInvalidKey(t *testing.T) {
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "settings_test")
@@ -0,0 +1,149 @@
+package storeops
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"main.go/gitrepo"
+	"main.go/recipients"
+)
+
+// ErrNotStoreFile is returned when committing a file that is neither an entry nor
+// a .gpg-id file without allowing other files
+var ErrNotStoreFile = errors.New("only entries and .gpg-id files are committed unless other files are allowed")
+
+// IsStoreFile reports whether a store-relative path is an entry or a .gpg-id
+// file, the only files pass itself commits
+func IsStoreFile(p string) bool {
+	return path.Ext(p) == ".gpg" || path.Base(p) == ".gpg-id"
+}
+
+// CommitFiles commits exactly the given changed files with message. Changes that
+// were staged before for other files stay staged but are not committed. Files other
+// than entries and .gpg-id files are refused unless allowOther is set, so editor
+// swap files and stray plaintext do not end up in the history.
+func CommitFiles(repo gitrepo.Repository, message string, paths []string, allowOther bool) error {
+	if !allowOther {
+		for _, p := range paths {
+			if !IsStoreFile(p) {
+				return fmt.Errorf("cannot commit %s: %w", p, ErrNotStoreFile)
+			}
+		}
+	}
+	return repo.CommitPaths(message, paths...)
+}
+
+// operation is a change to a file described for a commit message
+type operation struct {
+	verb   string // Lower-case verb for combined messages, such as "edit"
+	object string // What was changed, such as an entry name
+}
+
+// CommitMessage suggests a commit message for the given changed files, worded
+// like pass: a single change gets the message pass would use, such as
+// "Edit password for work/github using gpg_viewer.", and several changes are
+// listed by kind, such as "Add work/gitlab; edit work/github, personal/mail.".
+// .gpg-id files are read from the store in root for their recipients.
+func CommitMessage(root string, files []gitrepo.FileStatus) string {
+	switch len(files) {
+	case 0:
+		return ""
+	case 1:
+		return singleCommitMessage(root, files[0])
+	}
+
+	var verbs []string
+	objects := make(map[string][]string)
+	for _, file := range files {
+		op := operationOf(file)
+		if _, ok := objects[op.verb]; !ok {
+			verbs = append(verbs, op.verb)
+		}
+		objects[op.verb] = append(objects[op.verb], op.object)
+	}
+	// Additions come first, then edits, removals and recipient changes
+	order := []string{"add", "edit", "remove", "set GPG id for", "deinitialize GPG id for"}
+	slices.SortStableFunc(verbs, func(a, b string) int {
+		return slices.Index(order, a) - slices.Index(order, b)
+	})
+
+	groups := make([]string, len(verbs))
+	for i, verb := range verbs {
+		groups[i] = verb + " " + strings.Join(objects[verb], ", ")
+	}
+	message := strings.Join(groups, "; ") + "."
+	return strings.ToUpper(message[:1]) + message[1:]
+}
+
+// singleCommitMessage is the message pass uses for a change to one file
+func singleCommitMessage(root string, file gitrepo.FileStatus) string {
+	change := ChangeOf(file)
+	switch {
+	case path.Ext(file.Path) == ".gpg":
+		name := strings.TrimSuffix(file.Path, ".gpg")
+		switch change {
+		case New:
+			return fmt.Sprintf("Add given password for %s to store.", name)
+		case Deleted:
+			return fmt.Sprintf("Remove %s from store.", name)
+		}
+		return fmt.Sprintf("Edit password for %s using gpg_viewer.", name)
+	case path.Base(file.Path) == ".gpg-id":
+		folder := gpgIDFolder(file.Path)
+		if change == Deleted {
+			return fmt.Sprintf("Deinitialize %s%s.", file.Path, inFolder(folder))
+		}
+		ids, err := recipients.ReadGpgIDFile(filepath.Join(root, filepath.FromSlash(file.Path)))
+		if err != nil || len(ids) == 0 {
+			return fmt.Sprintf("Set GPG id%s.", inFolder(folder))
+		}
+		return fmt.Sprintf("Set GPG id to %s%s.", strings.Join(ids, ", "), inFolder(folder))
+	}
+	op := operationOf(file)
+	return strings.ToUpper(op.verb[:1]) + op.verb[1:] + " " + op.object + "."
+}
+
+// operationOf describes the change to a file for a combined commit message
+func operationOf(file gitrepo.FileStatus) operation {
+	verb := "edit"
+	switch ChangeOf(file) {
+	case New:
+		verb = "add"
+	case Deleted:
+		verb = "remove"
+	}
+	switch {
+	case path.Ext(file.Path) == ".gpg":
+		return operation{verb: verb, object: strings.TrimSuffix(file.Path, ".gpg")}
+	case path.Base(file.Path) == ".gpg-id":
+		object := gpgIDFolder(file.Path)
+		if object == "" {
+			object = "the store"
+		}
+		if verb == "remove" {
+			return operation{verb: "deinitialize GPG id for", object: object}
+		}
+		return operation{verb: "set GPG id for", object: object}
+	}
+	return operation{verb: verb, object: file.Path}
+}
+
+// gpgIDFolder returns the folder a .gpg-id file applies to, "" for the store root
+func gpgIDFolder(p string) string {
+	if dir := path.Dir(p); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+// inFolder formats a folder like pass does in commit messages, as " (work)"
+func inFolder(folder string) string {
+	if folder == "" {
+		return ""
+	}
+	return " (" + folder + ")"
+}
@@ -0,0 +1,86 @@
+package storeops
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main.go/gitrepo"
+	"main.go/internal/gittest"
+)
+
+func TestIsStoreFile(t *testing.T) {
+	assert.True(t, IsStoreFile("work/github.gpg"))
+	assert.True(t, IsStoreFile(".gpg-id"))
+	assert.True(t, IsStoreFile("work/.gpg-id"))
+	assert.False(t, IsStoreFile("work/.github.gpg.swp"))
+	assert.False(t, IsStoreFile("notes.txt"))
+	assert.False(t, IsStoreFile("work/.gpg-id~"))
+}
+
+func TestCommitMessageSingle(t *testing.T) {
+	root := newTestStore(t, "work/github.gpg")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", ".gpg-id"), []byte("alice@example.com\nbob@example.com\n"), 0644))
+
+	tests := []struct {
+		file     gitrepo.FileStatus
+		expected string
+	}{
+		{gitrepo.FileStatus{Path: "work/github.gpg", Staging: '?', Worktree: '?'}, "Add given password for work/github to store."},
+		{gitrepo.FileStatus{Path: "work/github.gpg", Staging: ' ', Worktree: 'M'}, "Edit password for work/github using gpg_viewer."},
+		{gitrepo.FileStatus{Path: "work/github.gpg", Staging: 'D', Worktree: ' '}, "Remove work/github from store."},
+		{gitrepo.FileStatus{Path: "work/.gpg-id", Staging: ' ', Worktree: 'M'}, "Set GPG id to alice@example.com, bob@example.com (work)."},
+		{gitrepo.FileStatus{Path: ".gpg-id", Staging: ' ', Worktree: 'D'}, "Deinitialize .gpg-id."},
+		{gitrepo.FileStatus{Path: "notes.txt", Staging: '?', Worktree: '?'}, "Add notes.txt."},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, CommitMessage(root, []gitrepo.FileStatus{test.file}))
+	}
+	assert.Equal(t, "", CommitMessage(root, nil))
+}
+
+func TestCommitMessageCombined(t *testing.T) {
+	message := CommitMessage(t.TempDir(), []gitrepo.FileStatus{
+		{Path: "personal/bank.gpg", Staging: ' ', Worktree: 'D'},
+		{Path: "personal/mail.gpg", Staging: ' ', Worktree: 'M'},
+		{Path: "work/.gpg-id", Staging: 'M', Worktree: ' '},
+		{Path: "work/github.gpg", Staging: 'M', Worktree: 'M'},
+		{Path: "work/gitlab.gpg", Staging: '?', Worktree: '?'},
+	})
+	assert.Equal(t, "Add work/gitlab; edit personal/mail, work/github; remove personal/bank; set GPG id for work.", message)
+}
+
+func TestCommitFiles(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg", "personal/bank.gpg", "personal/mail.gpg")
+	repo := gitrepo.NewCLI(root)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", "github.gpg"), []byte("changed"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", "gitlab.gpg"), []byte("new"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", ".github.gpg.swp"), []byte("plaintext"), 0644))
+	require.NoError(t, Remove(root, "personal/bank"))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "personal", "mail.gpg"), []byte("staged elsewhere"), 0644))
+	gittest.Git(t, root, "add", "personal/mail.gpg")
+
+	err := CommitFiles(repo, "Sweep everything", []string{"work/github.gpg", "work/.github.gpg.swp"}, false)
+	assert.ErrorIs(t, err, ErrNotStoreFile)
+	assert.Equal(t, "Initial commit\n", gittest.Git(t, root, "log", "-1", "--format=%s"))
+
+	require.NoError(t, CommitFiles(repo, "Edit work; remove personal/bank.", []string{"work/github.gpg", "work/gitlab.gpg", "personal/bank.gpg"}, false))
+	assert.Equal(t, "Edit work; remove personal/bank.\n", gittest.Git(t, root, "log", "-1", "--format=%s"))
+	files := gittest.Git(t, root, "show", "--name-status", "--format=", "HEAD")
+	assert.Equal(t, []string{"D\tpersonal/bank.gpg", "M\twork/github.gpg", "A\twork/gitlab.gpg"}, strings.Split(strings.TrimSpace(files), "\n"))
+
+	// The change staged before was not chosen, so it is still staged but not committed
+	assert.Equal(t, "M  personal/mail.gpg\n?? work/.github.gpg.swp\n", gittest.Git(t, root, "status", "--porcelain"))
+
+	require.NoError(t, CommitFiles(repo, "Add notes.", []string{"work/.github.gpg.swp"}, true))
+	assert.Equal(t, "M  personal/mail.gpg\n", gittest.Git(t, root, "status", "--porcelain"))
+}
+
+func TestCommitFilesNothing(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg")
+	assert.ErrorIs(t, CommitFiles(gitrepo.NewCLI(root), "Nothing.", nil, false), gitrepo.ErrNothingToCommit)
+}
@@ -0,0 +1,80 @@
+package storeops
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"main.go/gitrepo"
+)
+
+// IsGitRepo reports whether the store is a git repository, which pass decides by
+// looking for .git in the store root
+func IsGitRepo(root string) bool {
+	_, err := os.Stat(filepath.Join(root, ".git"))
+	return err == nil
+}
+
+// Commit stages the given store-relative paths, including removed ones, and commits
+// only those paths with message. Folders stand for the entries and .gpg-id files in
+// them, on disk and in the last commit, so other files below a moved or restored
+// folder, such as editor swap files, stay out. Changes staged elsewhere in the store
+// are left alone. Nothing is committed when the store is not a git repository or the
+// paths did not change.
+func Commit(root, message string, paths ...string) error {
+	if !IsGitRepo(root) || len(paths) == 0 {
+		return nil
+	}
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return err
+	}
+	files, err := storeFiles(root, repo, paths)
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	if err := repo.CommitPaths(message, files...); err != nil && !errors.Is(err, gitrepo.ErrNothingToCommit) {
+		return err
+	}
+	return nil
+}
+
+// storeFiles replaces the folders among paths with the entries and .gpg-id files
+// below them in the store and at HEAD
+func storeFiles(root string, repo gitrepo.Repository, paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		if IsStoreFile(p) {
+			files = append(files, p)
+			continue
+		}
+		dir := filepath.Join(root, filepath.FromSlash(p))
+		err := filepath.WalkDir(dir, func(full string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, full)
+			if err == nil && !d.IsDir() && IsStoreFile(rel) {
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return err
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error listing %s: %w", p, err)
+		}
+		tracked, err := repo.Files("HEAD", p+"/")
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range tracked {
+			if IsStoreFile(file) {
+				files = append(files, file)
+			}
+		}
+	}
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
@@ -0,0 +1,96 @@
+package storeops
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main.go/internal/gittest"
+)
+
+// newTestRepo creates a store that is a git repository with the given files committed
+func newTestRepo(t *testing.T, files ...string) string {
+	root := newTestStore(t, files...)
+	gittest.Init(t, root)
+	return root
+}
+
+func TestIsGitRepo(t *testing.T) {
+	assert.False(t, IsGitRepo(newTestStore(t, "root1.gpg")))
+	assert.True(t, IsGitRepo(newTestRepo(t, "root1.gpg")))
+}
+
+func TestCommitOnlyGivenPaths(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg", "work/gitlab.gpg", "personal/bank.gpg")
+
+	require.NoError(t, Remove(root, "work/"))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "personal", "bank.gpg"), []byte("changed"), 0644))
+	require.NoError(t, Commit(root, "Remove work from store.", DiskPath("work/")))
+
+	assert.Equal(t, "Remove work from store.\n", gittest.Git(t, root, "log", "-1", "--format=%s"))
+	files := gittest.Git(t, root, "show", "--name-status", "--format=", "HEAD")
+	assert.Equal(t, []string{"D\twork/github.gpg", "D\twork/gitlab.gpg"}, strings.Split(strings.TrimSpace(files), "\n"))
+
+	// The unrelated edit is still waiting to be committed
+	assert.Equal(t, " M personal/bank.gpg\n", gittest.Git(t, root, "status", "--porcelain"))
+}
+
+func TestCommitWithoutChanges(t *testing.T) {
+	root := newTestRepo(t, "root1.gpg")
+
+	require.NoError(t, Commit(root, "Nothing", "root1.gpg", "never-existed.gpg"))
+	assert.Equal(t, "Initial commit\n", gittest.Git(t, root, "log", "-1", "--format=%s"))
+
+	// Without a repository there is nothing to commit to
+	assert.NoError(t, Commit(newTestStore(t, "root1.gpg"), "Nothing", "root1.gpg"))
+}
+
+func TestCommitMove(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg", "personal/bank.gpg")
+
+	paths, err := Move(root, "work/", "job/", nil)
+	require.NoError(t, err)
+	require.NoError(t, Commit(root, "Rename work to job.", paths...))
+
+	assert.Equal(t, "Rename work to job.\n", gittest.Git(t, root, "log", "-1", "--format=%s"))
+	files := gittest.Git(t, root, "show", "-M", "--name-status", "--format=", "HEAD")
+	assert.Equal(t, "R100\twork/github.gpg\tjob/github.gpg", strings.TrimSpace(files))
+	assert.Empty(t, gittest.Git(t, root, "status", "--porcelain"))
+}
+
+func TestCommitFolderOnlyStoreFiles(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg", "personal/bank.gpg")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", ".github.swp"), []byte("plaintext"), 0644))
+
+	paths, err := Move(root, "work/", "job/", nil)
+	require.NoError(t, err)
+	require.NoError(t, Commit(root, "Rename work to job.", paths...))
+
+	files := gittest.Git(t, root, "show", "-M", "--name-status", "--format=", "HEAD")
+	assert.Equal(t, "R100\twork/github.gpg\tjob/github.gpg", strings.TrimSpace(files))
+	assert.Equal(t, "?? job/.github.swp\n", gittest.Git(t, root, "status", "--porcelain"))
+}
+
+func TestCommitConcurrent(t *testing.T) {
+	root := newTestRepo(t, "root1.gpg", "root2.gpg", "root3.gpg")
+
+	// Background commits must not fail on each other's index.lock
+	names := []string{"root1.gpg", "root2.gpg", "root3.gpg"}
+	errs := make(chan error, len(names))
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte("changed"), 0644))
+		go func(name string) {
+			errs <- Commit(root, "Edit password for "+strings.TrimSuffix(name, ".gpg")+" using gpg_viewer.", name)
+		}(name)
+	}
+	for range names {
+		assert.NoError(t, <-errs)
+	}
+
+	assert.Equal(t, "4\n", gittest.Git(t, root, "rev-list", "--count", "HEAD"))
+	assert.Empty(t, gittest.Git(t, root, "status", "--porcelain"))
+}
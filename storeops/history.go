@@ -0,0 +1,62 @@
+package storeops
+
+import (
+	"strings"
+	"time"
+
+	"main.go/gitrepo"
+)
+
+// ErrNotGitRepo is returned for history operations on a store without git
+var ErrNotGitRepo = gitrepo.ErrNotRepository
+
+// Revision is a commit that changed an entry
+type Revision struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Subject string
+	Path    string // Store-relative path in this commit, which differs before an entry was renamed
+	Deleted bool   // The commit removed the entry, so there is nothing to show
+}
+
+// Log lists the commits that changed the entry or directory with the given node
+// ID, newest first. Entries are followed across renames like git log --follow,
+// which git only supports for single files.
+func Log(root, id string) ([]Revision, error) {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	p := DiskPath(id)
+	if p == "" {
+		return nil, ErrStoreRoot
+	}
+
+	var commits []gitrepo.Commit
+	if strings.HasSuffix(id, "/") {
+		commits, err = repo.Log(p)
+		for i := range commits {
+			commits[i].Path = p
+		}
+	} else {
+		commits, err = repo.Follow(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]Revision, len(commits))
+	for i, commit := range commits {
+		revisions[i] = Revision(commit)
+	}
+	return revisions, nil
+}
+
+// Show returns the contents of a store-relative path at a revision
+func Show(root, hash, p string) ([]byte, error) {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Show(hash, p)
+}
@@ -0,0 +1,76 @@
+package storeops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogFollowsRenames(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg", "personal/bank.gpg")
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", "github.gpg"), []byte("second"), 0644))
+	require.NoError(t, Commit(root, "Edit password for work/github using gpg_viewer.", "work/github.gpg"))
+	paths, err := Move(root, "work/github", "job/github", nil)
+	require.NoError(t, err)
+	require.NoError(t, Commit(root, "Rename work/github to job/github.", paths...))
+
+	revisions, err := Log(root, "job/github")
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	assert.Equal(t, "Rename work/github to job/github.", revisions[0].Subject)
+	assert.Equal(t, "job/github.gpg", revisions[0].Path)
+	assert.Equal(t, "Edit password for work/github using gpg_viewer.", revisions[1].Subject)
+	assert.Equal(t, "work/github.gpg", revisions[1].Path)
+	assert.Equal(t, "Initial commit", revisions[2].Subject)
+	assert.Equal(t, "Test", revisions[2].Author)
+	assert.False(t, revisions[2].Date.IsZero())
+
+	// Old revisions are read from the path the entry had back then
+	content, err := Show(root, revisions[2].Hash, revisions[2].Path)
+	require.NoError(t, err)
+	assert.Equal(t, "test content", string(content))
+	content, err = Show(root, revisions[1].Hash, revisions[1].Path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+}
+
+func TestLogDeleted(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg", "personal/bank.gpg")
+
+	require.NoError(t, Remove(root, "work/github"))
+	require.NoError(t, Commit(root, "Remove work/github from store.", "work/github.gpg"))
+
+	revisions, err := Log(root, "work/github")
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.True(t, revisions[0].Deleted)
+	assert.False(t, revisions[1].Deleted)
+}
+
+func TestLogErrors(t *testing.T) {
+	_, err := Log(newTestStore(t, "root1.gpg"), "root1")
+	assert.ErrorIs(t, err, ErrNotGitRepo)
+
+	_, err = Log(newTestRepo(t, "work/github.gpg"), "")
+	assert.ErrorIs(t, err, ErrStoreRoot)
+}
+
+func TestLogDir(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg", "personal/bank.gpg")
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "personal", "bank.gpg"), []byte("second"), 0644))
+	require.NoError(t, Commit(root, "Edit password for personal/bank using gpg_viewer.", "personal/bank.gpg"))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", "github.gpg"), []byte("second"), 0644))
+	require.NoError(t, Commit(root, "Edit password for work/github using gpg_viewer.", "work/github.gpg"))
+
+	revisions, err := Log(root, "work/")
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "Edit password for work/github using gpg_viewer.", revisions[0].Subject)
+	assert.Equal(t, "work", revisions[0].Path)
+	assert.Equal(t, "Initial commit", revisions[1].Subject)
+}
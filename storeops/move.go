@@ -0,0 +1,158 @@
+package storeops
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"main.go/internal/fsutil"
+	"main.go/recipients"
+)
+
+// ErrExists is returned when the destination of a move is already taken
+var ErrExists = errors.New("destination already exists")
+
+// Reencrypter decrypts an entry and encrypts it again for the given recipients
+type Reencrypter func(ciphertext []byte, gpgIDs []string) ([]byte, error)
+
+// reencryption is an entry whose recipients change with a move
+type reencryption struct {
+	newPath    string // Full path after the move
+	ciphertext []byte // Content encrypted for the new recipients
+}
+
+// Move renames the entry or directory with node ID from to the node ID to, like
+// pass mv. Both IDs must be of the same kind. Entries whose nearest .gpg-id differs
+// at the destination are re-encrypted with reencrypt before anything on disk
+// changes, so a failing re-encryption leaves the store untouched. Move returns the
+// store-relative paths to pass to Commit.
+func Move(root, from, to string, reencrypt Reencrypter) ([]string, error) {
+	// .gpg-id lookups return absolute paths, which are compared with the source
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	source, err := fullPath(root, from)
+	if err != nil {
+		return nil, err
+	}
+	target, err := fullPath(root, to)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(from, "/") != strings.HasSuffix(to, "/") {
+		return nil, fmt.Errorf("cannot move %s to %s: not the same kind", from, to)
+	}
+	if source == target {
+		return nil, nil
+	}
+	if strings.HasPrefix(target, source+string(filepath.Separator)) {
+		return nil, fmt.Errorf("cannot move %s into itself", from)
+	}
+	if _, err := os.Lstat(source); err != nil {
+		return nil, fmt.Errorf("error finding %s: %w", from, err)
+	}
+	if _, err := os.Lstat(target); err == nil {
+		return nil, fmt.Errorf("cannot move %s to %s: %w", from, to, ErrExists)
+	}
+
+	pending, err := reencryptions(root, source, target, reencrypt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return nil, fmt.Errorf("error creating folder for %s: %w", to, err)
+	}
+	if err := os.Rename(source, target); err != nil {
+		return nil, fmt.Errorf("error moving %s to %s: %w", from, to, err)
+	}
+	for _, entry := range pending {
+		if err := WriteFile(entry.newPath, entry.ciphertext); err != nil {
+			return nil, fmt.Errorf("error writing re-encrypted %s: %w", entry.newPath, err)
+		}
+	}
+	fsutil.PruneEmptyDirs(root, filepath.Dir(source))
+
+	return []string{DiskPath(from), DiskPath(to)}, nil
+}
+
+// reencryptions re-encrypts every entry below source whose recipients differ at target
+func reencryptions(root, source, target string, reencrypt Reencrypter) ([]reencryption, error) {
+	var pending []reencryption
+	err := filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(p) != ".gpg" {
+			return nil
+		}
+
+		// A .gpg-id moving along with the entry keeps its recipients
+		oldGpgID, err := recipients.FindGpgIDFile(root, filepath.Dir(p))
+		if err == nil && strings.HasPrefix(oldGpgID, source+string(filepath.Separator)) {
+			return nil
+		}
+
+		newPath := target + strings.TrimPrefix(p, source)
+		oldIDs, oldErr := recipients.ForEntry(root, p)
+		newIDs, newErr := recipients.ForEntry(root, newPath)
+		if newErr != nil || (oldErr == nil && sameRecipients(oldIDs, newIDs)) {
+			// Without a .gpg-id at the destination the entry stays as it is
+			return nil
+		}
+
+		ciphertext, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		ciphertext, err = reencrypt(ciphertext, newIDs)
+		if err != nil {
+			return fmt.Errorf("error re-encrypting %s: %w", p, err)
+		}
+		pending = append(pending, reencryption{newPath: newPath, ciphertext: ciphertext})
+		return nil
+	})
+	return pending, err
+}
+
+// sameRecipients compares recipient lists regardless of order and case
+func sameRecipients(a, b []string) bool {
+	normalize := func(ids []string) []string {
+		ids = recipients.Normalize(ids)
+		for i := range ids {
+			ids[i] = strings.ToLower(ids[i])
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	return slices.Equal(normalize(a), normalize(b))
+}
+
+// WriteFile atomically replaces filePath with data, so an entry is never left half written
+func WriteFile(filePath string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(filePath), ".gpg_viewer_*")
+	if err != nil {
+		return err
+	}
+	tmpFileName := tmpFile.Name()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFileName)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFileName)
+		return err
+	}
+	if err := os.Rename(tmpFileName, filePath); err != nil {
+		os.Remove(tmpFileName)
+		return err
+	}
+	return nil
+}
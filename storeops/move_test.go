@@ -0,0 +1,118 @@
+package storeops
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReencrypt records calls and "encrypts" by naming the recipients
+func fakeReencrypt(calls *[]string) Reencrypter {
+	return func(ciphertext []byte, gpgIDs []string) ([]byte, error) {
+		*calls = append(*calls, string(ciphertext)+" -> "+strings.Join(gpgIDs, ","))
+		return []byte("for " + strings.Join(gpgIDs, ",")), nil
+	}
+}
+
+func TestMoveEntry(t *testing.T) {
+	root := newTestStore(t, "work/github.gpg", "personal/bank.gpg")
+	var calls []string
+
+	paths, err := Move(root, "work/github", "archive/2024/github", fakeReencrypt(&calls))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work/github.gpg", "archive/2024/github.gpg"}, paths)
+	assert.FileExists(t, filepath.Join(root, "archive", "2024", "github.gpg"))
+	assert.NoDirExists(t, filepath.Join(root, "work"))
+	assert.Empty(t, calls)
+}
+
+func TestMoveDir(t *testing.T) {
+	root := newTestStore(t, "work/github.gpg", "work/old/gitlab.gpg")
+	var calls []string
+
+	paths, err := Move(root, "work/", "job/", fakeReencrypt(&calls))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work", "job"}, paths)
+	assert.FileExists(t, filepath.Join(root, "job", "github.gpg"))
+	assert.FileExists(t, filepath.Join(root, "job", "old", "gitlab.gpg"))
+	assert.NoDirExists(t, filepath.Join(root, "work"))
+	assert.Empty(t, calls)
+}
+
+func TestMoveReencrypts(t *testing.T) {
+	t.Setenv("PASSWORD_STORE_KEY", "")
+	root := newTestStore(t, ".gpg-id", "personal/bank.gpg", "team/.gpg-id", "team/db.gpg", "shared/wiki.gpg")
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gpg-id"), []byte("alice@example.com\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "team", ".gpg-id"), []byte("bob@example.com\nalice@example.com\n"), 0644))
+
+	// Moving into a folder with other recipients re-encrypts for them
+	var calls []string
+	_, err := Move(root, "personal/bank", "team/bank", fakeReencrypt(&calls))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test content -> bob@example.com,alice@example.com"}, calls)
+	content, err := os.ReadFile(filepath.Join(root, "team", "bank.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "for bob@example.com,alice@example.com", string(content))
+
+	// A folder taking its .gpg-id along keeps its recipients
+	calls = nil
+	_, err = Move(root, "team/", "shared/team/", fakeReencrypt(&calls))
+	require.NoError(t, err)
+	assert.Empty(t, calls)
+
+	// Moving within the same recipients changes nothing
+	_, err = Move(root, "shared/wiki", "wiki", fakeReencrypt(&calls))
+	require.NoError(t, err)
+	assert.Empty(t, calls)
+}
+
+func TestMoveReencryptFailureLeavesStore(t *testing.T) {
+	t.Setenv("PASSWORD_STORE_KEY", "")
+	root := newTestStore(t, "personal/bank.gpg", "team/.gpg-id")
+	failure := errors.New("passphrase required")
+
+	_, err := Move(root, "personal/", "team/personal/", func([]byte, []string) ([]byte, error) {
+		return nil, failure
+	})
+	assert.ErrorIs(t, err, failure)
+	assert.FileExists(t, filepath.Join(root, "personal", "bank.gpg"))
+	assert.NoDirExists(t, filepath.Join(root, "team", "personal"))
+}
+
+func TestMoveErrors(t *testing.T) {
+	root := newTestStore(t, "work/github.gpg", "work/gitlab.gpg")
+	var calls []string
+
+	_, err := Move(root, "work/github", "work/gitlab", fakeReencrypt(&calls))
+	assert.ErrorIs(t, err, ErrExists)
+	_, err = Move(root, "work/", "work/old/", fakeReencrypt(&calls))
+	assert.Error(t, err)
+	_, err = Move(root, "work/github", "work/", fakeReencrypt(&calls))
+	assert.Error(t, err)
+	_, err = Move(root, "missing", "found", fakeReencrypt(&calls))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = Move(root, "/", "other/", fakeReencrypt(&calls))
+	assert.ErrorIs(t, err, ErrStoreRoot)
+
+	assert.FileExists(t, filepath.Join(root, "work", "github.gpg"))
+}
+
+func TestWriteFile(t *testing.T) {
+	root := newTestStore(t, "root1.gpg")
+	filePath := filepath.Join(root, "root1.gpg")
+
+	require.NoError(t, WriteFile(filePath, []byte("new ciphertext")))
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "new ciphertext", string(content))
+
+	// No temporary files are left behind
+	files, err := os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
@@ -0,0 +1,59 @@
+package storeops
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"main.go/internal/fsutil"
+)
+
+// ErrStoreRoot is returned when an operation would affect the whole store
+var ErrStoreRoot = errors.New("refusing to change the password store root")
+
+// DiskPath converts a node ID to the store-relative path on disk with forward
+// slashes: "work/github" becomes "work/github.gpg" and "work/" becomes "work"
+func DiskPath(id string) string {
+	p := strings.Trim(path.Clean("/"+id), "/")
+	if strings.HasSuffix(id, "/") || p == "" {
+		return p
+	}
+	return p + ".gpg"
+}
+
+// fullPath resolves a node ID to a path below root, refusing the root itself
+func fullPath(root, id string) (string, error) {
+	p := DiskPath(id)
+	if p == "" {
+		return "", ErrStoreRoot
+	}
+	return filepath.Join(root, filepath.FromSlash(p)), nil
+}
+
+// Remove deletes the entry or directory with the given node ID, like pass rm -r.
+// Directory IDs end in "/". Directories left empty are removed as well.
+func Remove(root, id string) error {
+	target, err := fullPath(root, id)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(target)
+	if err != nil {
+		return fmt.Errorf("error finding %s: %w", id, err)
+	}
+
+	if info.IsDir() {
+		err = os.RemoveAll(target)
+	} else {
+		err = os.Remove(target)
+	}
+	if err != nil {
+		return fmt.Errorf("error removing %s: %w", id, err)
+	}
+
+	fsutil.PruneEmptyDirs(root, filepath.Dir(target))
+	return nil
+}
@@ -0,0 +1,65 @@
+package storeops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStore creates a store directory containing the given relative files
+func newTestStore(t *testing.T, files ...string) string {
+	tempDir, err := os.MkdirTemp("", "storeops_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	for _, rel := range files {
+		filePath := filepath.Join(tempDir, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+		require.NoError(t, os.WriteFile(filePath, []byte("test content"), 0644))
+	}
+	return tempDir
+}
+
+func TestDiskPath(t *testing.T) {
+	assert.Equal(t, "work/github.gpg", DiskPath("work/github"))
+	assert.Equal(t, "work", DiskPath("work/"))
+	assert.Equal(t, "root1.gpg", DiskPath("/root1"))
+	assert.Equal(t, "github.gpg", DiskPath("../github"))
+	assert.Equal(t, "", DiskPath("/"))
+}
+
+func TestRemoveEntry(t *testing.T) {
+	root := newTestStore(t, "work/old/github.gpg", "personal/bank.gpg", "personal/mail.gpg")
+
+	// Directories left empty disappear up to the store root
+	require.NoError(t, Remove(root, "work/old/github"))
+	assert.NoDirExists(t, filepath.Join(root, "work"))
+
+	require.NoError(t, Remove(root, "personal/bank"))
+	assert.NoFileExists(t, filepath.Join(root, "personal", "bank.gpg"))
+	assert.FileExists(t, filepath.Join(root, "personal", "mail.gpg"))
+	assert.DirExists(t, root)
+}
+
+func TestRemoveDir(t *testing.T) {
+	root := newTestStore(t, "work/github.gpg", "work/old/gitlab.gpg", "team/shared/db.gpg", "team/.gpg-id")
+
+	require.NoError(t, Remove(root, "work/"))
+	assert.NoDirExists(t, filepath.Join(root, "work"))
+
+	// A folder with a .gpg-id keeps its recipients, like with pass
+	require.NoError(t, Remove(root, "team/shared/"))
+	assert.NoDirExists(t, filepath.Join(root, "team", "shared"))
+	assert.FileExists(t, filepath.Join(root, "team", ".gpg-id"))
+}
+
+func TestRemoveErrors(t *testing.T) {
+	root := newTestStore(t, "root1.gpg")
+
+	assert.ErrorIs(t, Remove(root, "/"), ErrStoreRoot)
+	assert.ErrorIs(t, Remove(root, "missing"), os.ErrNotExist)
+	assert.FileExists(t, filepath.Join(root, "root1.gpg"))
+}
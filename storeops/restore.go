@@ -0,0 +1,125 @@
+package storeops
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"main.go/gitrepo"
+	"main.go/internal/fsutil"
+	"main.go/recipients"
+)
+
+// Restore puts the entry or directory with node ID id back to its state at commit
+// hash, where it was called oldID. Restored entries that were encrypted for other
+// recipients than the current .gpg-id lists are re-encrypted with reencrypt before
+// anything on disk changes. Entries added to a directory since that commit are
+// removed. Restore returns the store-relative paths to pass to Commit.
+func Restore(root, hash, oldID, id string, reencrypt Reencrypter) ([]string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	target, err := fullPath(root, id)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(oldID, "/") != strings.HasSuffix(id, "/") {
+		return nil, fmt.Errorf("cannot restore %s as %s: not the same kind", oldID, id)
+	}
+	isDir := strings.HasSuffix(id, "/")
+
+	// Map the entries at the commit to their current store-relative paths
+	oldPath, newPath := DiskPath(oldID), DiskPath(id)
+	restored := map[string]string{newPath: oldPath}
+	if isDir {
+		files, err := repo.Files(hash, oldPath+"/")
+		if err != nil {
+			return nil, err
+		}
+		restored = map[string]string{}
+		for _, p := range files {
+			if path.Ext(p) == ".gpg" {
+				restored[newPath+strings.TrimPrefix(p, oldPath)] = p
+			}
+		}
+		if len(restored) == 0 {
+			return nil, fmt.Errorf("%s has no entries at %s", oldID, hash)
+		}
+	}
+
+	// Read and re-encrypt everything first, so a failure leaves the store untouched
+	pending := make([]reencryption, 0, len(restored))
+	for p, old := range restored {
+		ciphertext, err := repo.Show(hash, old)
+		if err != nil {
+			return nil, err
+		}
+		entryPath := filepath.Join(root, filepath.FromSlash(p))
+		oldIDs, oldErr := gpgIDsAt(repo, hash, path.Dir(old))
+		newIDs, newErr := recipients.ForEntry(root, entryPath)
+		if newErr == nil && (oldErr != nil || !sameRecipients(oldIDs, newIDs)) {
+			if ciphertext, err = reencrypt(ciphertext, newIDs); err != nil {
+				return nil, fmt.Errorf("error re-encrypting %s: %w", old, err)
+			}
+		}
+		pending = append(pending, reencryption{newPath: entryPath, ciphertext: ciphertext})
+	}
+
+	// Entries that did not exist back then go away
+	var added []string
+	if isDir {
+		err := filepath.WalkDir(target, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(root, p)
+			if !d.IsDir() && filepath.Ext(p) == ".gpg" && restored[filepath.ToSlash(rel)] == "" {
+				added = append(added, p)
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error listing %s: %w", id, err)
+		}
+	}
+
+	for _, entry := range pending {
+		if err := os.MkdirAll(filepath.Dir(entry.newPath), 0755); err != nil {
+			return nil, fmt.Errorf("error creating folder for %s: %w", entry.newPath, err)
+		}
+		if err := WriteFile(entry.newPath, entry.ciphertext); err != nil {
+			return nil, fmt.Errorf("error writing restored %s: %w", entry.newPath, err)
+		}
+	}
+	for _, p := range added {
+		if err := os.Remove(p); err != nil {
+			return nil, fmt.Errorf("error removing %s: %w", p, err)
+		}
+		fsutil.PruneEmptyDirs(root, filepath.Dir(p))
+	}
+
+	return []string{newPath}, nil
+}
+
+// gpgIDsAt returns the recipients from the nearest .gpg-id of a store-relative
+// directory at commit hash
+func gpgIDsAt(repo gitrepo.Repository, hash, dir string) ([]string, error) {
+	for {
+		p := path.Join(dir, recipients.GpgIDFile)
+		if content, err := repo.Show(hash, p); err == nil {
+			return recipients.ReadGpgID(strings.NewReader(string(content)), hash+":"+p)
+		}
+		if dir == "." || dir == "" {
+			return nil, recipients.ErrNoGpgID
+		}
+		dir = path.Dir(dir)
+	}
+}
@@ -0,0 +1,127 @@
+package storeops
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main.go/internal/gittest"
+)
+
+// headHash returns the commit the store is at
+func headHash(t *testing.T, root string) string {
+	return gittest.Git(t, root, "rev-parse", "HEAD")[:40]
+}
+
+func TestRestoreEntry(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg", "personal/bank.gpg")
+	initial := headHash(t, root)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", "github.gpg"), []byte("bad edit"), 0644))
+	require.NoError(t, Commit(root, "Edit password for work/github using gpg_viewer.", "work/github.gpg"))
+
+	paths, err := Restore(root, initial, "work/github", "work/github", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work/github.gpg"}, paths)
+	assertFileContent(t, filepath.Join(root, "work", "github.gpg"), "test content")
+}
+
+func TestRestoreRenamedEntry(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg")
+	initial := headHash(t, root)
+
+	_, err := Move(root, "work/github", "job/github", nil)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "job", "github.gpg"), []byte("bad edit"), 0644))
+
+	// The old content is written to the current path
+	_, err = Restore(root, initial, "work/github", "job/github", nil)
+	require.NoError(t, err)
+	assertFileContent(t, filepath.Join(root, "job", "github.gpg"), "test content")
+	assert.NoFileExists(t, filepath.Join(root, "work", "github.gpg"))
+}
+
+func TestRestoreDir(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg", "work/aws/prod.gpg", "personal/bank.gpg")
+	initial := headHash(t, root)
+
+	require.NoError(t, Remove(root, "work/aws/"))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", "github.gpg"), []byte("bad edit"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "work", "new"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", "new", "entry.gpg"), []byte("added"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "personal", "bank.gpg"), []byte("unrelated"), 0644))
+
+	paths, err := Restore(root, initial, "work/", "work/", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work"}, paths)
+	assertFileContent(t, filepath.Join(root, "work", "github.gpg"), "test content")
+	assertFileContent(t, filepath.Join(root, "work", "aws", "prod.gpg"), "test content")
+	assert.NoDirExists(t, filepath.Join(root, "work", "new"))
+	assertFileContent(t, filepath.Join(root, "personal", "bank.gpg"), "unrelated")
+
+	require.NoError(t, Commit(root, "Restore work/ to its state at "+initial[:7]+".", paths...))
+	assert.Equal(t, " M personal/bank.gpg\n", gittest.Git(t, root, "status", "--porcelain"))
+}
+
+func TestRestoreReencrypts(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg", "personal/bank.gpg")
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gpg-id"), []byte("alice@example.com\n"), 0644))
+	require.NoError(t, Commit(root, "Set recipients", ".gpg-id"))
+	initial := headHash(t, root)
+
+	// The team changed since the commit, so the old content is encrypted for it
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", ".gpg-id"), []byte("alice@example.com\nbob@example.com\n"), 0644))
+	var reencrypted []string
+	_, err := Restore(root, initial, "work/", "work/", func(ciphertext []byte, gpgIDs []string) ([]byte, error) {
+		reencrypted = append(reencrypted, string(ciphertext))
+		return []byte("for " + gpgIDs[1]), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test content"}, reencrypted)
+	assertFileContent(t, filepath.Join(root, "work", "github.gpg"), "for bob@example.com")
+
+	// Unchanged recipients keep the old ciphertext
+	_, err = Restore(root, initial, "personal/bank", "personal/bank", func([]byte, []string) ([]byte, error) {
+		return nil, errors.New("should not re-encrypt")
+	})
+	assert.NoError(t, err)
+}
+
+func TestRestoreFailureLeavesStore(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg")
+	initial := headHash(t, root)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", ".gpg-id"), []byte("bob@example.com\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", "github.gpg"), []byte("current"), 0644))
+
+	_, err := Restore(root, initial, "work/github", "work/github", func([]byte, []string) ([]byte, error) {
+		return nil, errors.New("no secret key")
+	})
+	assert.ErrorContains(t, err, "no secret key")
+	assertFileContent(t, filepath.Join(root, "work", "github.gpg"), "current")
+}
+
+func TestRestoreErrors(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg")
+	initial := headHash(t, root)
+
+	_, err := Restore(newTestStore(t, "work/github.gpg"), initial, "work/github", "work/github", nil)
+	assert.ErrorIs(t, err, ErrNotGitRepo)
+	_, err = Restore(root, initial, "work/", "work/github", nil)
+	assert.Error(t, err)
+	_, err = Restore(root, initial, "personal/", "personal/", nil)
+	assert.Error(t, err, "the folder did not exist")
+	_, err = Restore(root, initial, "personal/bank", "personal/bank", nil)
+	assert.Error(t, err, "the entry did not exist")
+	assert.NoDirExists(t, filepath.Join(root, "personal"))
+}
+
+// assertFileContent checks the content of a file in the store
+func assertFileContent(t *testing.T, filePath, expected string) {
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(content))
+}
@@ -0,0 +1,140 @@
+package storeops
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"main.go/gitrepo"
+)
+
+// Change is how a file in the store differs from the last commit
+type Change int
+
+const (
+	Unchanged Change = iota
+	Modified
+	New        // Untracked or newly staged
+	Deleted    // Removed from the working tree or the index
+	Conflicted // Changed on both sides of a merge or rebase
+)
+
+// changeOrder is the order of the counts in folder badges, most urgent first
+var changeOrder = []Change{Conflicted, Modified, New, Deleted}
+
+// Badge is the letter shown next to a changed entry in the tree
+func (c Change) Badge() string {
+	switch c {
+	case Modified:
+		return "M"
+	case New:
+		return "N"
+	case Deleted:
+		return "D"
+	case Conflicted:
+		return "C"
+	}
+	return ""
+}
+
+// ChangeOf classifies a file from git status. Unmerged files are reported by
+// git with a U on either side, or as added or deleted on both sides.
+func ChangeOf(file gitrepo.FileStatus) Change {
+	x, y := file.Staging, file.Worktree
+	switch {
+	case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+		return Conflicted
+	case x == 'D' || y == 'D':
+		return Deleted
+	case x == '?' || x == 'A' || x == 'R' || x == 'C':
+		return New
+	case x == 'M' || y == 'M':
+		return Modified
+	}
+	return Unchanged
+}
+
+// StoreChanges holds the uncommitted changes of a store by node ID
+type StoreChanges struct {
+	entries map[string]Change         // Changes of entries by node ID
+	folders map[string]map[Change]int // Changed files below each folder ID, "" for the store root
+}
+
+// NewStoreChanges sorts the files from git status into entries and folders.
+// Entries are .gpg files; every other file only counts towards its folders.
+func NewStoreChanges(files []gitrepo.FileStatus) *StoreChanges {
+	changes := &StoreChanges{
+		entries: make(map[string]Change),
+		folders: make(map[string]map[Change]int),
+	}
+	for _, file := range files {
+		change := ChangeOf(file)
+		if change == Unchanged {
+			continue
+		}
+		if strings.HasSuffix(file.Path, ".gpg") {
+			changes.entries[strings.TrimSuffix(file.Path, ".gpg")] = change
+		}
+		for dir := path.Dir(file.Path); ; dir = path.Dir(dir) {
+			id := dir + "/"
+			if dir == "." {
+				id = ""
+			}
+			if changes.folders[id] == nil {
+				changes.folders[id] = make(map[Change]int)
+			}
+			changes.folders[id][change]++
+			if id == "" {
+				break
+			}
+		}
+	}
+	return changes
+}
+
+// LoadChanges reads the uncommitted changes of a git store
+func LoadChanges(root string) (*StoreChanges, error) {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	files, err := repo.Status()
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreChanges(files), nil
+}
+
+// Entry returns how the entry with the given node ID changed
+func (c *StoreChanges) Entry(id string) Change {
+	if c == nil {
+		return Unchanged
+	}
+	return c.entries[id]
+}
+
+// Folder counts the changed files below the folder with the given node ID by
+// kind of change. The store root has the ID "".
+func (c *StoreChanges) Folder(id string) map[Change]int {
+	if c == nil {
+		return nil
+	}
+	return c.folders[id]
+}
+
+// Badge describes the changes of a node for the tree: the letter of an entry,
+// such as "M", or the counts below a folder, such as "M2 N1". Unchanged nodes
+// have no badge.
+func (c *StoreChanges) Badge(id string) string {
+	if id != "" && !strings.HasSuffix(id, "/") {
+		return c.Entry(id).Badge()
+	}
+	counts := c.Folder(id)
+	var parts []string
+	for _, change := range changeOrder {
+		if counts[change] > 0 {
+			parts = append(parts, fmt.Sprintf("%s%d", change.Badge(), counts[change]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,86 @@
+package storeops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main.go/gitrepo"
+)
+
+func TestChangeOf(t *testing.T) {
+	tests := []struct {
+		staging, worktree byte
+		expected          Change
+	}{
+		{' ', 'M', Modified},
+		{'M', ' ', Modified},
+		{'M', 'M', Modified},
+		{'?', '?', New},
+		{'A', ' ', New},
+		{'A', 'M', New},
+		{'R', ' ', New},
+		{' ', 'D', Deleted},
+		{'D', ' ', Deleted},
+		{'U', 'U', Conflicted},
+		{'A', 'A', Conflicted},
+		{'D', 'D', Conflicted},
+		{'U', 'D', Conflicted},
+		{' ', ' ', Unchanged},
+	}
+	for _, test := range tests {
+		file := gitrepo.FileStatus{Path: "entry.gpg", Staging: test.staging, Worktree: test.worktree}
+		assert.Equal(t, test.expected, ChangeOf(file), "%q", string([]byte{test.staging, test.worktree}))
+	}
+}
+
+func TestStoreChangesBadges(t *testing.T) {
+	changes := NewStoreChanges([]gitrepo.FileStatus{
+		{Path: "work/github.gpg", Staging: ' ', Worktree: 'M'},
+		{Path: "work/gitlab.gpg", Staging: '?', Worktree: '?'},
+		{Path: "work/old.gpg", Staging: ' ', Worktree: 'D'},
+		{Path: "work/.gpg-id", Staging: 'M', Worktree: ' '},
+		{Path: "work/servers/db.gpg", Staging: 'U', Worktree: 'U'},
+		{Path: "root.gpg", Staging: 'A', Worktree: ' '},
+	})
+
+	assert.Equal(t, "M", changes.Badge("work/github"))
+	assert.Equal(t, "N", changes.Badge("work/gitlab"))
+	assert.Equal(t, "D", changes.Badge("work/old"))
+	assert.Equal(t, "C", changes.Badge("work/servers/db"))
+	assert.Equal(t, "N", changes.Badge("root"))
+	assert.Equal(t, "", changes.Badge("personal/bank"))
+
+	// Folders count every changed file below them, .gpg-id files included
+	assert.Equal(t, "C1 M2 N1 D1", changes.Badge("work/"))
+	assert.Equal(t, "C1", changes.Badge("work/servers/"))
+	assert.Equal(t, "C1 M2 N2 D1", changes.Badge(""))
+	assert.Equal(t, "", changes.Badge("personal/"))
+	assert.Equal(t, map[Change]int{Conflicted: 1}, changes.Folder("work/servers/"))
+}
+
+func TestStoreChangesNil(t *testing.T) {
+	var changes *StoreChanges
+	assert.Equal(t, Unchanged, changes.Entry("work/github"))
+	assert.Equal(t, "", changes.Badge("work/"))
+}
+
+func TestLoadChanges(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg", "personal/bank.gpg")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", "github.gpg"), []byte("changed"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", "gitlab.gpg"), []byte("new"), 0644))
+	require.NoError(t, Remove(root, "personal/bank"))
+
+	changes, err := LoadChanges(root)
+	require.NoError(t, err)
+	assert.Equal(t, Modified, changes.Entry("work/github"))
+	assert.Equal(t, New, changes.Entry("work/gitlab"))
+	assert.Equal(t, Deleted, changes.Entry("personal/bank"))
+	assert.Equal(t, "M1 N1 D1", changes.Badge(""))
+
+	_, err = LoadChanges(newTestStore(t, "root.gpg"))
+	assert.ErrorIs(t, err, gitrepo.ErrNotRepository)
+}
@@ -0,0 +1,128 @@
+package storeops
+
+import "main.go/gitrepo"
+
+// ErrNoUpstream is returned when the current branch does not track a remote branch
+var ErrNoUpstream = gitrepo.ErrNoUpstream
+
+// ConflictError is returned when a rebase stops because files were changed on both
+// sides. The rebase stays in progress until every path is resolved with
+// ResolveConflict and ContinueRebase is called, or AbortRebase gives up.
+type ConflictError = gitrepo.ConflictError
+
+// ConflictVersions holds the versions of a conflicted file. A nil version did not
+// exist, for example because the file was removed on that side.
+type ConflictVersions = gitrepo.ConflictVersions
+
+// PullRebase pulls from the remote and rebases local commits onto it, like
+// git pull --rebase. Conflicts are returned as a *ConflictError.
+func PullRebase(root string) error {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return err
+	}
+	return repo.Pull()
+}
+
+// Fetch downloads the upstream branch without changing the store
+func Fetch(root string) error {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return err
+	}
+	return repo.Fetch()
+}
+
+// Push uploads local commits to the upstream branch
+func Push(root string) error {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return err
+	}
+	return repo.Push()
+}
+
+// AheadBehind counts the local commits not pushed yet and the fetched upstream
+// commits not pulled yet
+func AheadBehind(root string) (ahead, behind int, err error) {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return 0, 0, err
+	}
+	return repo.AheadBehind()
+}
+
+// HasLocalChanges reports whether tracked files have changes that are not committed,
+// which keep a pull from running
+func HasLocalChanges(root string) (bool, error) {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return false, err
+	}
+	files, err := repo.Status()
+	if err != nil {
+		return false, err
+	}
+	for _, file := range files {
+		if file.Staging != '?' {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RebaseInProgress reports whether a rebase stopped in the store, for example
+// because of conflicts
+func RebaseInProgress(root string) bool {
+	repo, err := gitrepo.Open(root)
+	return err == nil && repo.RebaseInProgress()
+}
+
+// Conflicts lists the store-relative paths that are still conflicted
+func Conflicts(root string) ([]string, error) {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Conflicts()
+}
+
+// Versions reads the base, remote and local versions of a conflicted path
+func Versions(root, p string) (*ConflictVersions, error) {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Versions(p)
+}
+
+// ResolveConflict marks a conflicted path as resolved with whatever is in the store
+// now. A removed file resolves the conflict by removing it.
+func ResolveConflict(root, p string) error {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return err
+	}
+	return repo.Resolve(p)
+}
+
+// ContinueRebase continues a rebase after every conflict was resolved. Conflicts
+// in later commits are returned as a *ConflictError. Local commits whose changes
+// the resolution dropped entirely are skipped.
+func ContinueRebase(root string) error {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return err
+	}
+	return repo.ContinueRebase()
+}
+
+// AbortRebase gives up a rebase in progress and returns the store to where it was
+// before pulling
+func AbortRebase(root string) error {
+	repo, err := gitrepo.Open(root)
+	if err != nil {
+		return err
+	}
+	return repo.AbortRebase()
+}
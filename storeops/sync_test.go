@@ -0,0 +1,128 @@
+package storeops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"main.go/internal/gittest"
+)
+
+// newTestConflict leaves the second clone in a rebase stopped on work/github.gpg
+func newTestConflict(t *testing.T) (string, string) {
+	_, first, second := gittest.Clones(t, "work/github.gpg", "personal/bank.gpg")
+	gittest.CommitFile(t, first, "work/github.gpg", "remote edit")
+	gittest.Git(t, first, "push", "-q")
+	gittest.CommitFile(t, second, "work/github.gpg", "local edit")
+
+	err := PullRebase(second)
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, []string{"work/github.gpg"}, conflict.Paths)
+	assert.True(t, RebaseInProgress(second))
+	return first, second
+}
+
+func TestPullRebaseWithoutConflicts(t *testing.T) {
+	_, first, second := gittest.Clones(t, "work/github.gpg", "personal/bank.gpg")
+	gittest.CommitFile(t, first, "work/github.gpg", "remote edit")
+	gittest.Git(t, first, "push", "-q")
+	gittest.CommitFile(t, second, "personal/bank.gpg", "local edit")
+
+	require.NoError(t, PullRebase(second))
+	assert.False(t, RebaseInProgress(second))
+	assertFileContent(t, filepath.Join(second, "work", "github.gpg"), "remote edit")
+	assertFileContent(t, filepath.Join(second, "personal", "bank.gpg"), "local edit")
+
+	assert.ErrorIs(t, PullRebase(newTestStore(t, "root1.gpg")), ErrNotGitRepo)
+}
+
+func TestResolveConflict(t *testing.T) {
+	_, second := newTestConflict(t)
+
+	versions, err := Versions(second, "work/github.gpg")
+	require.NoError(t, err)
+	assert.Equal(t, "test content", string(versions.Base))
+	assert.Equal(t, "remote edit", string(versions.Remote))
+	assert.Equal(t, "local edit", string(versions.Local))
+
+	require.NoError(t, os.WriteFile(filepath.Join(second, "work", "github.gpg"), []byte("merged"), 0644))
+	require.NoError(t, ResolveConflict(second, "work/github.gpg"))
+	conflicts, err := Conflicts(second)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+
+	require.NoError(t, ContinueRebase(second))
+	assert.False(t, RebaseInProgress(second))
+	assert.Equal(t, "Edit password for work/github.gpg using gpg_viewer.\n", gittest.Git(t, second, "log", "-1", "--format=%s"))
+	assert.Equal(t, "3\n", gittest.Git(t, second, "rev-list", "--count", "HEAD"))
+	assertFileContent(t, filepath.Join(second, "work", "github.gpg"), "merged")
+}
+
+func TestResolveConflictWithRemoteVersion(t *testing.T) {
+	_, second := newTestConflict(t)
+
+	// Taking the remote version leaves nothing of the local commit
+	require.NoError(t, os.WriteFile(filepath.Join(second, "work", "github.gpg"), []byte("remote edit"), 0644))
+	require.NoError(t, ResolveConflict(second, "work/github.gpg"))
+	require.NoError(t, ContinueRebase(second))
+	assert.False(t, RebaseInProgress(second))
+	assert.Equal(t, gittest.Git(t, second, "rev-parse", "@{upstream}"), gittest.Git(t, second, "rev-parse", "HEAD"))
+}
+
+func TestResolveConflictByRemoving(t *testing.T) {
+	_, second := newTestConflict(t)
+
+	require.NoError(t, os.Remove(filepath.Join(second, "work", "github.gpg")))
+	require.NoError(t, ResolveConflict(second, "work/github.gpg"))
+	require.NoError(t, ContinueRebase(second))
+	assert.False(t, RebaseInProgress(second))
+	assert.NoFileExists(t, filepath.Join(second, "work", "github.gpg"))
+}
+
+func TestAbortRebase(t *testing.T) {
+	_, second := newTestConflict(t)
+
+	require.NoError(t, AbortRebase(second))
+	assert.False(t, RebaseInProgress(second))
+	assertFileContent(t, filepath.Join(second, "work", "github.gpg"), "local edit")
+}
+
+func TestAheadBehind(t *testing.T) {
+	_, first, second := gittest.Clones(t, "work/github.gpg", "personal/bank.gpg")
+	gittest.CommitFile(t, first, "work/github.gpg", "remote edit")
+	require.NoError(t, Push(first))
+	gittest.CommitFile(t, second, "personal/bank.gpg", "local edit")
+	require.NoError(t, Fetch(second))
+
+	ahead, behind, err := AheadBehind(second)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ahead)
+	assert.Equal(t, 1, behind)
+
+	// Stores without a remote have nothing to sync with
+	_, _, err = AheadBehind(newTestRepo(t, "root1.gpg"))
+	assert.ErrorIs(t, err, ErrNoUpstream)
+}
+
+func TestHasLocalChanges(t *testing.T) {
+	root := newTestRepo(t, "work/github.gpg")
+
+	changed, err := HasLocalChanges(root)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// Untracked files do not keep a rebase from running
+	require.NoError(t, os.WriteFile(filepath.Join(root, "new.gpg"), []byte("new"), 0644))
+	changed, err = HasLocalChanges(root)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "work", "github.gpg"), []byte("changed"), 0644))
+	changed, err = HasLocalChanges(root)
+	require.NoError(t, err)
+	assert.True(t, changed)
+}